@@ -13,6 +13,7 @@ we ignore assets completely!
 */
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -84,6 +85,7 @@ type Fs struct {
 	root     string             // the path we are working on
 	dirCache *dircache.DirCache // Map of directory path to directory id
 	pacer    *pacer.Pacer       // pacer for API calls
+	features *fs.Features       // optional features
 }
 
 // Object describes a acd object
@@ -137,6 +139,7 @@ func shouldRetry(resp *http.Response, err error) (bool, error) {
 
 // NewFs constructs an Fs from the path, container:path
 func NewFs(name, root string) (fs.Fs, error) {
+	ctx := context.Background()
 	root = parsePath(root)
 	oAuthClient, err := oauthutil.NewClient(name, acdConfig)
 	if err != nil {
@@ -151,10 +154,14 @@ func NewFs(name, root string) (fs.Fs, error) {
 		c:     c,
 		pacer: pacer.New().SetMinSleep(minSleep).SetPacer(pacer.AmazonCloudDrivePacer),
 	}
+	f.features = (&fs.Features{
+		CaseInsensitive:         true,
+		CanHaveEmptyDirectories: true,
+	}).Fill(f)
 
 	// Update endpoints
 	var resp *http.Response
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.Call(ctx, func() (bool, error) {
 		_, resp, err = f.c.Account.GetEndpoints()
 		return shouldRetry(resp, err)
 	})
@@ -164,7 +171,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 
 	// Get rootID
 	var rootInfo *acd.Folder
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.Call(ctx, func() (bool, error) {
 		rootInfo, resp, err = f.c.Nodes.GetRoot()
 		return shouldRetry(resp, err)
 	})
@@ -175,20 +182,22 @@ func NewFs(name, root string) (fs.Fs, error) {
 	f.dirCache = dircache.New(root, *rootInfo.Id, f)
 
 	// Find the current root
-	err = f.dirCache.FindRoot(false)
+	err = f.dirCache.FindRoot(ctx, false)
 	if err != nil {
 		// Assume it is a file
 		newRoot, remote := dircache.SplitPath(root)
 		newF := *f
+		features := *f.features
+		newF.features = features.Fill(&newF)
 		newF.dirCache = dircache.New(newRoot, *rootInfo.Id, &newF)
 		newF.root = newRoot
 		// Make new Fs which is the parent
-		err = newF.dirCache.FindRoot(false)
+		err = newF.dirCache.FindRoot(ctx, false)
 		if err != nil {
 			// No root so return old f
 			return f, nil
 		}
-		obj := newF.newFsObjectWithInfo(remote, nil)
+		obj := newF.newFsObjectWithInfo(ctx, remote, nil)
 		if obj == nil {
 			// File doesn't exist so return old f
 			return f, nil
@@ -202,7 +211,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 // Return an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) newFsObjectWithInfo(remote string, info *acd.Node) fs.Object {
+func (f *Fs) newFsObjectWithInfo(ctx context.Context, remote string, info *acd.Node) fs.Object {
 	o := &Object{
 		fs:     f,
 		remote: remote,
@@ -211,7 +220,7 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *acd.Node) fs.Object {
 		// Set info but not meta
 		o.info = info
 	} else {
-		err := o.readMetaData() // reads info and meta, returning an error
+		err := o.readMetaData(ctx) // reads info and meta, returning an error
 		if err != nil {
 			// logged already FsDebug("Failed to read info: %s", err)
 			return nil
@@ -223,17 +232,17 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *acd.Node) fs.Object {
 // NewFsObject returns an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) NewFsObject(remote string) fs.Object {
-	return f.newFsObjectWithInfo(remote, nil)
+func (f *Fs) NewFsObject(ctx context.Context, remote string) fs.Object {
+	return f.newFsObjectWithInfo(ctx, remote, nil)
 }
 
 // FindLeaf finds a directory of name leaf in the folder with ID pathID
-func (f *Fs) FindLeaf(pathID, leaf string) (pathIDOut string, found bool, err error) {
+func (f *Fs) FindLeaf(ctx context.Context, pathID, leaf string) (pathIDOut string, found bool, err error) {
 	//fs.Debug(f, "FindLeaf(%q, %q)", pathID, leaf)
 	folder := acd.FolderFromId(pathID, f.c.Nodes)
 	var resp *http.Response
 	var subFolder *acd.Folder
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.Call(ctx, func() (bool, error) {
 		subFolder, resp, err = folder.GetFolder(leaf)
 		return shouldRetry(resp, err)
 	})
@@ -246,7 +255,7 @@ func (f *Fs) FindLeaf(pathID, leaf string) (pathIDOut string, found bool, err er
 		return "", false, err
 	}
 	if subFolder.Status != nil && *subFolder.Status != statusAvailable {
-		fs.Debug(f, "Ignoring folder %q in state %q", *subFolder.Status)
+		fs.Debug(f, "Ignoring folder %q in state %q", leaf, *subFolder.Status)
 		time.Sleep(1 * time.Second) // FIXME wait for problem to go away!
 		return "", false, nil
 	}
@@ -255,12 +264,12 @@ func (f *Fs) FindLeaf(pathID, leaf string) (pathIDOut string, found bool, err er
 }
 
 // CreateDir makes a directory with pathID as parent and name leaf
-func (f *Fs) CreateDir(pathID, leaf string) (newID string, err error) {
+func (f *Fs) CreateDir(ctx context.Context, pathID, leaf string) (newID string, err error) {
 	//fmt.Printf("CreateDir(%q, %q)\n", pathID, leaf)
 	folder := acd.FolderFromId(pathID, f.c.Nodes)
 	var resp *http.Response
 	var info *acd.Folder
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.Call(ctx, func() (bool, error) {
 		info, resp, err = folder.CreateFolder(leaf)
 		return shouldRetry(resp, err)
 	})
@@ -283,7 +292,7 @@ type listAllFn func(*acd.Node) bool
 // Lists the directory required calling the user function on each item found
 //
 // If the user fn ever returns true then it early exits with found = true
-func (f *Fs) listAll(dirID string, title string, directoriesOnly bool, filesOnly bool, fn listAllFn) (found bool, err error) {
+func (f *Fs) listAll(ctx context.Context, dirID string, title string, directoriesOnly bool, filesOnly bool, fn listAllFn) (found bool, err error) {
 	query := "parents:" + dirID
 	if directoriesOnly {
 		query += " AND kind:" + folderKind
@@ -302,13 +311,11 @@ func (f *Fs) listAll(dirID string, title string, directoriesOnly bool, filesOnly
 OUTER:
 	for {
 		var resp *http.Response
-		err = f.pacer.Call(func() (bool, error) {
+		err = f.pacer.Call(ctx, func() (bool, error) {
 			nodes, resp, err = f.c.Nodes.GetNodes(&opts)
 			return shouldRetry(resp, err)
 		})
 		if err != nil {
-			fs.Stats.Error()
-			fs.ErrorLog(f, "Couldn't list files: %v", err)
 			break
 		}
 		if nodes == nil {
@@ -336,11 +343,15 @@ OUTER:
 //
 // This fetches the minimum amount of stuff but does more API calls
 // which makes it slow
-func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) error {
-	var subError error
+func (f *Fs) listDirRecursive(ctx context.Context, dirID string, path string, out fs.ListOpts) error {
+	var (
+		subErrorMu sync.Mutex
+		subError   error
+		aborted    bool
+	)
 	// Make the API request
 	var wg sync.WaitGroup
-	_, err := f.listAll(dirID, "", false, false, func(node *acd.Node) bool {
+	_, err := f.listAll(ctx, dirID, "", false, false, func(node *acd.Node) bool {
 		// Recurse on directories
 		switch *node.Kind {
 		case folderKind:
@@ -349,16 +360,20 @@ func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) err
 			fs.Debug(f, "Reading %s", folder)
 			go func() {
 				defer wg.Done()
-				err := f.listDirRecursive(*node.Id, folder, out)
+				err := f.listDirRecursive(ctx, *node.Id, folder, out)
 				if err != nil {
+					subErrorMu.Lock()
 					subError = err
-					fs.ErrorLog(f, "Error reading %s:%s", folder, err)
+					subErrorMu.Unlock()
 				}
 			}()
 			return false
 		case fileKind:
-			if fs := f.newFsObjectWithInfo(path+*node.Name, node); fs != nil {
-				out <- fs
+			if o := f.newFsObjectWithInfo(ctx, path+*node.Name, node); o != nil {
+				if out.Add(o) {
+					aborted = true
+					return true
+				}
 			}
 		default:
 			// ignore ASSET etc
@@ -367,6 +382,12 @@ func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) err
 	})
 	wg.Wait()
 	fs.Debug(f, "Finished reading %s", path)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if aborted {
+		return fs.ErrorListAborted
+	}
 	if err != nil {
 		return err
 	}
@@ -376,47 +397,90 @@ func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) err
 	return nil
 }
 
-// List walks the path returning a channel of FsObjects
-func (f *Fs) List() fs.ObjectsChan {
-	out := make(fs.ObjectsChan, fs.Config.Checkers)
-	go func() {
-		defer close(out)
-		err := f.dirCache.FindRoot(false)
-		if err != nil {
-			fs.Stats.Error()
-			fs.ErrorLog(f, "Couldn't find root: %s", err)
-		} else {
-			err = f.listDirRecursive(f.dirCache.RootID(), "", out)
-			if err != nil {
-				fs.Stats.Error()
-				fs.ErrorLog(f, "List failed: %s", err)
+// List walks the path into out
+func (f *Fs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
+	err := f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		out.SetError(err)
+		return
+	}
+	err = f.listDirRecursive(ctx, f.dirCache.RootID(), "", out)
+	if err != nil && err != fs.ErrorListAborted {
+		out.SetError(err)
+	}
+}
+
+// ListDirEntries lists the objects and directories directly in dir
+func (f *Fs) ListDirEntries(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	err = f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	_, err = f.listAll(ctx, directoryID, "", false, false, func(node *acd.Node) bool {
+		remote := prefix + *node.Name
+		switch *node.Kind {
+		case folderKind:
+			// Cache the directory for when it is listed
+			f.dirCache.Put(remote, *node.Id)
+			d := &fs.Dir{
+				Name:  remote,
+				Bytes: -1,
+				Count: -1,
+			}
+			d.When, _ = time.Parse(timeFormat, *node.ModifiedDate)
+			dirs = append(dirs, d)
+		case fileKind:
+			if o := f.newFsObjectWithInfo(ctx, remote, node); o != nil {
+				objects = append(objects, o)
 			}
+		default:
+			// ignore ASSET etc
 		}
-	}()
-	return out
+		return ctx.Err() != nil
+	})
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return objects, dirs, nil
 }
 
 // ListDir lists the directories
-func (f *Fs) ListDir() fs.DirChan {
+func (f *Fs) ListDir(ctx context.Context) fs.DirChan {
 	out := make(fs.DirChan, fs.Config.Checkers)
 	go func() {
 		defer close(out)
-		err := f.dirCache.FindRoot(false)
+		err := f.dirCache.FindRoot(ctx, false)
 		if err != nil {
 			fs.Stats.Error()
 			fs.ErrorLog(f, "Couldn't find root: %s", err)
 		} else {
-			_, err := f.listAll(f.dirCache.RootID(), "", true, false, func(item *acd.Node) bool {
+			_, err := f.listAll(ctx, f.dirCache.RootID(), "", true, false, func(item *acd.Node) bool {
 				dir := &fs.Dir{
 					Name:  *item.Name,
 					Bytes: -1,
 					Count: -1,
 				}
 				dir.When, _ = time.Parse(timeFormat, *item.ModifiedDate)
-				out <- dir
+				select {
+				case out <- dir:
+				case <-ctx.Done():
+					return true
+				}
 				return false
 			})
-			if err != nil {
+			if err != nil && ctx.Err() == nil {
 				fs.Stats.Error()
 				fs.ErrorLog(f, "ListDir failed: %s", err)
 			}
@@ -430,20 +494,21 @@ func (f *Fs) ListDir() fs.DirChan {
 // Copy the reader in to the new object which is returned
 //
 // The new object may have been created if an error is returned
-func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs.Object, error) {
+func (f *Fs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
 	// Temporary Object under construction
 	o := &Object{
 		fs:     f,
 		remote: remote,
 	}
-	leaf, directoryID, err := f.dirCache.FindPath(remote, true)
+	leaf, directoryID, err := f.dirCache.FindPath(ctx, remote, true)
 	if err != nil {
 		return nil, err
 	}
+	in = fs.NewContextReader(ctx, in)
 	folder := acd.FolderFromId(directoryID, o.fs.c.Nodes)
 	var info *acd.File
 	var resp *http.Response
-	err = f.pacer.CallNoRetry(func() (bool, error) {
+	err = f.pacer.CallNoRetry(ctx, func() (bool, error) {
 		if size != 0 {
 			info, resp, err = folder.Put(in, leaf)
 		} else {
@@ -459,27 +524,36 @@ func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs
 }
 
 // Mkdir creates the container if it doesn't exist
-func (f *Fs) Mkdir() error {
-	return f.dirCache.FindRoot(true)
+func (f *Fs) Mkdir(ctx context.Context) error {
+	return f.dirCache.FindRoot(ctx, true)
 }
 
 // purgeCheck remotes the root directory, if check is set then it
 // refuses to do so if it has anything in
-func (f *Fs) purgeCheck(check bool) error {
+func (f *Fs) purgeCheck(ctx context.Context, check bool) error {
 	if f.root == "" {
 		return fmt.Errorf("Can't purge root directory")
 	}
 	dc := f.dirCache
-	err := dc.FindRoot(false)
+	err := dc.FindRoot(ctx, false)
 	if err != nil {
 		return err
 	}
-	rootID := dc.RootID()
+	err = f.rmdir(ctx, dc.RootID(), check)
+	if err != nil {
+		return err
+	}
+	f.dirCache.ResetRoot()
+	return nil
+}
 
+// rmdir trashes the directory with ID directoryID, if check is set
+// then it refuses to do so if it has anything in
+func (f *Fs) rmdir(ctx context.Context, directoryID string, check bool) (err error) {
 	if check {
 		// check directory is empty
 		empty := true
-		_, err = f.listAll(rootID, "", false, false, func(node *acd.Node) bool {
+		_, err = f.listAll(ctx, directoryID, "", false, false, func(node *acd.Node) bool {
 			switch *node.Kind {
 			case folderKind:
 				empty = false
@@ -500,28 +574,49 @@ func (f *Fs) purgeCheck(check bool) error {
 		}
 	}
 
-	node := acd.NodeFromId(rootID, f.c.Nodes)
+	node := acd.NodeFromId(directoryID, f.c.Nodes)
 	var resp *http.Response
-	err = f.pacer.Call(func() (bool, error) {
+	return f.pacer.Call(ctx, func() (bool, error) {
 		resp, err = node.Trash()
 		return shouldRetry(resp, err)
 	})
-	if err != nil {
-		return err
-	}
+}
 
-	f.dirCache.ResetRoot()
+// Rmdir deletes the root folder
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context) error {
+	return f.purgeCheck(ctx, true)
+}
+
+// MakeDir makes the directory dir and any parents it needs
+func (f *Fs) MakeDir(ctx context.Context, dir string) error {
+	err := f.dirCache.FindRoot(ctx, true)
 	if err != nil {
 		return err
 	}
-	return nil
+	_, err = f.dirCache.FindDir(ctx, dir, true)
+	return err
 }
 
-// Rmdir deletes the root folder
+// RemoveDir removes the directory dir
 //
 // Returns an error if it isn't empty
-func (f *Fs) Rmdir() error {
-	return f.purgeCheck(true)
+func (f *Fs) RemoveDir(ctx context.Context, dir string) error {
+	err := f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return err
+	}
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return err
+	}
+	err = f.rmdir(ctx, directoryID, true)
+	if err != nil {
+		return err
+	}
+	f.dirCache.FlushDir(dir)
+	return nil
 }
 
 // Precision return the precision of this Fs
@@ -529,6 +624,16 @@ func (f *Fs) Precision() time.Duration {
 	return fs.ModTimeNotSupported
 }
 
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() fs.HashSet {
+	return fs.HashSet(fs.HashMD5)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
 // Copy src to this remote using server side copy operations.
 //
 // This is stored with the remote path given
@@ -552,13 +657,103 @@ func (f *Fs) Precision() time.Duration {
 // return f.NewFsObject(remote), nil
 //}
 
+// DirMove moves src directory to this remote using server side move
+// operations.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantDirMove
+//
+// If destination exists then return fs.ErrorDirExists
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs) error {
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		fs.Debug(srcFs, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+	if srcFs.root == "" {
+		fs.Debug(srcFs, "Can't move root directory")
+		return fs.ErrorCantDirMove
+	}
+
+	// Find the source directory and its parent
+	err := srcFs.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return err
+	}
+	srcID := srcFs.dirCache.RootID()
+	srcParentID, err := srcFs.dirCache.RootParentID()
+	if err != nil {
+		return err
+	}
+	_, srcLeaf := dircache.SplitPath(srcFs.root)
+
+	// Check if destination exists
+	f.dirCache.ResetRoot()
+	err = f.dirCache.FindRoot(ctx, false)
+	if err == nil {
+		return fs.ErrorDirExists
+	} else if err != fs.ErrorDirNotFound {
+		return err
+	}
+
+	// Find ID of parent
+	leaf, directoryID, err := f.dirCache.FindPath(ctx, f.root, true)
+	if err != nil {
+		return err
+	}
+
+	// Do the move - this needs a rename and a change of parent
+	// if the name and the directory are both changing.  These
+	// are separate calls so if changing the parent fails the
+	// rename is undone.
+	node := acd.NodeFromId(srcID, f.c.Nodes)
+	var resp *http.Response
+	if leaf != srcLeaf {
+		err = f.pacer.Call(ctx, func() (bool, error) {
+			_, resp, err = node.Rename(leaf)
+			return shouldRetry(resp, err)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if directoryID != srcParentID {
+		err = f.pacer.Call(ctx, func() (bool, error) {
+			resp, err = node.ReplaceParent(srcParentID, directoryID)
+			return shouldRetry(resp, err)
+		})
+		if err != nil {
+			if leaf != srcLeaf {
+				undoErr := f.pacer.Call(ctx, func() (bool, error) {
+					_, resp, err := node.Rename(srcLeaf)
+					return shouldRetry(resp, err)
+				})
+				if undoErr != nil {
+					fs.ErrorLog(srcFs, "Failed to undo rename to %q after failed move: %v", leaf, undoErr)
+				}
+			}
+			return err
+		}
+	}
+	srcFs.dirCache.ResetRoot()
+	f.dirCache.ResetRoot()
+	return nil
+}
+
+// DirCacheFlush resets the directory cache - used when another Fs on
+// the same remote has changed the directories, eg with DirMove
+func (f *Fs) DirCacheFlush() {
+	f.dirCache.ResetRoot()
+}
+
 // Purge deletes all the files and the container
 //
 // Optional interface: Only implement this if you have a way of
 // deleting all the files quicker than just running Remove() on the
 // result of List()
-func (f *Fs) Purge() error {
-	return f.purgeCheck(false)
+func (f *Fs) Purge(ctx context.Context) error {
+	return f.purgeCheck(ctx, false)
 }
 
 // ------------------------------------------------------------
@@ -581,8 +776,11 @@ func (o *Object) Remote() string {
 	return o.remote
 }
 
-// Md5sum returns the Md5sum of an object returning a lowercase hex string
-func (o *Object) Md5sum() (string, error) {
+// Hash returns the Md5sum of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t fs.HashType) (string, error) {
+	if t != fs.HashMD5 {
+		return "", fs.ErrHashUnsupported
+	}
 	if o.info.ContentProperties.Md5 != nil {
 		return *o.info.ContentProperties.Md5, nil
 	}
@@ -597,18 +795,18 @@ func (o *Object) Size() int64 {
 // readMetaData gets the metadata if it hasn't already been fetched
 //
 // it also sets the info
-func (o *Object) readMetaData() (err error) {
+func (o *Object) readMetaData(ctx context.Context) (err error) {
 	if o.info != nil {
 		return nil
 	}
-	leaf, directoryID, err := o.fs.dirCache.FindPath(o.remote, false)
+	leaf, directoryID, err := o.fs.dirCache.FindPath(ctx, o.remote, false)
 	if err != nil {
 		return err
 	}
 	folder := acd.FolderFromId(directoryID, o.fs.c.Nodes)
 	var resp *http.Response
 	var info *acd.File
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
 		info, resp, err = folder.GetFile(leaf)
 		return shouldRetry(resp, err)
 	})
@@ -625,8 +823,8 @@ func (o *Object) readMetaData() (err error) {
 //
 // It attempts to read the objects mtime and if that isn't present the
 // LastModified returned in the http headers
-func (o *Object) ModTime() time.Time {
-	err := o.readMetaData()
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Log(o, "Failed to read metadata: %s", err)
 		return time.Now()
@@ -640,7 +838,7 @@ func (o *Object) ModTime() time.Time {
 }
 
 // SetModTime sets the modification time of the local fs object
-func (o *Object) SetModTime(modTime time.Time) {
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) {
 	// FIXME not implemented
 	return
 }
@@ -651,26 +849,34 @@ func (o *Object) Storable() bool {
 }
 
 // Open an object for read
-func (o *Object) Open() (in io.ReadCloser, err error) {
+//
+// Ranged reads aren't supported natively so are done by skipping
+// through the stream.
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
 	file := acd.File{Node: o.info}
 	var resp *http.Response
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
 		in, resp, err = file.Open()
 		return shouldRetry(resp, err)
 	})
-	return in, err
+	if err != nil {
+		return nil, err
+	}
+	return fs.OpenOptionsReadCloser(fs.NewContextReadCloser(ctx, in), o.Size(), options...)
 }
 
 // Update the object with the contents of the io.Reader, modTime and size
 //
 // The new object may have been created if an error is returned
-func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
+func (o *Object) Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...fs.PutOption) error {
+	in = fs.NewContextReader(ctx, in)
 	file := acd.File{Node: o.info}
 	var info *acd.File
 	var resp *http.Response
 	var err error
-	err = o.fs.pacer.CallNoRetry(func() (bool, error) {
-		if size != 0 {
+	err = o.fs.pacer.CallNoRetry(ctx, func() (bool, error) {
+		// size is -1 if the length of the stream isn't known
+		if size > 0 {
 			info, resp, err = file.OverwriteSized(in, size)
 		} else {
 			info, resp, err = file.Overwrite(in)
@@ -685,10 +891,10 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 }
 
 // Remove an object
-func (o *Object) Remove() error {
+func (o *Object) Remove(ctx context.Context) error {
 	var resp *http.Response
 	var err error
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
 		resp, err = o.info.Trash()
 		return shouldRetry(resp, err)
 	})
@@ -697,10 +903,13 @@ func (o *Object) Remove() error {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs     = (*Fs)(nil)
-	_ fs.Purger = (*Fs)(nil)
+	_ fs.Fs              = (*Fs)(nil)
+	_ fs.Purger          = (*Fs)(nil)
+	_ fs.DirMover        = (*Fs)(nil)
+	_ fs.DirCacheFlusher = (*Fs)(nil)
+	_ fs.DirLister       = (*Fs)(nil)
+	_ fs.DirMaker        = (*Fs)(nil)
 	//	_ fs.Copier   = (*Fs)(nil)
 	//	_ fs.Mover    = (*Fs)(nil)
-	//	_ fs.DirMover = (*Fs)(nil)
 	_ fs.Object = (*Object)(nil)
 )
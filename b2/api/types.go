@@ -59,7 +59,7 @@ func (t *Timestamp) UnmarshalJSON(data []byte) error {
 type File struct {
 	ID              string    `json:"fileId"`          // The unique identifier for this version of this file. Used with b2_get_file_info, b2_download_file_by_id, and b2_delete_file_version.
 	Name            string    `json:"fileName"`        // The name of this file, which can be used with b2_download_file_by_name.
-	Action          string    `json:"action"`          // Either "upload" or "hide". "upload" means a file that was uploaded to B2 Cloud Storage. "hide" means a file version marking the file as hidden, so that it will not show up in b2_list_file_names. The result of b2_list_file_names will contain only "upload". The result of b2_list_file_versions may have both.
+	Action          string    `json:"action"`          // Either "upload" or "hide". "upload" means a file that was uploaded to B2 Cloud Storage. "hide" means a file version marking the file as hidden, so that it will not show up in b2_list_file_names. The result of b2_list_file_names will contain only "upload", or "folder" for the folders returned when a delimiter is used. The result of b2_list_file_versions may have both.
 	Size            int64     `json:"size"`            // The number of bytes in the file.
 	UploadTimestamp Timestamp `json:"uploadTimestamp"` // This is a UTC time when this file was uploaded.
 }
@@ -83,6 +83,8 @@ type ListFileNamesRequest struct {
 	StartFileName string `json:"startFileName,omitempty"` // optional - The first file name to return. If there is a file with this name, it will be returned in the list. If not, the first file name after this the first one after this name.
 	MaxFileCount  int    `json:"maxFileCount,omitempty"`  // optional - The maximum number of files to return from this call. The default value is 100, and the maximum allowed is 1000.
 	StartFileID   string `json:"startFileId,omitempty"`   // optional - What to pass in to startFileId for the next search to continue where this one left off.
+	Prefix        string `json:"prefix,omitempty"`        // optional - Files returned will be limited to those with the given prefix.
+	Delimiter     string `json:"delimiter,omitempty"`     // optional - Files returned will be limited to those within the top folder, or any one subfolder. Folder names will also be returned. The delimiter character will be used to "break" file names into folders.
 }
 
 // ListFileNamesResponse is as received from b2_list_file_names or b2_list_file_versions
@@ -104,16 +106,31 @@ type GetUploadURLResponse struct {
 	AuthorizationToken string `json:"authorizationToken"` // The authorizationToken that must be used when uploading files to this bucket, see b2_upload_file.
 }
 
-// FileInfo is received from b2_upload_file and b2_get_file_info
+// FileInfo is received from b2_upload_file, b2_get_file_info and b2_copy_file
 type FileInfo struct {
-	ID          string            `json:"fileId"`        // The unique identifier for this version of this file. Used with b2_get_file_info, b2_download_file_by_id, and b2_delete_file_version.
-	Name        string            `json:"fileName"`      // The name of this file, which can be used with b2_download_file_by_name.
-	AccountID   string            `json:"accountId"`     // Your account ID.
-	BucketID    string            `json:"bucketId"`      // The bucket that the file is in.
-	Size        int64             `json:"contentLength"` // The number of bytes stored in the file.
-	SHA1        string            `json:"contentSha1"`   // The SHA1 of the bytes stored in the file.
-	ContentType string            `json:"contentType"`   // The MIME type of the file.
-	Info        map[string]string `json:"fileInfo"`      // The custom information that was uploaded with the file. This is a JSON object, holding the name/value pairs that were uploaded with the file.
+	ID              string            `json:"fileId"`          // The unique identifier for this version of this file. Used with b2_get_file_info, b2_download_file_by_id, and b2_delete_file_version.
+	Name            string            `json:"fileName"`        // The name of this file, which can be used with b2_download_file_by_name.
+	AccountID       string            `json:"accountId"`       // Your account ID.
+	BucketID        string            `json:"bucketId"`        // The bucket that the file is in.
+	Size            int64             `json:"contentLength"`   // The number of bytes stored in the file.
+	SHA1            string            `json:"contentSha1"`     // The SHA1 of the bytes stored in the file.
+	ContentType     string            `json:"contentType"`     // The MIME type of the file.
+	Info            map[string]string `json:"fileInfo"`        // The custom information that was uploaded with the file. This is a JSON object, holding the name/value pairs that were uploaded with the file.
+	UploadTimestamp Timestamp         `json:"uploadTimestamp"` // This is a UTC time when this file was uploaded.
+}
+
+// GetDownloadAuthorizationRequest is passed to b2_get_download_authorization
+type GetDownloadAuthorizationRequest struct {
+	BucketID               string `json:"bucketId"`               // The ID of the bucket that you want to download from.
+	FileNamePrefix         string `json:"fileNamePrefix"`         // The file name prefix of files the download authorization will allow.
+	ValidDurationInSeconds int64  `json:"validDurationInSeconds"` // The number of seconds before the authorization expires, between 1 and 604800 (one week).
+}
+
+// GetDownloadAuthorizationResponse is received from b2_get_download_authorization
+type GetDownloadAuthorizationResponse struct {
+	BucketID           string `json:"bucketId"`           // The unique ID of the bucket.
+	FileNamePrefix     string `json:"fileNamePrefix"`     // The file name prefix that was passed in.
+	AuthorizationToken string `json:"authorizationToken"` // The authorizationToken that must be passed as the Authorization parameter to download files with this prefix.
 }
 
 // CreateBucketRequest is used to create a bucket
@@ -145,3 +162,11 @@ type HideFileRequest struct {
 type GetFileInfoRequest struct {
 	ID string `json:"fileId"` // The ID of the file, as returned by b2_upload_file, b2_list_file_names, or b2_list_file_versions.
 }
+
+// CopyFileRequest is as passed to b2_copy_file
+type CopyFileRequest struct {
+	SourceID          string `json:"sourceFileId"`                  // The ID of the source file being copied.
+	Name              string `json:"fileName"`                      // The name of the new file being created.
+	MetadataDirective string `json:"metadataDirective,omitempty"`   // COPY to copy the content type and file info from the source file, REPLACE to use the ones given.
+	DestBucketID      string `json:"destinationBucketId,omitempty"` // The ID of the bucket to copy to - the same as the source if omitted.
+}
@@ -6,6 +6,7 @@ package b2
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"regexp"
@@ -32,6 +34,7 @@ const (
 	timeKey         = "src_last_modified_millis"
 	timeHeader      = headerPrefix + timeKey
 	sha1Header      = "X-Bz-Content-Sha1"
+	maxLinkExpire   = 7 * 24 * time.Hour // longest a download authorization can last
 )
 
 // Register with Fs
@@ -64,6 +67,7 @@ type Fs struct {
 	info          api.AuthorizeAccountResponse // result of authorize call
 	uploadMu      sync.Mutex                   // lock for upload variable
 	upload        api.GetUploadURLResponse     // result of get upload URL call
+	features      *fs.Features                 // optional features
 }
 
 // Object describes a b2 object
@@ -74,6 +78,7 @@ type Object struct {
 	remote  string    // The remote path
 	info    api.File  // Info from the b2 object if known
 	modTime time.Time // The modified time of the object if known
+	sha1    string    // SHA-1 hash if known
 }
 
 // ------------------------------------------------------------
@@ -136,6 +141,7 @@ func errorHandler(resp *http.Response) error {
 
 // NewFs contstructs an Fs from the path, bucket:path
 func NewFs(name, root string) (fs.Fs, error) {
+	ctx := context.Background()
 	bucket, directory, err := parsePath(root)
 	if err != nil {
 		return nil, err
@@ -145,6 +151,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 		bucket: bucket,
 		root:   directory,
 	}
+	f.features = (&fs.Features{}).Fill(f)
 
 	account := fs.ConfigFile.MustValue(name, "account")
 	if account == "" {
@@ -164,7 +171,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 		UserName: account,
 		Password: key,
 	}
-	_, err = f.srv.CallJSON(&opts, nil, &f.info)
+	_, err = f.srv.CallJSON(ctx, &opts, nil, &f.info)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to authenticate: %v", err)
 	}
@@ -181,7 +188,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 		} else {
 			f.root += "/"
 		}
-		obj := f.NewFsObject(remote)
+		obj := f.NewFsObject(ctx, remote)
 		if obj != nil {
 			return fs.NewLimited(f, obj), nil
 		}
@@ -191,10 +198,10 @@ func NewFs(name, root string) (fs.Fs, error) {
 }
 
 // getUploadURL returns the UploadURL and the AuthorizationToken
-func (f *Fs) getUploadURL() (string, string, error) {
+func (f *Fs) getUploadURL(ctx context.Context) (string, string, error) {
 	f.uploadMu.Lock()
 	defer f.uploadMu.Unlock()
-	bucketID, err := f.getBucketID()
+	bucketID, err := f.getBucketID(ctx)
 	if err != nil {
 		return "", "", err
 	}
@@ -206,7 +213,7 @@ func (f *Fs) getUploadURL() (string, string, error) {
 		var request = api.GetUploadURLRequest{
 			BucketID: bucketID,
 		}
-		_, err := f.srv.CallJSON(&opts, &request, &f.upload)
+		_, err := f.srv.CallJSON(ctx, &opts, &request, &f.upload)
 		if err != nil {
 			return "", "", fmt.Errorf("Failed to get upload URL: %v", err)
 		}
@@ -224,7 +231,7 @@ func (f *Fs) clearUploadURL() {
 // Return an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) newFsObjectWithInfo(remote string, info *api.File) fs.Object {
+func (f *Fs) newFsObjectWithInfo(ctx context.Context, remote string, info *api.File) fs.Object {
 	o := &Object{
 		fs:     f,
 		remote: remote,
@@ -233,7 +240,7 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *api.File) fs.Object {
 		// Set info but not headers
 		o.info = *info
 	} else {
-		err := o.readMetaData() // reads info and headers, returning an error
+		err := o.readMetaData(ctx) // reads info and headers, returning an error
 		if err != nil {
 			fs.Debug(o, "Failed to read metadata: %s", err)
 			return nil
@@ -245,25 +252,36 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *api.File) fs.Object {
 // NewFsObject returns an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) NewFsObject(remote string) fs.Object {
-	return f.newFsObjectWithInfo(remote, nil)
+func (f *Fs) NewFsObject(ctx context.Context, remote string) fs.Object {
+	return f.newFsObjectWithInfo(ctx, remote, nil)
 }
 
 // listFn is called from list to handle an object
 type listFn func(string, *api.File) error
 
+// folderAction is the Action of the folders returned when listing
+// with a delimiter
+const folderAction = "folder"
+
 // list lists the objects into the function supplied from
 // the bucket and root supplied
 //
 // If prefix is set then startFileName is used as a prefix which all
 // files must have
 //
+// If directories is set then only the files directly in prefix are
+// listed along with the folders in it, which have an Action of
+// folderAction and a remote without the trailing "/".  prefix should
+// be "" or end in "/".
+//
 // If limit is > 0 then it limits to that many files (must be less
 // than 1000)
 //
 // If hidden is set then it will list the hidden (deleted) files too.
-func (f *Fs) list(prefix string, limit int, hidden bool, fn listFn) error {
-	bucketID, err := f.getBucketID()
+//
+// It returns ctx.Err() if ctx is cancelled.
+func (f *Fs) list(ctx context.Context, prefix string, directories bool, limit int, hidden bool, fn listFn) error {
+	bucketID, err := f.getBucketID(ctx)
 	if err != nil {
 		return err
 	}
@@ -279,6 +297,10 @@ func (f *Fs) list(prefix string, limit int, hidden bool, fn listFn) error {
 	if prefix != "" {
 		request.StartFileName = prefix
 	}
+	if directories {
+		request.Prefix = prefix
+		request.Delimiter = "/"
+	}
 	var response api.ListFileNamesResponse
 	opts := rest.Opts{
 		Method: "POST",
@@ -288,7 +310,10 @@ func (f *Fs) list(prefix string, limit int, hidden bool, fn listFn) error {
 		opts.Path = "/b2_list_file_versions"
 	}
 	for {
-		_, err = f.srv.CallJSON(&opts, &request, &response)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		_, err = f.srv.CallJSON(ctx, &opts, &request, &response)
 		if err != nil {
 			return err
 		}
@@ -298,7 +323,11 @@ func (f *Fs) list(prefix string, limit int, hidden bool, fn listFn) error {
 			if !strings.HasPrefix(file.Name, prefix) {
 				return nil
 			}
-			err = fn(file.Name[len(prefix):], file)
+			remote := file.Name[len(prefix):]
+			if directories && file.Action == folderAction {
+				remote = strings.TrimSuffix(remote, "/")
+			}
+			err = fn(remote, file)
 			if err != nil {
 				return err
 			}
@@ -315,45 +344,77 @@ func (f *Fs) list(prefix string, limit int, hidden bool, fn listFn) error {
 	return nil
 }
 
-// List walks the path returning a channel of FsObjects
-func (f *Fs) List() fs.ObjectsChan {
-	out := make(fs.ObjectsChan, fs.Config.Checkers)
+// List lists the objects in the bucket into out
+func (f *Fs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
 	if f.bucket == "" {
-		// Return no objects at top level list
-		close(out)
-		fs.Stats.Error()
-		fs.ErrorLog(f, "Can't list objects at root - choose a bucket using lsd")
-	} else {
-		// List the objects
-		go func() {
-			defer close(out)
-			err := f.list("", 0, false, func(remote string, object *api.File) error {
-				if o := f.newFsObjectWithInfo(remote, object); o != nil {
-					out <- o
-				}
-				return nil
-			})
-			if err != nil {
-				fs.Stats.Error()
-				fs.ErrorLog(f, "Couldn't list bucket %q: %s", f.bucket, err)
+		// Objects can only be listed within a bucket
+		out.SetError(fmt.Errorf("Can't list objects at root - choose a bucket using lsd"))
+		return
+	}
+	err := f.list(ctx, "", false, 0, false, func(remote string, object *api.File) error {
+		if o := f.newFsObjectWithInfo(ctx, remote, object); o != nil {
+			if out.Add(o) {
+				return fs.ErrorListAborted
 			}
-		}()
+		}
+		return nil
+	})
+	if err != nil && err != fs.ErrorListAborted {
+		if err != ctx.Err() {
+			err = fmt.Errorf("Couldn't list bucket %q: %s", f.bucket, err)
+		}
+		out.SetError(err)
 	}
-	return out
+}
+
+// ListDirEntries lists the objects and directories directly in dir
+func (f *Fs) ListDirEntries(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	if f.bucket == "" {
+		return nil, nil, fmt.Errorf("Can't list objects at root - choose a bucket using lsd")
+	}
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	err = f.list(ctx, prefix, true, 0, false, func(remote string, object *api.File) error {
+		if remote == "" {
+			// Skip a file with the same name as the directory
+			return nil
+		}
+		remote = prefix + remote
+		if object.Action == folderAction {
+			dirs = append(dirs, &fs.Dir{
+				Name:  remote,
+				Bytes: -1,
+				Count: -1,
+			})
+		} else if o := f.newFsObjectWithInfo(ctx, remote, object); o != nil {
+			objects = append(objects, o)
+		}
+		return nil
+	})
+	if err != nil {
+		if err != ctx.Err() {
+			err = fmt.Errorf("Couldn't list bucket %q: %s", f.bucket, err)
+		}
+		return nil, nil, err
+	}
+	return objects, dirs, nil
 }
 
 // listBucketFn is called from listBuckets to handle a bucket
 type listBucketFn func(*api.Bucket)
 
 // listBuckets lists the buckets to the function supplied
-func (f *Fs) listBuckets(fn listBucketFn) error {
+func (f *Fs) listBuckets(ctx context.Context, fn listBucketFn) error {
 	var account = api.Account{ID: f.info.AccountID}
 	var response api.ListBucketsResponse
 	opts := rest.Opts{
 		Method: "POST",
 		Path:   "/b2_list_buckets",
 	}
-	_, err := f.srv.CallJSON(&opts, &account, &response)
+	_, err := f.srv.CallJSON(ctx, &opts, &account, &response)
 	if err != nil {
 		return err
 	}
@@ -364,13 +425,13 @@ func (f *Fs) listBuckets(fn listBucketFn) error {
 }
 
 // getBucketID finds the ID for the current bucket name
-func (f *Fs) getBucketID() (bucketID string, err error) {
+func (f *Fs) getBucketID(ctx context.Context) (bucketID string, err error) {
 	f.bucketIDMutex.Lock()
 	defer f.bucketIDMutex.Unlock()
 	if f._bucketID != "" {
 		return f._bucketID, nil
 	}
-	err = f.listBuckets(func(bucket *api.Bucket) {
+	err = f.listBuckets(ctx, func(bucket *api.Bucket) {
 		if bucket.Name == f.bucket {
 			bucketID = bucket.ID
 		}
@@ -397,20 +458,23 @@ func (f *Fs) clearBucketID() {
 }
 
 // ListDir lists the buckets
-func (f *Fs) ListDir() fs.DirChan {
+func (f *Fs) ListDir(ctx context.Context) fs.DirChan {
 	out := make(fs.DirChan, fs.Config.Checkers)
 	if f.bucket == "" {
 		// List the buckets
 		go func() {
 			defer close(out)
-			err := f.listBuckets(func(bucket *api.Bucket) {
-				out <- &fs.Dir{
+			err := f.listBuckets(ctx, func(bucket *api.Bucket) {
+				select {
+				case out <- &fs.Dir{
 					Name:  bucket.Name,
 					Bytes: -1,
 					Count: -1,
+				}:
+				case <-ctx.Done():
 				}
 			})
-			if err != nil {
+			if err != nil && ctx.Err() == nil {
 				fs.Stats.Error()
 				fs.ErrorLog(f, "Error listing buckets: %v", err)
 			}
@@ -420,7 +484,7 @@ func (f *Fs) ListDir() fs.DirChan {
 		go func() {
 			defer close(out)
 			lastDir := ""
-			err := f.list("", 0, false, func(remote string, object *api.File) error {
+			err := f.list(ctx, "", false, 0, false, func(remote string, object *api.File) error {
 				slash := strings.IndexRune(remote, '/')
 				if slash < 0 {
 					return nil
@@ -429,15 +493,19 @@ func (f *Fs) ListDir() fs.DirChan {
 				if dir == lastDir {
 					return nil
 				}
-				out <- &fs.Dir{
+				select {
+				case out <- &fs.Dir{
 					Name:  dir,
 					Bytes: -1,
 					Count: -1,
+				}:
+				case <-ctx.Done():
+					return ctx.Err()
 				}
 				lastDir = dir
 				return nil
 			})
-			if err != nil {
+			if err != nil && err != ctx.Err() {
 				fs.Stats.Error()
 				fs.ErrorLog(f, "Couldn't list bucket %q: %s", f.bucket, err)
 			}
@@ -451,17 +519,17 @@ func (f *Fs) ListDir() fs.DirChan {
 // Copy the reader in to the new object which is returned
 //
 // The new object may have been created if an error is returned
-func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs.Object, error) {
+func (f *Fs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
 	// Temporary Object under construction
 	fs := &Object{
 		fs:     f,
 		remote: remote,
 	}
-	return fs, fs.Update(in, modTime, size)
+	return fs, fs.Update(ctx, in, modTime, size, options...)
 }
 
 // Mkdir creates the bucket if it doesn't exist
-func (f *Fs) Mkdir() error {
+func (f *Fs) Mkdir(ctx context.Context) error {
 	opts := rest.Opts{
 		Method: "POST",
 		Path:   "/b2_create_bucket",
@@ -472,7 +540,7 @@ func (f *Fs) Mkdir() error {
 		Type:      "allPrivate",
 	}
 	var response api.Bucket
-	_, err := f.srv.CallJSON(&opts, &request, &response)
+	_, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 	if err != nil {
 		if apiErr, ok := err.(*api.Error); ok {
 			if apiErr.Code == "duplicate_bucket_name" {
@@ -488,7 +556,7 @@ func (f *Fs) Mkdir() error {
 // Rmdir deletes the bucket if the fs is at the root
 //
 // Returns an error if it isn't empty
-func (f *Fs) Rmdir() error {
+func (f *Fs) Rmdir(ctx context.Context) error {
 	if f.root != "" {
 		return nil
 	}
@@ -496,7 +564,7 @@ func (f *Fs) Rmdir() error {
 		Method: "POST",
 		Path:   "/b2_delete_bucket",
 	}
-	bucketID, err := f.getBucketID()
+	bucketID, err := f.getBucketID(ctx)
 	if err != nil {
 		return err
 	}
@@ -505,7 +573,7 @@ func (f *Fs) Rmdir() error {
 		AccountID: f.info.AccountID,
 	}
 	var response api.Bucket
-	_, err = f.srv.CallJSON(&opts, &request, &response)
+	_, err = f.srv.CallJSON(ctx, &opts, &request, &response)
 	if err != nil {
 		return fmt.Errorf("Failed to delete bucket: %v", err)
 	}
@@ -519,8 +587,127 @@ func (f *Fs) Precision() time.Duration {
 	return fs.ModTimeNotSupported
 }
 
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() fs.HashSet {
+	return fs.HashSet(fs.HashSHA1)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// Copy src to this remote using server side copy operations.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantCopy
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debug(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	// Read metadata (need ID)
+	err := srcObj.readMetaData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bucketID, err := f.getBucketID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/b2_copy_file",
+	}
+	// Copying the metadata keeps the modification time in timeKey
+	var request = api.CopyFileRequest{
+		SourceID:          srcObj.info.ID,
+		Name:              f.root + remote,
+		MetadataDirective: "COPY",
+		DestBucketID:      bucketID,
+	}
+	var response api.FileInfo
+	_, err = f.srv.CallJSON(ctx, &opts, &request, &response)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to copy: %v", err)
+	}
+	o := &Object{
+		fs:     f,
+		remote: remote,
+		info: api.File{
+			ID:              response.ID,
+			Name:            response.Name,
+			Action:          "upload",
+			Size:            response.Size,
+			UploadTimestamp: response.UploadTimestamp,
+		},
+		sha1: response.SHA1,
+	}
+	timeString := response.Info[timeKey]
+	parsed, err := parseTimeString(timeString)
+	if err != nil {
+		fs.Debug(o, "Failed to parse mod time string %q: %v", timeString, err)
+	} else {
+		o.modTime = parsed
+	}
+	return o, nil
+}
+
+// linkExpireSeconds returns expire as a number of seconds B2 will
+// authorize a download for, which is between 1 and a week
+func linkExpireSeconds(expire time.Duration) int64 {
+	if expire > maxLinkExpire {
+		expire = maxLinkExpire
+	}
+	seconds := int64(expire / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// PublicLink makes a link to download the object at remote which is
+// authorized for expire
+//
+// B2 limits this to a week at most so longer times are cut down to
+// that.
+func (f *Fs) PublicLink(ctx context.Context, remote string, expire time.Duration) (string, error) {
+	if f.NewFsObject(ctx, remote) == nil {
+		return "", fs.ErrorObjectNotFound
+	}
+	if expire > maxLinkExpire {
+		fs.Debug(f, "Link expiry %v is longer than B2 allows - using %v", expire, maxLinkExpire)
+	}
+	bucketID, err := f.getBucketID(ctx)
+	if err != nil {
+		return "", err
+	}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/b2_get_download_authorization",
+	}
+	var request = api.GetDownloadAuthorizationRequest{
+		BucketID:               bucketID,
+		FileNamePrefix:         f.root + remote,
+		ValidDurationInSeconds: linkExpireSeconds(expire),
+	}
+	var response api.GetDownloadAuthorizationResponse
+	_, err = f.srv.CallJSON(ctx, &opts, &request, &response)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get download authorization: %v", err)
+	}
+	link := f.info.DownloadURL + "/file/" + urlEncode(f.bucket) + "/" + urlEncode(f.root+remote)
+	return link + "?Authorization=" + url.QueryEscape(response.AuthorizationToken), nil
+}
+
 // deleteByID deletes a file version given Name and ID
-func (f *Fs) deleteByID(ID, Name string) error {
+func (f *Fs) deleteByID(ctx context.Context, ID, Name string) error {
 	opts := rest.Opts{
 		Method: "POST",
 		Path:   "/b2_delete_file_version",
@@ -530,17 +717,39 @@ func (f *Fs) deleteByID(ID, Name string) error {
 		Name: Name,
 	}
 	var response api.File
-	_, err := f.srv.CallJSON(&opts, &request, &response)
+	_, err := f.srv.CallJSON(ctx, &opts, &request, &response)
 	if err != nil {
 		return fmt.Errorf("Failed to delete %q: %v", Name, err)
 	}
 	return nil
 }
 
-// Purge deletes all the files and directories
+// purge deletes all the files and directories
+//
+// if oldOnly is true then it deletes only non current files.
 //
 // Implemented here so we can make sure we delete old versions.
-func (f *Fs) Purge() error {
+// newVersionDeleter returns a function which is called with each
+// version in the order B2 lists them - by name, newest first - and
+// returns whether that version should be deleted.
+//
+// If oldOnly is set then the current version of each file is kept
+// unless it is a hide marker.
+func newVersionDeleter(oldOnly bool) func(remote string, object *api.File) bool {
+	last := ""
+	return func(remote string, object *api.File) bool {
+		// The first version of each name is the current version
+		current := remote != last
+		last = remote
+		return !(oldOnly && current && object.Action != "hide")
+	}
+}
+
+// purge deletes all the versions of all the files, or just the old
+// versions and hidden files if oldOnly is set
+//
+// With --dry-run it logs what would be deleted instead
+func (f *Fs) purge(ctx context.Context, oldOnly bool) error {
 	var errReturn error
 	var checkErrMutex sync.Mutex
 	var checkErr = func(err error) {
@@ -564,22 +773,51 @@ func (f *Fs) Purge() error {
 		go func() {
 			defer wg.Done()
 			for object := range toBeDeleted {
-				checkErr(f.deleteByID(object.ID, object.Name))
+				if ctx.Err() != nil {
+					continue
+				}
+				checkErr(f.deleteByID(ctx, object.ID, object.Name))
 			}
 		}()
 	}
-	checkErr(f.list("", 0, true, func(remote string, object *api.File) error {
+	shouldDelete := newVersionDeleter(oldOnly)
+	checkErr(f.list(ctx, "", false, 0, true, func(remote string, object *api.File) error {
+		if !shouldDelete(remote, object) {
+			fs.Debug(remote, "Not deleting current version (id %q)", object.ID)
+			return nil
+		}
+		if fs.Config.DryRun {
+			fs.Log(remote, "Not deleting version (id %q) as --dry-run", object.ID)
+			return nil
+		}
 		fs.Debug(remote, "Deleting (id %q)", object.ID)
-		toBeDeleted <- object
+		select {
+		case toBeDeleted <- object:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 		return nil
 	}))
 	close(toBeDeleted)
 	wg.Wait()
 
-	checkErr(f.Rmdir())
+	if !oldOnly && !fs.Config.DryRun {
+		checkErr(f.Rmdir(ctx))
+	}
 	return errReturn
 }
 
+// Purge deletes all the files and directories including the old versions.
+func (f *Fs) Purge(ctx context.Context) error {
+	return f.purge(ctx, false)
+}
+
+// CleanUp deletes all the hidden files and the old versions of the
+// current files.
+func (f *Fs) CleanUp(ctx context.Context) error {
+	return f.purge(ctx, true)
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -600,9 +838,24 @@ func (o *Object) Remote() string {
 	return o.remote
 }
 
-// Md5sum returns the Md5sum of an object returning a lowercase hex string
-func (o *Object) Md5sum() (string, error) {
-	return "", nil
+// Hash returns the Sha-1 of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t fs.HashType) (string, error) {
+	if t != fs.HashSHA1 {
+		return "", fs.ErrHashUnsupported
+	}
+	if o.sha1 == "" {
+		// Read metadata (need ID)
+		err := o.readMetaData(ctx)
+		if err != nil {
+			return "", err
+		}
+		info, err := o.getFileInfo(ctx)
+		if err != nil {
+			return "", err
+		}
+		o.sha1 = info.SHA1
+	}
+	return o.sha1, nil
 }
 
 // Size returns the size of an object in bytes
@@ -613,11 +866,11 @@ func (o *Object) Size() int64 {
 // readMetaData gets the metadata if it hasn't already been fetched
 //
 // it also sets the info
-func (o *Object) readMetaData() (err error) {
+func (o *Object) readMetaData(ctx context.Context) (err error) {
 	if o.info.ID != "" {
 		return nil
 	}
-	err = o.fs.list(o.remote, 1, false, func(remote string, object *api.File) error {
+	err = o.fs.list(ctx, o.remote, false, 1, false, func(remote string, object *api.File) error {
 		if remote == "" {
 			o.info = *object
 		}
@@ -648,11 +901,29 @@ func parseTimeString(timeString string) (result time.Time, err error) {
 	return time.Unix(unixMilliseconds/1E3, (unixMilliseconds%1E3)*1E6).UTC(), nil
 }
 
+// getFileInfo reads the file info for the object with
+// b2_get_file_info - the object must have its ID set
+func (o *Object) getFileInfo(ctx context.Context) (*api.FileInfo, error) {
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/b2_get_file_info",
+	}
+	var request = api.GetFileInfoRequest{
+		ID: o.info.ID,
+	}
+	var response api.FileInfo
+	_, err := o.fs.srv.CallJSON(ctx, &opts, &request, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
 // ModTime returns the modification time of the object
 //
 // It attempts to read the objects mtime and if that isn't present the
 // LastModified returned in the http headers
-func (o *Object) ModTime() (result time.Time) {
+func (o *Object) ModTime(ctx context.Context) (result time.Time) {
 	if !o.modTime.IsZero() {
 		return o.modTime
 	}
@@ -661,7 +932,7 @@ func (o *Object) ModTime() (result time.Time) {
 	result = time.Now()
 
 	// Read metadata (need ID)
-	err := o.readMetaData()
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Debug(o, "Failed to read metadata: %v", err)
 		return result
@@ -671,19 +942,14 @@ func (o *Object) ModTime() (result time.Time) {
 	result = time.Time(o.info.UploadTimestamp)
 
 	// Now read the metadata for the modified time
-	opts := rest.Opts{
-		Method: "POST",
-		Path:   "/b2_get_file_info",
-	}
-	var request = api.GetFileInfoRequest{
-		ID: o.info.ID,
-	}
-	var response api.FileInfo
-	_, err = o.fs.srv.CallJSON(&opts, &request, &response)
+	response, err := o.getFileInfo(ctx)
 	if err != nil {
 		fs.Debug(o, "Failed to get file info: %v", err)
 		return result
 	}
+	if o.sha1 == "" {
+		o.sha1 = response.SHA1
+	}
 
 	// Parse the result
 	timeString := response.Info[timeKey]
@@ -696,7 +962,7 @@ func (o *Object) ModTime() (result time.Time) {
 }
 
 // SetModTime sets the modification time of the local fs object
-func (o *Object) SetModTime(modTime time.Time) {
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) {
 	// Not possible with B2
 }
 
@@ -766,13 +1032,14 @@ func (file *openFile) Close() (err error) {
 var _ io.ReadCloser = &openFile{}
 
 // Open an object for read
-func (o *Object) Open() (in io.ReadCloser, err error) {
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
 	opts := rest.Opts{
-		Method:   "GET",
-		Absolute: true,
-		Path:     o.fs.info.DownloadURL + "/file/" + urlEncode(o.fs.bucket) + "/" + urlEncode(o.fs.root+o.remote),
+		Method:       "GET",
+		Absolute:     true,
+		Path:         o.fs.info.DownloadURL + "/file/" + urlEncode(o.fs.bucket) + "/" + urlEncode(o.fs.root+o.remote),
+		ExtraHeaders: fs.OpenOptionHeaders(nil, options...),
 	}
-	resp, err := o.fs.srv.Call(&opts)
+	resp, err := o.fs.srv.Call(ctx, &opts)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to open for download: %v", err)
 	}
@@ -785,7 +1052,15 @@ func (o *Object) Open() (in io.ReadCloser, err error) {
 	} else {
 		o.modTime = parsed
 	}
-	return newOpenFile(o, resp), nil
+	if sum := resp.Header.Get(sha1Header); sum != "" {
+		o.sha1 = sum
+	}
+	// Don't check length or hash on partial content
+	if resp.StatusCode == http.StatusPartialContent {
+		return resp.Body, nil
+	}
+	// Read the part wanted if the Range was ignored
+	return fs.OpenOptionsReadCloser(newOpenFile(o, resp), o.Size(), options...)
 }
 
 // dontEncode is the characters that do not need percent-encoding
@@ -826,7 +1101,7 @@ func urlEncode(in string) string {
 // Update the object with the contents of the io.Reader, modTime and size
 //
 // The new object may have been created if an error is returned
-func (o *Object) Update(in io.Reader, modTime time.Time, size int64) (err error) {
+func (o *Object) Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...fs.PutOption) (err error) {
 	// Open a temp file to copy the input
 	fd, err := ioutil.TempFile("", "rclone-b2-")
 	if err != nil {
@@ -840,14 +1115,16 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) (err error)
 
 	// Copy the input while calculating the sha1
 	hash := sha1.New()
-	teed := io.TeeReader(in, hash)
+	teed := io.TeeReader(fs.NewContextReader(ctx, in), hash)
 	n, err := io.Copy(fd, teed)
 	if err != nil {
 		return err
 	}
-	if n != size {
+	// size is -1 if the length of the stream wasn't known
+	if size >= 0 && n != size {
 		return fmt.Errorf("Read %d bytes expecting %d", n, size)
 	}
+	size = n
 	calculatedSha1 := fmt.Sprintf("%x", hash.Sum(nil))
 
 	// Rewind the temporary file
@@ -857,7 +1134,7 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) (err error)
 	}
 
 	// Get upload URL
-	UploadURL, AuthorizationToken, err := o.fs.getUploadURL()
+	UploadURL, AuthorizationToken, err := o.fs.getUploadURL(ctx)
 	if err != nil {
 		return err
 	}
@@ -923,14 +1200,14 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) (err error)
 		ExtraHeaders: map[string]string{
 			"Authorization":  AuthorizationToken,
 			"X-Bz-File-Name": urlEncode(o.fs.root + o.remote),
-			"Content-Type":   fs.MimeType(o),
+			"Content-Type":   fs.MimeType(o, options...),
 			sha1Header:       calculatedSha1,
 			timeHeader:       timeString(modTime),
 		},
 		ContentLength: &size,
 	}
 	var response api.FileInfo
-	_, err = o.fs.srv.CallJSON(&opts, nil, &response)
+	_, err = o.fs.srv.CallJSON(ctx, &opts, nil, &response)
 	if err != nil {
 		return fmt.Errorf("Failed to upload: %v", err)
 	}
@@ -939,12 +1216,13 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) (err error)
 	o.info.Action = "upload"
 	o.info.Size = response.Size
 	o.info.UploadTimestamp = api.Timestamp(time.Now()) // FIXME not quite right
+	o.sha1 = calculatedSha1
 	return nil
 }
 
 // Remove an object
-func (o *Object) Remove() error {
-	bucketID, err := o.fs.getBucketID()
+func (o *Object) Remove(ctx context.Context) error {
+	bucketID, err := o.fs.getBucketID(ctx)
 	if err != nil {
 		return err
 	}
@@ -957,7 +1235,7 @@ func (o *Object) Remove() error {
 		Name:     o.info.Name,
 	}
 	var response api.File
-	_, err = o.fs.srv.CallJSON(&opts, &request, &response)
+	_, err = o.fs.srv.CallJSON(ctx, &opts, &request, &response)
 	if err != nil {
 		return fmt.Errorf("Failed to delete file: %v", err)
 	}
@@ -966,7 +1244,11 @@ func (o *Object) Remove() error {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs     = &Fs{}
-	_ fs.Purger = &Fs{}
-	_ fs.Object = &Object{}
+	_ fs.Fs           = &Fs{}
+	_ fs.Purger       = &Fs{}
+	_ fs.Copier       = &Fs{}
+	_ fs.CleanUpper   = &Fs{}
+	_ fs.PublicLinker = &Fs{}
+	_ fs.DirLister    = &Fs{}
+	_ fs.Object       = &Object{}
 )
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Shop2market/rclone/b2/api"
 	"github.com/Shop2market/rclone/fstest"
 )
 
@@ -166,3 +167,55 @@ func TestParseTimeString(t *testing.T) {
 	}
 
 }
+
+func TestLinkExpireSeconds(t *testing.T) {
+	for _, test := range []struct {
+		in   time.Duration
+		want int64
+	}{
+		{time.Hour, 3600},
+		{7 * 24 * time.Hour, 604800},
+		{7*24*time.Hour + time.Minute, 604800},
+		{365 * 24 * time.Hour, 604800},
+		{time.Millisecond, 1},
+		{-time.Hour, 1},
+	} {
+		got := linkExpireSeconds(test.in)
+		if got != test.want {
+			t.Errorf("%v: want %d got %d", test.in, test.want, got)
+		}
+	}
+}
+
+func TestVersionDeleter(t *testing.T) {
+	// Versions as listed by b2_list_file_versions - by name then
+	// newest first
+	versions := []struct {
+		remote string
+		action string
+	}{
+		{"a", "upload"},
+		{"a", "upload"},
+		{"a", "upload"},
+		{"b", "hide"},
+		{"b", "upload"},
+		{"c", "upload"},
+		{"d/e", "upload"},
+		{"d/e", "upload"},
+	}
+	for _, test := range []struct {
+		oldOnly bool
+		want    []bool
+	}{
+		{false, []bool{true, true, true, true, true, true, true, true}},
+		{true, []bool{false, true, true, true, true, false, false, true}},
+	} {
+		shouldDelete := newVersionDeleter(test.oldOnly)
+		for i, version := range versions {
+			got := shouldDelete(version.remote, &api.File{Action: version.action})
+			if got != test.want[i] {
+				t.Errorf("oldOnly=%v %d %q %q: want %v got %v", test.oldOnly, i, version.remote, version.action, test.want[i], got)
+			}
+		}
+	}
+}
@@ -8,6 +8,7 @@ package drive
 // * files with / in name
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -91,17 +92,20 @@ type Fs struct {
 	about    *drive.About       // information about the drive, including the root
 	dirCache *dircache.DirCache // Map of directory path to directory id
 	pacer    *pacer.Pacer       // To pace the API calls
+	features *fs.Features       // optional features
 }
 
 // Object describes a drive object
 type Object struct {
-	fs           *Fs    // what this object is part of
-	remote       string // The remote path
-	id           string // Drive Id of this object
-	url          string // Download URL of this object
-	md5sum       string // md5sum of the object
-	bytes        int64  // size of the object
-	modifiedDate string // RFC3339 time it was last modified
+	fs           *Fs         // what this object is part of
+	remote       string      // The remote path
+	id           string      // Drive Id of this object
+	url          string      // Download URL of this object
+	md5sum       string      // md5sum of the object
+	bytes        int64       // size of the object
+	modifiedDate string      // RFC3339 time it was last modified
+	mimeType     string      // MimeType of the object
+	properties   fs.Metadata // The custom properties of the object
 }
 
 // ------------------------------------------------------------
@@ -161,7 +165,7 @@ type listAllFn func(*drive.File) bool
 // If the user fn ever returns true then it early exits with found = true
 //
 // Search params: https://developers.google.com/drive/search-parameters
-func (f *Fs) listAll(dirID string, title string, directoriesOnly bool, filesOnly bool, fn listAllFn) (found bool, err error) {
+func (f *Fs) listAll(ctx context.Context, dirID string, title string, directoriesOnly bool, filesOnly bool, fn listAllFn) (found bool, err error) {
 	query := fmt.Sprintf("trashed=false")
 	if dirID != "" {
 		query += fmt.Sprintf(" and '%s' in parents", dirID)
@@ -183,8 +187,8 @@ func (f *Fs) listAll(dirID string, title string, directoriesOnly bool, filesOnly
 OUTER:
 	for {
 		var files *drive.FileList
-		err = f.pacer.Call(func() (bool, error) {
-			files, err = list.Do()
+		err = f.pacer.Call(ctx, func() (bool, error) {
+			files, err = list.Context(ctx).Do()
 			return shouldRetry(err)
 		})
 		if err != nil {
@@ -224,6 +228,7 @@ func NewFs(name, path string) (fs.Fs, error) {
 	if chunkSize < 256*1024 {
 		return nil, fmt.Errorf("drive: chunk size can't be less than 256k - was %v", chunkSize)
 	}
+	ctx := context.Background()
 
 	oAuthClient, err := oauthutil.NewClient(name, driveConfig)
 	if err != nil {
@@ -240,6 +245,10 @@ func NewFs(name, path string) (fs.Fs, error) {
 		root:  root,
 		pacer: pacer.New().SetMinSleep(minSleep).SetMaxSleep(maxSleep).SetDecayConstant(decayConstant),
 	}
+	f.features = (&fs.Features{
+		DuplicateFiles:          true,
+		CanHaveEmptyDirectories: true,
+	}).Fill(f)
 
 	// Create a new authorized Drive client.
 	f.client = oAuthClient
@@ -249,8 +258,8 @@ func NewFs(name, path string) (fs.Fs, error) {
 	}
 
 	// Read About so we know the root path
-	err = f.pacer.Call(func() (bool, error) {
-		f.about, err = f.svc.About.Get().Do()
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		f.about, err = f.svc.About.Get().Context(ctx).Do()
 		return shouldRetry(err)
 	})
 	if err != nil {
@@ -260,20 +269,22 @@ func NewFs(name, path string) (fs.Fs, error) {
 	f.dirCache = dircache.New(root, f.about.RootFolderId, f)
 
 	// Find the current root
-	err = f.dirCache.FindRoot(false)
+	err = f.dirCache.FindRoot(ctx, false)
 	if err != nil {
 		// Assume it is a file
 		newRoot, remote := dircache.SplitPath(root)
 		newF := *f
+		features := *f.features
+		newF.features = features.Fill(&newF)
 		newF.dirCache = dircache.New(newRoot, f.about.RootFolderId, &newF)
 		newF.root = newRoot
 		// Make new Fs which is the parent
-		err = newF.dirCache.FindRoot(false)
+		err = newF.dirCache.FindRoot(ctx, false)
 		if err != nil {
 			// No root so return old f
 			return f, nil
 		}
-		obj, err := newF.newFsObjectWithInfoErr(remote, nil)
+		obj, err := newF.newFsObjectWithInfoErr(ctx, remote, nil)
 		if err != nil {
 			// File doesn't exist so return old f
 			return f, nil
@@ -286,7 +297,7 @@ func NewFs(name, path string) (fs.Fs, error) {
 }
 
 // Return an FsObject from a path
-func (f *Fs) newFsObjectWithInfoErr(remote string, info *drive.File) (fs.Object, error) {
+func (f *Fs) newFsObjectWithInfoErr(ctx context.Context, remote string, info *drive.File) (fs.Object, error) {
 	fs := &Object{
 		fs:     f,
 		remote: remote,
@@ -294,7 +305,7 @@ func (f *Fs) newFsObjectWithInfoErr(remote string, info *drive.File) (fs.Object,
 	if info != nil {
 		fs.setMetaData(info)
 	} else {
-		err := fs.readMetaData() // reads info and meta, returning an error
+		err := fs.readMetaData(ctx) // reads info and meta, returning an error
 		if err != nil {
 			// logged already fs.Debug("Failed to read info: %s", err)
 			return nil, err
@@ -306,8 +317,8 @@ func (f *Fs) newFsObjectWithInfoErr(remote string, info *drive.File) (fs.Object,
 // Return an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) newFsObjectWithInfo(remote string, info *drive.File) fs.Object {
-	fs, _ := f.newFsObjectWithInfoErr(remote, info)
+func (f *Fs) newFsObjectWithInfo(ctx context.Context, remote string, info *drive.File) fs.Object {
+	fs, _ := f.newFsObjectWithInfoErr(ctx, remote, info)
 	// Errors have already been logged
 	return fs
 }
@@ -315,14 +326,14 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *drive.File) fs.Object {
 // NewFsObject returns an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) NewFsObject(remote string) fs.Object {
-	return f.newFsObjectWithInfo(remote, nil)
+func (f *Fs) NewFsObject(ctx context.Context, remote string) fs.Object {
+	return f.newFsObjectWithInfo(ctx, remote, nil)
 }
 
 // FindLeaf finds a directory of name leaf in the folder with ID pathID
-func (f *Fs) FindLeaf(pathID, leaf string) (pathIDOut string, found bool, err error) {
+func (f *Fs) FindLeaf(ctx context.Context, pathID, leaf string) (pathIDOut string, found bool, err error) {
 	// Find the leaf in pathID
-	found, err = f.listAll(pathID, leaf, true, false, func(item *drive.File) bool {
+	found, err = f.listAll(ctx, pathID, leaf, true, false, func(item *drive.File) bool {
 		if item.Title == leaf {
 			pathIDOut = item.Id
 			return true
@@ -333,7 +344,7 @@ func (f *Fs) FindLeaf(pathID, leaf string) (pathIDOut string, found bool, err er
 }
 
 // CreateDir makes a directory with pathID as parent and name leaf
-func (f *Fs) CreateDir(pathID, leaf string) (newID string, err error) {
+func (f *Fs) CreateDir(ctx context.Context, pathID, leaf string) (newID string, err error) {
 	// fmt.Println("Making", path)
 	// Define the metadata for the directory we are going to create.
 	createInfo := &drive.File{
@@ -343,8 +354,8 @@ func (f *Fs) CreateDir(pathID, leaf string) (newID string, err error) {
 		Parents:     []*drive.ParentReference{{Id: pathID}},
 	}
 	var info *drive.File
-	err = f.pacer.Call(func() (bool, error) {
-		info, err = f.svc.Files.Insert(createInfo).Do()
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		info, err = f.svc.Files.Insert(createInfo).Context(ctx).Do()
 		return shouldRetry(err)
 	})
 	if err != nil {
@@ -359,11 +370,15 @@ func (f *Fs) CreateDir(pathID, leaf string) (newID string, err error) {
 //
 // This fetches the minimum amount of stuff but does more API calls
 // which makes it slow
-func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) error {
-	var subError error
+func (f *Fs) listDirRecursive(ctx context.Context, dirID string, path string, out fs.ListOpts) error {
+	var (
+		subErrorMu sync.Mutex
+		subError   error
+		aborted    bool
+	)
 	// Make the API request
 	var wg sync.WaitGroup
-	_, err := f.listAll(dirID, "", false, false, func(item *drive.File) bool {
+	_, err := f.listAll(ctx, dirID, "", false, false, func(item *drive.File) bool {
 		// Recurse on directories
 		if item.MimeType == driveFolderType {
 			wg.Add(1)
@@ -372,18 +387,22 @@ func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) err
 
 			go func() {
 				defer wg.Done()
-				err := f.listDirRecursive(item.Id, folder, out)
+				err := f.listDirRecursive(ctx, item.Id, folder, out)
 				if err != nil {
+					subErrorMu.Lock()
 					subError = err
-					fs.ErrorLog(f, "Error reading %s:%s", folder, err)
+					subErrorMu.Unlock()
 				}
 
 			}()
 		} else {
 			// If item has no MD5 sum it isn't stored on drive, so ignore it
 			if item.Md5Checksum != "" {
-				if fs := f.newFsObjectWithInfo(path+item.Title, item); fs != nil {
-					out <- fs
+				if o := f.newFsObjectWithInfo(ctx, path+item.Title, item); o != nil {
+					if out.Add(o) {
+						aborted = true
+						return true
+					}
 				}
 			}
 		}
@@ -391,6 +410,12 @@ func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) err
 	})
 	wg.Wait()
 	fs.Debug(f, "Finished reading %s", path)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if aborted {
+		return fs.ErrorListAborted
+	}
 	if err != nil {
 		return err
 	}
@@ -407,10 +432,13 @@ func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) err
 //
 // This is fast in terms of number of API calls, but slow in terms of
 // fetching more data than it needs
-func (f *Fs) listDirFull(dirID string, path string, out fs.ObjectsChan) error {
+func (f *Fs) listDirFull(ctx context.Context, dirID string, path string, out fs.ListOpts) error {
 	// Orphans waiting for their parent
 	orphans := make(map[string][]*drive.File)
 
+	// Set if out.Add asks us to stop
+	aborted := false
+
 	var outputItem func(*drive.File, string) // forward def for recursive fn
 
 	// Output an item or directory
@@ -434,15 +462,18 @@ func (f *Fs) listDirFull(dirID string, path string, out fs.ObjectsChan) error {
 			// fmt.Printf("file %s %s %s\n", path, item.Title, item.Id)
 			// If item has no MD5 sum it isn't stored on drive, so ignore it
 			if item.Md5Checksum != "" {
-				if fs := f.newFsObjectWithInfo(path, item); fs != nil {
-					out <- fs
+				if o := f.newFsObjectWithInfo(ctx, path, item); o != nil && !aborted {
+					aborted = out.Add(o)
 				}
 			}
 		}
 	}
 
 	// Make the API request
-	_, err := f.listAll("", "", false, false, func(item *drive.File) bool {
+	_, err := f.listAll(ctx, "", "", false, false, func(item *drive.File) bool {
+		if aborted || ctx.Err() != nil {
+			return true
+		}
 		if len(item.Parents) == 0 {
 			// fmt.Printf("no parents %s %s: %#v\n", item.Title, item.Id, item)
 			return false
@@ -458,6 +489,12 @@ func (f *Fs) listDirFull(dirID string, path string, out fs.ObjectsChan) error {
 		}
 		return false
 	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if aborted {
+		return fs.ErrorListAborted
+	}
 	if err != nil {
 		return err
 	}
@@ -468,51 +505,49 @@ func (f *Fs) listDirFull(dirID string, path string, out fs.ObjectsChan) error {
 	return nil
 }
 
-// List walks the path returning a channel of FsObjects
-func (f *Fs) List() fs.ObjectsChan {
-	out := make(fs.ObjectsChan, fs.Config.Checkers)
-	go func() {
-		defer close(out)
-		err := f.dirCache.FindRoot(false)
-		if err != nil {
-			fs.Stats.Error()
-			fs.ErrorLog(f, "Couldn't find root: %s", err)
-		} else {
-			if f.root == "" && *driveFullList {
-				err = f.listDirFull(f.dirCache.RootID(), "", out)
-			} else {
-				err = f.listDirRecursive(f.dirCache.RootID(), "", out)
-			}
-			if err != nil {
-				fs.Stats.Error()
-				fs.ErrorLog(f, "List failed: %s", err)
-			}
-		}
-	}()
-	return out
+// List walks the path into out
+func (f *Fs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
+	err := f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		out.SetError(err)
+		return
+	}
+	if f.root == "" && *driveFullList {
+		err = f.listDirFull(ctx, f.dirCache.RootID(), "", out)
+	} else {
+		err = f.listDirRecursive(ctx, f.dirCache.RootID(), "", out)
+	}
+	if err != nil && err != fs.ErrorListAborted {
+		out.SetError(err)
+	}
 }
 
 // ListDir walks the path returning a channel of directories
-func (f *Fs) ListDir() fs.DirChan {
+func (f *Fs) ListDir(ctx context.Context) fs.DirChan {
 	out := make(fs.DirChan, fs.Config.Checkers)
 	go func() {
 		defer close(out)
-		err := f.dirCache.FindRoot(false)
+		err := f.dirCache.FindRoot(ctx, false)
 		if err != nil {
 			fs.Stats.Error()
 			fs.ErrorLog(f, "Couldn't find root: %s", err)
 		} else {
-			_, err := f.listAll(f.dirCache.RootID(), "", true, false, func(item *drive.File) bool {
+			_, err := f.listAll(ctx, f.dirCache.RootID(), "", true, false, func(item *drive.File) bool {
 				dir := &fs.Dir{
 					Name:  item.Title,
 					Bytes: -1,
 					Count: -1,
 				}
 				dir.When, _ = time.Parse(timeFormatIn, item.ModifiedDate)
-				out <- dir
+				select {
+				case out <- dir:
+				case <-ctx.Done():
+					return true
+				}
 				return false
 			})
-			if err != nil {
+			if err != nil && ctx.Err() == nil {
 				fs.Stats.Error()
 				fs.ErrorLog(f, "ListDir failed: %s", err)
 			}
@@ -521,11 +556,54 @@ func (f *Fs) ListDir() fs.DirChan {
 	return out
 }
 
+// ListDirEntries lists the objects and directories directly in dir
+func (f *Fs) ListDirEntries(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	err = f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	_, err = f.listAll(ctx, directoryID, "", false, false, func(item *drive.File) bool {
+		remote := prefix + item.Title
+		if item.MimeType == driveFolderType {
+			// Cache the directory for when it is listed
+			f.dirCache.Put(remote, item.Id)
+			d := &fs.Dir{
+				Name:  remote,
+				Bytes: -1,
+				Count: -1,
+			}
+			d.When, _ = time.Parse(timeFormatIn, item.ModifiedDate)
+			dirs = append(dirs, d)
+		} else if item.Md5Checksum != "" {
+			// If item has no MD5 sum it isn't stored on drive, so ignore it
+			if o := f.newFsObjectWithInfo(ctx, remote, item); o != nil {
+				objects = append(objects, o)
+			}
+		}
+		return ctx.Err() != nil
+	})
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return objects, dirs, nil
+}
+
 // Creates a drive.File info from the parameters passed in and a half
 // finished Object which must have setMetaData called on it
 //
 // Used to create new objects
-func (f *Fs) createFileInfo(remote string, modTime time.Time, size int64) (*Object, *drive.File, error) {
+func (f *Fs) createFileInfo(ctx context.Context, remote string, modTime time.Time, size int64, options ...fs.PutOption) (*Object, *drive.File, error) {
 	// Temporary Object under construction
 	o := &Object{
 		fs:     f,
@@ -533,7 +611,7 @@ func (f *Fs) createFileInfo(remote string, modTime time.Time, size int64) (*Obje
 		bytes:  size,
 	}
 
-	leaf, directoryID, err := f.dirCache.FindPath(remote, true)
+	leaf, directoryID, err := f.dirCache.FindPath(ctx, remote, true)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -543,8 +621,9 @@ func (f *Fs) createFileInfo(remote string, modTime time.Time, size int64) (*Obje
 		Title:        leaf,
 		Description:  leaf,
 		Parents:      []*drive.ParentReference{{Id: directoryID}},
-		MimeType:     fs.MimeType(o),
+		MimeType:     fs.MimeType(o, options...),
 		ModifiedDate: modTime.Format(timeFormatOut),
+		Properties:   driveProperties(options...),
 	}
 	return o, createInfo, nil
 }
@@ -558,18 +637,21 @@ func (f *Fs) createFileInfo(remote string, modTime time.Time, size int64) (*Obje
 // Copy the reader in to the new object which is returned
 //
 // The new object may have been created if an error is returned
-func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs.Object, error) {
-	o, createInfo, err := f.createFileInfo(remote, modTime, size)
+func (f *Fs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
+	o, createInfo, err := f.createFileInfo(ctx, remote, modTime, size, options...)
 	if err != nil {
 		return nil, err
 	}
 
 	var info *drive.File
+	// Streams of unknown length (size -1) go this way too - the
+	// media uploader sends them in chunks of chunkSize if they turn
+	// out to be longer than one chunk
 	if size == 0 || size < int64(driveUploadCutoff) {
 		// Make the API request to upload metadata and file data.
 		// Don't retry, return a retry error instead
-		err = f.pacer.CallNoRetry(func() (bool, error) {
-			info, err = f.svc.Files.Insert(createInfo).Media(in).Do()
+		err = f.pacer.CallNoRetry(ctx, func() (bool, error) {
+			info, err = f.svc.Files.Insert(createInfo).Media(in, googleapi.ChunkSize(int(chunkSize))).Context(ctx).Do()
 			return shouldRetry(err)
 		})
 		if err != nil {
@@ -577,7 +659,7 @@ func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs
 		}
 	} else {
 		// Upload the file in chunks
-		info, err = f.Upload(in, size, createInfo.MimeType, createInfo, remote)
+		info, err = f.Upload(ctx, in, size, createInfo.MimeType, createInfo, remote)
 		if err != nil {
 			return o, err
 		}
@@ -587,21 +669,19 @@ func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs
 }
 
 // Mkdir creates the container if it doesn't exist
-func (f *Fs) Mkdir() error {
-	return f.dirCache.FindRoot(true)
+func (f *Fs) Mkdir(ctx context.Context) error {
+	return f.dirCache.FindRoot(ctx, true)
 }
 
-// Rmdir deletes the container
+// rmdir checks the directory with ID directoryID is empty and then
+// deletes it if deleteIt is set
 //
 // Returns an error if it isn't empty
-func (f *Fs) Rmdir() error {
-	err := f.dirCache.FindRoot(false)
-	if err != nil {
-		return err
-	}
+func (f *Fs) rmdir(ctx context.Context, directoryID string, deleteIt bool) error {
 	var children *drive.ChildList
-	err = f.pacer.Call(func() (bool, error) {
-		children, err = f.svc.Children.List(f.dirCache.RootID()).MaxResults(10).Do()
+	err := f.pacer.Call(ctx, func() (bool, error) {
+		var err error
+		children, err = f.svc.Children.List(directoryID).MaxResults(10).Context(ctx).Do()
 		return shouldRetry(err)
 	})
 	if err != nil {
@@ -610,29 +690,170 @@ func (f *Fs) Rmdir() error {
 	if len(children.Items) > 0 {
 		return fmt.Errorf("Directory not empty: %#v", children.Items)
 	}
-	// Delete the directory if it isn't the root
-	if f.root != "" {
-		err = f.pacer.Call(func() (bool, error) {
-			if *driveUseTrash {
-				_, err = f.svc.Files.Trash(f.dirCache.RootID()).Do()
-			} else {
-				err = f.svc.Files.Delete(f.dirCache.RootID()).Do()
-			}
-			return shouldRetry(err)
-		})
-		if err != nil {
-			return err
+	if !deleteIt {
+		return nil
+	}
+	return f.pacer.Call(ctx, func() (bool, error) {
+		var err error
+		if *driveUseTrash {
+			_, err = f.svc.Files.Trash(directoryID).Context(ctx).Do()
+		} else {
+			err = f.svc.Files.Delete(directoryID).Context(ctx).Do()
 		}
+		return shouldRetry(err)
+	})
+}
+
+// Rmdir deletes the container
+//
+// Returns an error if it isn't empty
+func (f *Fs) Rmdir(ctx context.Context) error {
+	err := f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return err
+	}
+	// Delete the directory if it isn't the root
+	err = f.rmdir(ctx, f.dirCache.RootID(), f.root != "")
+	if err != nil {
+		return err
 	}
 	f.dirCache.ResetRoot()
 	return nil
 }
 
+// MakeDir makes the directory dir and any parents it needs
+func (f *Fs) MakeDir(ctx context.Context, dir string) error {
+	err := f.dirCache.FindRoot(ctx, true)
+	if err != nil {
+		return err
+	}
+	_, err = f.dirCache.FindDir(ctx, dir, true)
+	return err
+}
+
+// RemoveDir removes the directory dir
+//
+// Returns an error if it isn't empty
+func (f *Fs) RemoveDir(ctx context.Context, dir string) error {
+	err := f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return err
+	}
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return err
+	}
+	err = f.rmdir(ctx, directoryID, true)
+	if err != nil {
+		return err
+	}
+	f.dirCache.FlushDir(dir)
+	return nil
+}
+
+// SetDirModTime sets the modification time of the directory dir
+func (f *Fs) SetDirModTime(ctx context.Context, dir string, modTime time.Time) error {
+	err := f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return err
+	}
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return err
+	}
+	updateInfo := &drive.File{
+		ModifiedDate: modTime.Format(timeFormatOut),
+	}
+	return f.pacer.Call(ctx, func() (bool, error) {
+		_, err := f.svc.Files.Update(directoryID, updateInfo).SetModifiedDate(true).Context(ctx).Do()
+		return shouldRetry(err)
+	})
+}
+
 // Precision of the object storage system
 func (f *Fs) Precision() time.Duration {
 	return time.Millisecond
 }
 
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() fs.HashSet {
+	return fs.HashSet(fs.HashMD5)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// About gets quota information
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	var about *drive.About
+	var err error
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		about, err = f.svc.About.Get().Context(ctx).Do()
+		return shouldRetry(err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Drive about: %v", err)
+	}
+	usage := &fs.Usage{
+		Used:    fs.NewUsageValue(about.QuotaBytesUsedAggregate),
+		Trashed: fs.NewUsageValue(about.QuotaBytesUsedInTrash),
+	}
+	// The total is 0 if the account has unlimited storage
+	if about.QuotaBytesTotal > 0 {
+		usage.Total = fs.NewUsageValue(about.QuotaBytesTotal)
+		usage.Free = fs.NewUsageValue(about.QuotaBytesTotal - about.QuotaBytesUsedAggregate)
+	}
+	return usage, nil
+}
+
+// CleanUp empties the trash
+//
+// The trash is for the whole drive, not just the root of the Fs
+func (f *Fs) CleanUp(ctx context.Context) error {
+	if fs.Config.DryRun {
+		fs.Log(f, "Not emptying trash as --dry-run")
+		return nil
+	}
+	err := f.pacer.Call(ctx, func() (bool, error) {
+		err := f.svc.Files.EmptyTrash().Context(ctx).Do()
+		return shouldRetry(err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to empty trash: %v", err)
+	}
+	return nil
+}
+
+// PublicLink shares the file or directory at remote with anyone who
+// has the link and returns the link
+//
+// Drive links don't expire so expire is ignored
+func (f *Fs) PublicLink(ctx context.Context, remote string, expire time.Duration) (string, error) {
+	id, err := f.dirCache.FindDir(ctx, remote, false)
+	if err != nil {
+		o, ok := f.NewFsObject(ctx, remote).(*Object)
+		if !ok {
+			return "", fs.ErrorObjectNotFound
+		}
+		id = o.id
+	}
+	permission := &drive.Permission{
+		Role:     "reader",
+		Type:     "anyone",
+		WithLink: true,
+	}
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		_, err = f.svc.Permissions.Insert(id, permission).Context(ctx).Do()
+		return shouldRetry(err)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to share: %v", err)
+	}
+	return fmt.Sprintf("https://drive.google.com/open?id=%s", id), nil
+}
+
 // Copy src to this remote using server side copy operations.
 //
 // This is stored with the remote path given
@@ -642,21 +863,21 @@ func (f *Fs) Precision() time.Duration {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantCopy
-func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debug(src, "Can't copy - not same remote type")
 		return nil, fs.ErrorCantCopy
 	}
 
-	o, createInfo, err := f.createFileInfo(remote, srcObj.ModTime(), srcObj.bytes)
+	o, createInfo, err := f.createFileInfo(ctx, remote, srcObj.ModTime(ctx), srcObj.bytes)
 	if err != nil {
 		return nil, err
 	}
 
 	var info *drive.File
-	err = o.fs.pacer.Call(func() (bool, error) {
-		info, err = o.fs.svc.Files.Copy(srcObj.id, createInfo).Do()
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
+		info, err = o.fs.svc.Files.Copy(srcObj.id, createInfo).Context(ctx).Do()
 		return shouldRetry(err)
 	})
 	if err != nil {
@@ -672,19 +893,19 @@ func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
 // Optional interface: Only implement this if you have a way of
 // deleting all the files quicker than just running Remove() on the
 // result of List()
-func (f *Fs) Purge() error {
+func (f *Fs) Purge(ctx context.Context) error {
 	if f.root == "" {
 		return fmt.Errorf("Can't purge root directory")
 	}
-	err := f.dirCache.FindRoot(false)
+	err := f.dirCache.FindRoot(ctx, false)
 	if err != nil {
 		return err
 	}
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.Call(ctx, func() (bool, error) {
 		if *driveUseTrash {
-			_, err = f.svc.Files.Trash(f.dirCache.RootID()).Do()
+			_, err = f.svc.Files.Trash(f.dirCache.RootID()).Context(ctx).Do()
 		} else {
-			err = f.svc.Files.Delete(f.dirCache.RootID()).Do()
+			err = f.svc.Files.Delete(f.dirCache.RootID()).Context(ctx).Do()
 		}
 		return shouldRetry(err)
 	})
@@ -704,7 +925,7 @@ func (f *Fs) Purge() error {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantMove
-func (f *Fs) Move(src fs.Object, remote string) (fs.Object, error) {
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debug(src, "Can't move - not same remote type")
@@ -712,13 +933,13 @@ func (f *Fs) Move(src fs.Object, remote string) (fs.Object, error) {
 	}
 
 	// Temporary FsObject under construction
-	dstObj, dstInfo, err := f.createFileInfo(remote, srcObj.ModTime(), srcObj.bytes)
+	dstObj, dstInfo, err := f.createFileInfo(ctx, remote, srcObj.ModTime(ctx), srcObj.bytes)
 	if err != nil {
 		return nil, err
 	}
 
 	// Do the move
-	info, err := f.svc.Files.Patch(srcObj.id, dstInfo).SetModifiedDate(true).Do()
+	info, err := f.svc.Files.Patch(srcObj.id, dstInfo).SetModifiedDate(true).Context(ctx).Do()
 	if err != nil {
 		return nil, err
 	}
@@ -735,7 +956,7 @@ func (f *Fs) Move(src fs.Object, remote string) (fs.Object, error) {
 // If it isn't possible then return fs.ErrorCantDirMove
 //
 // If destination exists then return fs.ErrorDirExists
-func (f *Fs) DirMove(src fs.Fs) error {
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs) error {
 	srcFs, ok := src.(*Fs)
 	if !ok {
 		fs.Debug(srcFs, "Can't move directory - not same remote type")
@@ -744,13 +965,13 @@ func (f *Fs) DirMove(src fs.Fs) error {
 
 	// Check if destination exists
 	f.dirCache.ResetRoot()
-	err := f.dirCache.FindRoot(false)
+	err := f.dirCache.FindRoot(ctx, false)
 	if err == nil {
 		return fs.ErrorDirExists
 	}
 
 	// Find ID of parent
-	leaf, directoryID, err := f.dirCache.FindPath(f.root, true)
+	leaf, directoryID, err := f.dirCache.FindPath(ctx, f.root, true)
 	if err != nil {
 		return err
 	}
@@ -760,7 +981,7 @@ func (f *Fs) DirMove(src fs.Fs) error {
 		Title:   leaf,
 		Parents: []*drive.ParentReference{{Id: directoryID}},
 	}
-	_, err = f.svc.Files.Patch(srcFs.dirCache.RootID(), &patch).Do()
+	_, err = f.svc.Files.Patch(srcFs.dirCache.RootID(), &patch).Context(ctx).Do()
 	if err != nil {
 		return err
 	}
@@ -768,6 +989,12 @@ func (f *Fs) DirMove(src fs.Fs) error {
 	return nil
 }
 
+// DirCacheFlush resets the directory cache - used when another Fs on
+// the same remote has changed the directories, eg with DirMove
+func (f *Fs) DirCacheFlush() {
+	f.dirCache.ResetRoot()
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -788,8 +1015,11 @@ func (o *Object) Remote() string {
 	return o.remote
 }
 
-// Md5sum returns the Md5sum of an object returning a lowercase hex string
-func (o *Object) Md5sum() (string, error) {
+// Hash returns the Md5sum of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t fs.HashType) (string, error) {
+	if t != fs.HashMD5 {
+		return "", fs.ErrHashUnsupported
+	}
 	return o.md5sum, nil
 }
 
@@ -805,20 +1035,38 @@ func (o *Object) setMetaData(info *drive.File) {
 	o.md5sum = strings.ToLower(info.Md5Checksum)
 	o.bytes = info.FileSize
 	o.modifiedDate = info.ModifiedDate
+	o.mimeType = info.MimeType
+	o.properties = make(fs.Metadata, len(info.Properties))
+	for _, property := range info.Properties {
+		o.properties[strings.ToLower(property.Key)] = property.Value
+	}
+}
+
+// driveProperties converts the user metadata in options into drive
+// properties, returning nil if there isn't any
+func driveProperties(options ...fs.PutOption) (properties []*drive.Property) {
+	_, metadata := fs.PutOptionsMetadata(options...)
+	for k, v := range metadata {
+		properties = append(properties, &drive.Property{
+			Key:   k,
+			Value: v,
+		})
+	}
+	return properties
 }
 
 // readMetaData gets the info if it hasn't already been fetched
-func (o *Object) readMetaData() (err error) {
+func (o *Object) readMetaData(ctx context.Context) (err error) {
 	if o.id != "" {
 		return nil
 	}
 
-	leaf, directoryID, err := o.fs.dirCache.FindPath(o.remote, false)
+	leaf, directoryID, err := o.fs.dirCache.FindPath(ctx, o.remote, false)
 	if err != nil {
 		return err
 	}
 
-	found, err := o.fs.listAll(directoryID, leaf, false, true, func(item *drive.File) bool {
+	found, err := o.fs.listAll(ctx, directoryID, leaf, false, true, func(item *drive.File) bool {
 		if item.Title == leaf {
 			o.setMetaData(item)
 			return true
@@ -840,8 +1088,8 @@ func (o *Object) readMetaData() (err error) {
 //
 // It attempts to read the objects mtime and if that isn't present the
 // LastModified returned in the http headers
-func (o *Object) ModTime() time.Time {
-	err := o.readMetaData()
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Log(o, "Failed to read metadata: %s", err)
 		return time.Now()
@@ -855,8 +1103,8 @@ func (o *Object) ModTime() time.Time {
 }
 
 // SetModTime sets the modification time of the drive fs object
-func (o *Object) SetModTime(modTime time.Time) {
-	err := o.readMetaData()
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Stats.Error()
 		fs.ErrorLog(o, "Failed to read metadata: %s", err)
@@ -868,8 +1116,8 @@ func (o *Object) SetModTime(modTime time.Time) {
 	}
 	// Set modified date
 	var info *drive.File
-	err = o.fs.pacer.Call(func() (bool, error) {
-		info, err = o.fs.svc.Files.Update(o.id, updateInfo).SetModifiedDate(true).Do()
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
+		info, err = o.fs.svc.Files.Update(o.id, updateInfo).SetModifiedDate(true).Context(ctx).Do()
 		return shouldRetry(err)
 	})
 	if err != nil {
@@ -881,13 +1129,32 @@ func (o *Object) SetModTime(modTime time.Time) {
 	o.setMetaData(info)
 }
 
+// MimeType returns the MimeType of the object
+func (o *Object) MimeType(ctx context.Context) string {
+	err := o.readMetaData(ctx)
+	if err != nil {
+		fs.Log(o, "Failed to read metadata: %s", err)
+		return ""
+	}
+	return o.mimeType
+}
+
+// Metadata returns the custom properties of the object
+func (o *Object) Metadata(ctx context.Context) (fs.Metadata, error) {
+	err := o.readMetaData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return o.properties, nil
+}
+
 // Storable returns a boolean as to whether this object is storable
 func (o *Object) Storable() bool {
 	return true
 }
 
 // Open an object for read
-func (o *Object) Open() (in io.ReadCloser, err error) {
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
 	if o.url == "" {
 		return nil, fmt.Errorf("Forbidden to download - check sharing permission")
 	}
@@ -895,20 +1162,22 @@ func (o *Object) Open() (in io.ReadCloser, err error) {
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	req.Header.Set("User-Agent", fs.UserAgent)
+	fs.OpenOptionAddHTTPHeaders(req.Header, options...)
 	var res *http.Response
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
 		res, err = o.fs.client.Do(req)
 		return shouldRetry(err)
 	})
 	if err != nil {
 		return nil, err
 	}
-	if res.StatusCode != 200 {
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
 		_ = res.Body.Close() // ignore error
 		return nil, fmt.Errorf("Bad response: %d: %s", res.StatusCode, res.Status)
 	}
-	return res.Body, nil
+	return fs.OpenOptionsResponse(res, o.Size(), options...)
 }
 
 // Update the already existing object
@@ -916,19 +1185,24 @@ func (o *Object) Open() (in io.ReadCloser, err error) {
 // Copy the reader into the object updating modTime and size
 //
 // The new object may have been created if an error is returned
-func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
+func (o *Object) Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...fs.PutOption) error {
 	updateInfo := &drive.File{
 		Id:           o.id,
 		ModifiedDate: modTime.Format(timeFormatOut),
+		Properties:   driveProperties(options...),
+	}
+	if mimeType, _ := fs.PutOptionsMetadata(options...); mimeType != "" {
+		updateInfo.MimeType = mimeType
 	}
 
 	// Make the API request to upload metadata and file data.
 	var err error
 	var info *drive.File
+	// Streams of unknown length are chunked as in Put
 	if size == 0 || size < int64(driveUploadCutoff) {
 		// Don't retry, return a retry error instead
-		err = o.fs.pacer.CallNoRetry(func() (bool, error) {
-			info, err = o.fs.svc.Files.Update(updateInfo.Id, updateInfo).SetModifiedDate(true).Media(in).Do()
+		err = o.fs.pacer.CallNoRetry(ctx, func() (bool, error) {
+			info, err = o.fs.svc.Files.Update(updateInfo.Id, updateInfo).SetModifiedDate(true).Media(in, googleapi.ChunkSize(int(chunkSize))).Context(ctx).Do()
 			return shouldRetry(err)
 		})
 		if err != nil {
@@ -936,7 +1210,7 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 		}
 	} else {
 		// Upload the file in chunks
-		info, err = o.fs.Upload(in, size, fs.MimeType(o), updateInfo, o.remote)
+		info, err = o.fs.Upload(ctx, in, size, fs.MimeType(o, options...), updateInfo, o.remote)
 		if err != nil {
 			return err
 		}
@@ -946,13 +1220,13 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 }
 
 // Remove an object
-func (o *Object) Remove() error {
+func (o *Object) Remove(ctx context.Context) error {
 	var err error
-	err = o.fs.pacer.Call(func() (bool, error) {
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
 		if *driveUseTrash {
-			_, err = o.fs.svc.Files.Trash(o.id).Do()
+			_, err = o.fs.svc.Files.Trash(o.id).Context(ctx).Do()
 		} else {
-			err = o.fs.svc.Files.Delete(o.id).Do()
+			err = o.fs.svc.Files.Delete(o.id).Context(ctx).Do()
 		}
 		return shouldRetry(err)
 	})
@@ -961,10 +1235,18 @@ func (o *Object) Remove() error {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs       = (*Fs)(nil)
-	_ fs.Purger   = (*Fs)(nil)
-	_ fs.Copier   = (*Fs)(nil)
-	_ fs.Mover    = (*Fs)(nil)
-	_ fs.DirMover = (*Fs)(nil)
-	_ fs.Object   = (*Object)(nil)
+	_ fs.Fs               = (*Fs)(nil)
+	_ fs.Purger           = (*Fs)(nil)
+	_ fs.Copier           = (*Fs)(nil)
+	_ fs.Mover            = (*Fs)(nil)
+	_ fs.DirMover         = (*Fs)(nil)
+	_ fs.DirCacheFlusher  = (*Fs)(nil)
+	_ fs.DirLister        = (*Fs)(nil)
+	_ fs.DirMaker         = (*Fs)(nil)
+	_ fs.DirModTimeSetter = (*Fs)(nil)
+	_ fs.Abouter          = (*Fs)(nil)
+	_ fs.PublicLinker     = (*Fs)(nil)
+	_ fs.CleanUpper       = (*Fs)(nil)
+	_ fs.Object           = (*Object)(nil)
+	_ fs.Metadataer       = (*Object)(nil)
 )
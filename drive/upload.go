@@ -12,6 +12,7 @@ package drive
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -51,7 +52,7 @@ type resumableUpload struct {
 }
 
 // Upload the io.Reader in of size bytes with contentType and info
-func (f *Fs) Upload(in io.Reader, size int64, contentType string, info *drive.File, remote string) (*drive.File, error) {
+func (f *Fs) Upload(ctx context.Context, in io.Reader, size int64, contentType string, info *drive.File, remote string) (*drive.File, error) {
 	fileID := info.Id
 	var body io.Reader
 	body, err := googleapi.WithoutDataWrapper.JSONReader(info)
@@ -70,6 +71,7 @@ func (f *Fs) Upload(in io.Reader, size int64, contentType string, info *drive.Fi
 	}
 	urls += "?" + params.Encode()
 	req, _ := http.NewRequest(method, urls, body)
+	req = req.WithContext(ctx)
 	googleapi.Expand(req.URL, map[string]string{
 		"fileId": fileID,
 	})
@@ -78,7 +80,7 @@ func (f *Fs) Upload(in io.Reader, size int64, contentType string, info *drive.Fi
 	req.Header.Set("X-Upload-Content-Length", fmt.Sprintf("%v", size))
 	req.Header.Set("User-Agent", fs.UserAgent)
 	var res *http.Response
-	err = f.pacer.Call(func() (bool, error) {
+	err = f.pacer.Call(ctx, func() (bool, error) {
 		res, err = f.client.Do(req)
 		if err == nil {
 			defer googleapi.CloseBody(res)
@@ -98,13 +100,14 @@ func (f *Fs) Upload(in io.Reader, size int64, contentType string, info *drive.Fi
 		MediaType:     contentType,
 		ContentLength: size,
 	}
-	return rx.Upload()
+	return rx.Upload(ctx)
 }
 
 // Make an http.Request for the range passed in
-func (rx *resumableUpload) makeRequest(start int64, body []byte) *http.Request {
+func (rx *resumableUpload) makeRequest(ctx context.Context, start int64, body []byte) *http.Request {
 	reqSize := int64(len(body))
 	req, _ := http.NewRequest("POST", rx.URI, bytes.NewBuffer(body))
+	req = req.WithContext(ctx)
 	req.ContentLength = reqSize
 	if reqSize != 0 {
 		req.Header.Set("Content-Range", fmt.Sprintf("bytes %v-%v/%v", start, start+reqSize-1, rx.ContentLength))
@@ -123,8 +126,8 @@ var rangeRE = regexp.MustCompile(`^0\-(\d+)$`)
 // Query drive for the amount transferred so far
 //
 // If error is nil, then start should be valid
-func (rx *resumableUpload) transferStatus() (start int64, err error) {
-	req := rx.makeRequest(0, nil)
+func (rx *resumableUpload) transferStatus(ctx context.Context) (start int64, err error) {
+	req := rx.makeRequest(ctx, 0, nil)
 	res, err := rx.f.client.Do(req)
 	if err != nil {
 		return 0, err
@@ -151,8 +154,8 @@ func (rx *resumableUpload) transferStatus() (start int64, err error) {
 }
 
 // Transfer a chunk - caller must call googleapi.CloseBody(res) if err == nil || res != nil
-func (rx *resumableUpload) transferChunk(start int64, body []byte) (int, error) {
-	req := rx.makeRequest(start, body)
+func (rx *resumableUpload) transferChunk(ctx context.Context, start int64, body []byte) (int, error) {
+	req := rx.makeRequest(ctx, start, body)
 	res, err := rx.f.client.Do(req)
 	if err != nil {
 		return 599, err
@@ -185,7 +188,7 @@ func (rx *resumableUpload) transferChunk(start int64, body []byte) (int, error)
 
 // Upload uploads the chunks from the input
 // It retries each chunk maxTries times (with a pause of uploadPause between attempts).
-func (rx *resumableUpload) Upload() (*drive.File, error) {
+func (rx *resumableUpload) Upload(ctx context.Context) (*drive.File, error) {
 	start := int64(0)
 	buf := make([]byte, chunkSize)
 	var StatusCode int
@@ -204,9 +207,9 @@ func (rx *resumableUpload) Upload() (*drive.File, error) {
 		}
 
 		// Transfer the chunk
-		err = rx.f.pacer.Call(func() (bool, error) {
+		err = rx.f.pacer.Call(ctx, func() (bool, error) {
 			fs.Debug(rx.remote, "Sending chunk %d length %d", start, reqSize)
-			StatusCode, err = rx.transferChunk(start, buf)
+			StatusCode, err = rx.transferChunk(ctx, start, buf)
 			again, err := shouldRetry(err)
 			if StatusCode == statusResumeIncomplete || StatusCode == http.StatusCreated || StatusCode == http.StatusOK {
 				again = false
@@ -226,16 +226,26 @@ func (s *StatsInfo) GetTransfers() int64 {
 
 // Transferring adds a transfer into the stats
 func (s *StatsInfo) Transferring(o Object) {
+	s.transferringName(o.Remote())
+}
+
+// transferringName adds a transfer of name into the stats
+func (s *StatsInfo) transferringName(name string) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.transferring[o.Remote()] = struct{}{}
+	s.transferring[name] = struct{}{}
 }
 
 // DoneTransferring removes a transfer from the stats
 func (s *StatsInfo) DoneTransferring(o Object) {
+	s.doneTransferringName(o.Remote())
+}
+
+// doneTransferringName removes a transfer of name from the stats
+func (s *StatsInfo) doneTransferringName(name string) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	delete(s.transferring, o.Remote())
+	delete(s.transferring, name)
 	s.transfers++
 }
 
@@ -262,10 +272,18 @@ type Account struct {
 
 // NewAccount makes a Account reader for an object
 func NewAccount(in io.ReadCloser, obj Object) *Account {
+	return NewAccountSizeName(in, obj.Size(), obj.Remote())
+}
+
+// NewAccountSizeName makes a Account reader for an io.ReadCloser of
+// the given size and name
+//
+// The size may be -1 if it isn't known
+func NewAccountSizeName(in io.ReadCloser, size int64, name string) *Account {
 	acc := &Account{
 		in:     in,
-		size:   obj.Size(),
-		name:   obj.Remote(),
+		size:   size,
+		name:   name,
 		exit:   make(chan struct{}),
 		avg:    ewma.NewMovingAverage(),
 		lpTime: time.Now(),
@@ -0,0 +1,53 @@
+// Helpers for context.Context
+
+package fs
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader wraps an io.Reader so it stops reading when the
+// context is cancelled
+type contextReader struct {
+	ctx context.Context
+	in  io.Reader
+}
+
+// NewContextReader returns an io.Reader which reads from in until ctx
+// is cancelled, after which every Read returns ctx.Err().
+//
+// This is for backends whose libraries can't be passed a context -
+// wrapping the upload body aborts the transfer on cancellation.
+func NewContextReader(ctx context.Context, in io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, in: in}
+}
+
+// Read bytes from the underlying reader unless cancelled - see io.Reader
+func (r *contextReader) Read(p []byte) (n int, err error) {
+	if err = r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.in.Read(p)
+}
+
+// contextReadCloser is a contextReader which can be closed
+type contextReadCloser struct {
+	contextReader
+	closer io.Closer
+}
+
+// NewContextReadCloser returns an io.ReadCloser which reads from in
+// until ctx is cancelled, after which every Read returns ctx.Err().
+// Close closes in.
+func NewContextReadCloser(ctx context.Context, in io.ReadCloser) io.ReadCloser {
+	return &contextReadCloser{
+		contextReader: contextReader{ctx: ctx, in: in},
+		closer:        in,
+	}
+}
+
+// Close the underlying reader - see io.Closer
+func (r *contextReadCloser) Close() error {
+	return r.closer.Close()
+}
@@ -0,0 +1,271 @@
+// Find and deal with objects with duplicate names
+
+package fs
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// DeduplicateMode is how Deduplicate deals with duplicate names
+type DeduplicateMode int
+
+// Deduplicate modes
+const (
+	DeduplicateInteractive DeduplicateMode = iota // interactively ask the user
+	DeduplicateSkip                               // skip all conflicts
+	DeduplicateFirst                              // choose the first object
+	DeduplicateNewest                             // choose the newest object
+	DeduplicateOldest                             // choose the oldest object
+	DeduplicateRename                             // rename the objects
+)
+
+// deduplicateModeNames are the names of the modes in flag order
+var deduplicateModeNames = []string{
+	DeduplicateInteractive: "interactive",
+	DeduplicateSkip:        "skip",
+	DeduplicateFirst:       "first",
+	DeduplicateNewest:      "newest",
+	DeduplicateOldest:      "oldest",
+	DeduplicateRename:      "rename",
+}
+
+// String turns a DeduplicateMode into a string
+func (mode DeduplicateMode) String() string {
+	if mode < 0 || int(mode) >= len(deduplicateModeNames) {
+		return "unknown"
+	}
+	return deduplicateModeNames[mode]
+}
+
+// Set a DeduplicateMode from a string
+func (mode *DeduplicateMode) Set(s string) error {
+	for i, name := range deduplicateModeNames {
+		if strings.ToLower(s) == name {
+			*mode = DeduplicateMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown mode for dedupe %q - use one of %s", s, strings.Join(deduplicateModeNames, "|"))
+}
+
+// Type of the value
+func (mode *DeduplicateMode) Type() string {
+	return "string"
+}
+
+// Check it satisfies the interface
+var _ pflag.Value = (*DeduplicateMode)(nil)
+
+// dedupeDelete deletes o unless --dry-run is set
+func dedupeDelete(ctx context.Context, o Object) {
+	if Config.DryRun {
+		Log(o, "Not deleting as --dry-run")
+		return
+	}
+	err := o.Remove(ctx)
+	if err != nil {
+		Stats.Error()
+		ErrorLog(o, "Couldn't delete: %v", err)
+		return
+	}
+	Log(o, "Deleted")
+}
+
+// dedupeDeleteAllButOne deletes all of objs except the one at keep
+func dedupeDeleteAllButOne(ctx context.Context, keep int, remote string, objs []Object) {
+	Log(remote, "Keeping one of %d duplicates", len(objs))
+	for i, o := range objs {
+		if i != keep {
+			dedupeDelete(ctx, o)
+		}
+	}
+}
+
+// dedupeDeleteIdentical deletes all but one of the objects in objs
+// which have the same MD5 sum, returning the objects which are left
+//
+// Objects whose MD5 sum can't be read are left alone.
+func dedupeDeleteIdentical(ctx context.Context, remote string, objs []Object) []Object {
+	var remaining []Object
+	seen := make(map[string]bool)
+	for _, o := range objs {
+		md5sum, err := o.Hash(ctx, HashMD5)
+		if err != nil || md5sum == "" {
+			remaining = append(remaining, o)
+			continue
+		}
+		if seen[md5sum] {
+			Log(remote, "Deleting identical duplicate with MD5 %q", md5sum)
+			dedupeDelete(ctx, o)
+			continue
+		}
+		seen[md5sum] = true
+		remaining = append(remaining, o)
+	}
+	return remaining
+}
+
+// dedupeInteractive asks the user what to do with the duplicates
+func dedupeInteractive(ctx context.Context, f Fs, remote string, objs []Object) error {
+	fmt.Printf("%s: %d duplicates remain\n", remote, len(objs))
+	for i, o := range objs {
+		md5sum, err := o.Hash(ctx, HashMD5)
+		if err != nil {
+			md5sum = err.Error()
+		}
+		fmt.Printf("  %d: %12d bytes, %s, md5sum %32s\n", i+1, o.Size(), o.ModTime(ctx).Format("2006-01-02 15:04:05.000000000"), md5sum)
+	}
+	switch Command([]string{"sSkip and do nothing", "kKeep just one (choose which in next step)", "rRename all to be different (by changing file.jpg to file-1.jpg)"}) {
+	case 's':
+	case 'k':
+		for {
+			fmt.Printf("Enter the number of the file to keep> ")
+			n, err := strconv.Atoi(ReadLine())
+			if err == nil && n >= 1 && n <= len(objs) {
+				dedupeDeleteAllButOne(ctx, n-1, remote, objs)
+				return nil
+			}
+		}
+	case 'r':
+		return dedupeRename(ctx, f, remote, objs)
+	}
+	return nil
+}
+
+// objectsByModTime sorts Objects by ModTime
+type objectsByModTime struct {
+	ctx  context.Context
+	objs []Object
+}
+
+func (os objectsByModTime) Len() int      { return len(os.objs) }
+func (os objectsByModTime) Swap(i, j int) { os.objs[i], os.objs[j] = os.objs[j], os.objs[i] }
+func (os objectsByModTime) Less(i, j int) bool {
+	return os.objs[i].ModTime(os.ctx).Before(os.objs[j].ModTime(os.ctx))
+}
+
+// dedupeRename renames the objs so they all have different names,
+// changing file.jpg to file-1.jpg, file-2.jpg etc, skipping any of
+// those names which are already in use
+func dedupeRename(ctx context.Context, f Fs, remote string, objs []Object) error {
+	doMove := f.Features().Move
+	if doMove == nil {
+		return fmt.Errorf("%v can't Move so can't rename duplicates", f)
+	}
+	ext := path.Ext(remote)
+	base := remote[:len(remote)-len(ext)]
+	suffix := 0
+	for _, o := range objs {
+		var newName string
+		for {
+			suffix++
+			newName = fmt.Sprintf("%s-%d%s", base, suffix, ext)
+			if f.NewFsObject(ctx, newName) == nil {
+				break
+			}
+			Debug(o, "Not renaming to %q as it already exists", newName)
+		}
+		if Config.DryRun {
+			Log(o, "Not renaming to %q as --dry-run", newName)
+			continue
+		}
+		newObj, err := doMove(ctx, o, newName)
+		if err != nil {
+			Stats.Error()
+			ErrorLog(o, "Failed to rename to %q: %v", newName, err)
+			continue
+		}
+		Log(newObj, "Renamed from %q", remote)
+	}
+	return nil
+}
+
+// dedupe deals with the objs which all have the name remote
+// according to mode
+func dedupe(ctx context.Context, f Fs, mode DeduplicateMode, remote string, objs []Object) error {
+	Log(remote, "Found %d duplicates - deleting identical copies", len(objs))
+	objs = dedupeDeleteIdentical(ctx, remote, objs)
+	if len(objs) <= 1 {
+		Log(remote, "All duplicates removed")
+		return nil
+	}
+	switch mode {
+	case DeduplicateInteractive:
+		return dedupeInteractive(ctx, f, remote, objs)
+	case DeduplicateFirst:
+		dedupeDeleteAllButOne(ctx, 0, remote, objs)
+	case DeduplicateNewest:
+		sort.Sort(objectsByModTime{ctx, objs})
+		dedupeDeleteAllButOne(ctx, len(objs)-1, remote, objs)
+	case DeduplicateOldest:
+		sort.Sort(objectsByModTime{ctx, objs})
+		dedupeDeleteAllButOne(ctx, 0, remote, objs)
+	case DeduplicateRename:
+		return dedupeRename(ctx, f, remote, objs)
+	case DeduplicateSkip:
+		Log(remote, "Skipping %d duplicates", len(objs))
+	default:
+		return fmt.Errorf("Unknown dedupe mode %v", mode)
+	}
+	return nil
+}
+
+// Deduplicate finds objects with the same name in f, which only
+// remotes with the DuplicateFiles feature such as Drive allow, and
+// deals with them according to mode
+//
+// Duplicates with the same MD5 sum are deleted whatever the mode so
+// only one copy is left.  It observes --dry-run.
+func Deduplicate(ctx context.Context, f Fs, mode DeduplicateMode) error {
+	Log(f, "Looking for duplicates using %v mode", mode)
+	if mode == DeduplicateRename && f.Features().Move == nil {
+		return fmt.Errorf("%v can't Move so can't rename duplicates", f)
+	}
+	lister := newDirLister(f)
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		objects, dirs, err := lister.List(ctx, dir)
+		if err != nil {
+			return err
+		}
+		// objects are sorted by name so duplicates are adjacent
+		for i := 0; i < len(objects); {
+			remote := objects[i].Remote()
+			j := i + 1
+			for j < len(objects) && objects[j].Remote() == remote {
+				j++
+			}
+			if j-i > 1 {
+				err = dedupe(ctx, f, mode, remote, objects[i:j])
+				if err != nil {
+					return err
+				}
+			}
+			i = j
+		}
+		for i, d := range dirs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// dirs are sorted by name too but directories
+			// can't be merged so only the first is looked in
+			if i > 0 && d.Name == dirs[i-1].Name {
+				Log(d.Name, "Duplicate directory found - merge it by hand")
+				continue
+			}
+			err = walk(d.Name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk("")
+}
@@ -0,0 +1,125 @@
+package fs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeduplicateModeSet(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want DeduplicateMode
+		err  bool
+	}{
+		{"interactive", DeduplicateInteractive, false},
+		{"skip", DeduplicateSkip, false},
+		{"First", DeduplicateFirst, false},
+		{"NEWEST", DeduplicateNewest, false},
+		{"oldest", DeduplicateOldest, false},
+		{"rename", DeduplicateRename, false},
+		{"potato", DeduplicateInteractive, true},
+	} {
+		var mode DeduplicateMode
+		err := mode.Set(test.in)
+		if (err != nil) != test.err {
+			t.Errorf("%q: want error %v got %v", test.in, test.err, err)
+		}
+		if mode != test.want {
+			t.Errorf("%q: want %v got %v", test.in, test.want, mode)
+		}
+		if !test.err && mode.String() != deduplicateModeNames[mode] {
+			t.Errorf("%q: bad String() %q", test.in, mode.String())
+		}
+	}
+}
+
+// dedupeObject is an Object with a hash and modification time which
+// records whether it has been removed
+type dedupeObject struct {
+	Object
+	md5sum  string
+	modTime time.Time
+	removed bool
+}
+
+func (o *dedupeObject) Remote() string                                       { return "file.txt" }
+func (o *dedupeObject) ModTime(ctx context.Context) time.Time                { return o.modTime }
+func (o *dedupeObject) Hash(ctx context.Context, t HashType) (string, error) { return o.md5sum, nil }
+func (o *dedupeObject) Remove(ctx context.Context) error {
+	o.removed = true
+	return nil
+}
+
+func TestDedupe(t *testing.T) {
+	t1 := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	for _, test := range []struct {
+		mode DeduplicateMode
+		want string // which objects are left
+	}{
+		{DeduplicateSkip, "abc"},
+		{DeduplicateFirst, "a"},
+		{DeduplicateNewest, "b"},
+		{DeduplicateOldest, "c"},
+	} {
+		objs := map[string]*dedupeObject{
+			"a": {md5sum: "1", modTime: t1.Add(time.Hour)},
+			"b": {md5sum: "2", modTime: t1.Add(2 * time.Hour)},
+			"c": {md5sum: "3", modTime: t1},
+			"d": {md5sum: "1", modTime: t1}, // identical to a
+		}
+		err := dedupe(context.Background(), nil, test.mode, "file.txt", []Object{objs["a"], objs["b"], objs["c"], objs["d"]})
+		if err != nil {
+			t.Fatalf("%v: dedupe failed: %v", test.mode, err)
+		}
+		got := ""
+		for _, name := range []string{"a", "b", "c", "d"} {
+			if !objs[name].removed {
+				got += name
+			}
+		}
+		if got != test.want {
+			t.Errorf("%v: want %q left got %q", test.mode, test.want, got)
+		}
+	}
+}
+
+// renameFs can Move, recording the names objects are moved to, and
+// already has the objects named in existing
+type renameFs struct {
+	Fs
+	existing map[string]bool
+	moved    []string
+}
+
+func (f *renameFs) Features() *Features { return &Features{Move: f.Move} }
+func (f *renameFs) NewFsObject(ctx context.Context, remote string) Object {
+	if f.existing[remote] {
+		return &dedupeObject{}
+	}
+	return nil
+}
+func (f *renameFs) Move(ctx context.Context, src Object, remote string) (Object, error) {
+	f.existing[remote] = true
+	f.moved = append(f.moved, remote)
+	return src, nil
+}
+
+func TestDedupeRename(t *testing.T) {
+	f := &renameFs{existing: map[string]bool{"file-2.txt": true}}
+	objs := []Object{
+		&dedupeObject{md5sum: "1"},
+		&dedupeObject{md5sum: "2"},
+		&dedupeObject{md5sum: "3"},
+	}
+	err := dedupe(context.Background(), f, DeduplicateRename, "file.txt", objs)
+	if err != nil {
+		t.Fatalf("dedupe failed: %v", err)
+	}
+	got := strings.Join(f.moved, ",")
+	want := "file-1.txt,file-3.txt,file-4.txt"
+	if got != want {
+		t.Errorf("want renamed to %q got %q", want, got)
+	}
+}
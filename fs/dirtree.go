@@ -0,0 +1,220 @@
+// Build a tree of the directories in an Fs with their totals
+
+package fs
+
+import (
+	"context"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DirTree is a directory in an Fs along with the number and total
+// size of the objects in it and all its sub directories
+type DirTree struct {
+	Name    string     // path relative to the root of the Fs, "" for the root
+	Count   int64      // number of objects in and beneath this directory
+	Bytes   int64      // total size of those objects
+	Objects []Object   // objects directly in this directory, if kept
+	Dirs    []*DirTree // sub directories
+}
+
+// NewDirTree lists f in a single pass and returns the tree of its
+// directories - obeys includes and excludes
+//
+// Only directories up to maxDepth levels down are in the tree, or
+// all of them if maxDepth < 0, and objects in deeper directories are
+// counted in their ancestor at maxDepth.  The Objects are only kept,
+// for the directories less than maxDepth levels down, if keepObjects
+// is set, otherwise just the counts are.
+//
+// Directories are only found if they have objects in them.  The
+// contents of each directory are sorted by name, or by size largest
+// first if sortBySize is set.
+func NewDirTree(ctx context.Context, f Fs, maxDepth int, keepObjects, sortBySize bool) (*DirTree, error) {
+	root := &DirTree{}
+	dirs := map[string]*DirTree{"": root}
+	// getDir finds or makes the DirTree for dir and its parents
+	var getDir func(dir string) *DirTree
+	getDir = func(dir string) *DirTree {
+		if d, ok := dirs[dir]; ok {
+			return d
+		}
+		parent := path.Dir(dir)
+		if parent == "." {
+			parent = ""
+		}
+		d := &DirTree{Name: dir}
+		p := getDir(parent)
+		p.Dirs = append(p.Dirs, d)
+		dirs[dir] = d
+		return d
+	}
+
+	list := NewLister().Start(ctx, f)
+	for {
+		o, err := list.Get()
+		if err != nil {
+			return nil, err
+		}
+		if o == nil {
+			break
+		}
+		if !Config.Filter.IncludeObject(ctx, o) {
+			continue
+		}
+		dir := path.Dir(o.Remote())
+		if dir == "." {
+			dir = ""
+		}
+		depth := 0
+		if dir != "" {
+			depth = strings.Count(dir, "/") + 1
+		}
+		if maxDepth >= 0 && depth > maxDepth {
+			dir = strings.Join(strings.SplitN(dir, "/", maxDepth+1)[:maxDepth], "/")
+		}
+		d := getDir(dir)
+		if keepObjects && (maxDepth < 0 || depth < maxDepth) {
+			d.Objects = append(d.Objects, o)
+		}
+		size := o.Size()
+		if size < 0 {
+			size = 0 // unknown
+		}
+		// Add the object to the totals of d and all its parents
+		for {
+			d.Count++
+			d.Bytes += size
+			if d == root {
+				break
+			}
+			parent := path.Dir(d.Name)
+			if parent == "." {
+				parent = ""
+			}
+			d = dirs[parent]
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, d := range dirs {
+		d.sort(sortBySize)
+	}
+	return root, nil
+}
+
+// sort the contents of d by name or by size largest first
+func (d *DirTree) sort(bySize bool) {
+	sort.Sort(objectsBySize{d.Objects, bySize})
+	sort.Sort(dirTreesBySize{d.Dirs, bySize})
+}
+
+// objectsBySize sorts Objects by size largest first if bySize is set
+// otherwise by name
+type objectsBySize struct {
+	objs   []Object
+	bySize bool
+}
+
+func (os objectsBySize) Len() int      { return len(os.objs) }
+func (os objectsBySize) Swap(i, j int) { os.objs[i], os.objs[j] = os.objs[j], os.objs[i] }
+func (os objectsBySize) Less(i, j int) bool {
+	a, b := os.objs[i], os.objs[j]
+	if os.bySize && a.Size() != b.Size() {
+		return a.Size() > b.Size()
+	}
+	return a.Remote() < b.Remote()
+}
+
+// dirTreesBySize sorts DirTrees by size largest first if bySize is
+// set otherwise by name
+type dirTreesBySize struct {
+	dirs   []*DirTree
+	bySize bool
+}
+
+func (ds dirTreesBySize) Len() int      { return len(ds.dirs) }
+func (ds dirTreesBySize) Swap(i, j int) { ds.dirs[i], ds.dirs[j] = ds.dirs[j], ds.dirs[i] }
+func (ds dirTreesBySize) Less(i, j int) bool {
+	a, b := ds.dirs[i], ds.dirs[j]
+	if ds.bySize && a.Bytes != b.Bytes {
+		return a.Bytes > b.Bytes
+	}
+	return a.Name < b.Name
+}
+
+// Tree writes an ASCII tree of the directories and objects in f to w
+// with their sizes - obeys includes and excludes
+//
+// Directories are shown with the total size of everything beneath
+// them.  If maxDepth >= 0 then only that many levels are shown.
+func Tree(ctx context.Context, f Fs, w io.Writer, maxDepth int, sortBySize bool) error {
+	root, err := NewDirTree(ctx, f, maxDepth, true, sortBySize)
+	if err != nil {
+		return err
+	}
+	var dirs, objects int
+	var walk func(d *DirTree, indent string, depth int)
+	walk = func(d *DirTree, indent string, depth int) {
+		if maxDepth >= 0 && depth > maxDepth {
+			return
+		}
+		n := len(d.Dirs) + len(d.Objects)
+		i := 0
+		// branch returns the prefixes for the next entry and its children
+		branch := func() (string, string) {
+			i++
+			if i == n {
+				return indent + "`-- ", indent + "    "
+			}
+			return indent + "|-- ", indent + "|   "
+		}
+		for _, sub := range d.Dirs {
+			prefix, childIndent := branch()
+			dirs++
+			syncFprintf(w, "%s[%12d]  %s/ (%d files)\n", prefix, sub.Bytes, path.Base(sub.Name), sub.Count)
+			walk(sub, childIndent, depth+1)
+		}
+		for _, o := range d.Objects {
+			prefix, _ := branch()
+			objects++
+			syncFprintf(w, "%s[%12d]  %s\n", prefix, o.Size(), path.Base(o.Remote()))
+		}
+	}
+	syncFprintf(w, "[%12d]  %v (%d files)\n", root.Bytes, f, root.Count)
+	walk(root, "", 1)
+	syncFprintf(w, "\n%d directories, %d files\n", dirs, objects)
+	return nil
+}
+
+// Du writes the number of objects and their total size for each
+// directory in f to w, sub directories before their parents and the
+// whole of f last - obeys includes and excludes
+//
+// If maxDepth >= 0 then only directories that many levels deep are
+// shown though everything beneath them is still counted.
+func Du(ctx context.Context, f Fs, w io.Writer, maxDepth int, sortBySize bool) error {
+	root, err := NewDirTree(ctx, f, maxDepth, false, sortBySize)
+	if err != nil {
+		return err
+	}
+	var walk func(d *DirTree, depth int)
+	walk = func(d *DirTree, depth int) {
+		if maxDepth >= 0 && depth > maxDepth {
+			return
+		}
+		for _, sub := range d.Dirs {
+			walk(sub, depth+1)
+		}
+		name := d.Name
+		if name == "" {
+			name = "."
+		}
+		syncFprintf(w, "%12d %9d %s\n", d.Bytes, d.Count, name)
+	}
+	walk(root, 0)
+	return nil
+}
@@ -4,6 +4,7 @@ package fs
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"regexp"
@@ -256,6 +257,17 @@ func (f *Filter) Clear() {
 	f.rules = nil
 }
 
+// InActive returns true if no filters are in use, so everything
+// will be included
+func (f *Filter) InActive() bool {
+	return f.files == nil &&
+		f.ModTimeFrom.IsZero() &&
+		f.ModTimeTo.IsZero() &&
+		f.MinSize == 0 &&
+		f.MaxSize == 0 &&
+		len(f.rules) == 0
+}
+
 // Include returns whether this object should be included into the
 // sync or not
 func (f *Filter) Include(remote string, size int64, modTime time.Time) bool {
@@ -287,11 +299,11 @@ func (f *Filter) Include(remote string, size int64, modTime time.Time) bool {
 // IncludeObject returns whether this object should be included into
 // the sync or not. This is a convenience function to avoid calling
 // o.ModTime(), which is an expensive operation.
-func (f *Filter) IncludeObject(o Object) bool {
+func (f *Filter) IncludeObject(ctx context.Context, o Object) bool {
 	var modTime time.Time
 
 	if !f.ModTimeFrom.IsZero() || !f.ModTimeTo.IsZero() {
-		modTime = o.ModTime()
+		modTime = o.ModTime(ctx)
 	} else {
 		modTime = time.Unix(0, 0)
 	}
@@ -62,6 +62,9 @@ func TestNewFilterDefault(t *testing.T) {
 	if f.files != nil {
 		t.Errorf("files want none got %v", f.files)
 	}
+	if !f.InActive() {
+		t.Errorf("want InActive")
+	}
 }
 
 // return a pointer to the string
@@ -219,6 +222,9 @@ func TestNewFilterMinSize(t *testing.T) {
 		{"file2.jpg", 101, 0, true},
 		{"potato/file2.jpg", 99, 0, false},
 	})
+	if f.InActive() {
+		t.Errorf("want !InActive")
+	}
 }
 
 func TestNewFilterMaxSize(t *testing.T) {
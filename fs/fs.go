@@ -2,11 +2,13 @@
 package fs
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -30,6 +32,10 @@ var (
 	ErrorCantMove             = fmt.Errorf("Can't copy object - incompatible remotes")
 	ErrorCantDirMove          = fmt.Errorf("Can't copy directory - incompatible remotes")
 	ErrorDirExists            = fmt.Errorf("Can't copy directory - destination already exists")
+	ErrorListAborted          = fmt.Errorf("List aborted")
+	ErrorDirNotFound          = fmt.Errorf("Directory not found")
+	ErrorNotImplemented       = fmt.Errorf("Optional feature not implemented")
+	ErrorObjectNotFound       = fmt.Errorf("Object not found")
 )
 
 // Info information about a filesystem
@@ -68,6 +74,10 @@ func Register(info *Info) {
 }
 
 // Fs is the interface a cloud storage system must provide
+//
+// Methods which talk to the remote take a context.Context - if it is
+// cancelled then any operation in progress should be aborted and
+// ctx.Err() returned.
 type Fs interface {
 	// Name of the remote (as passed into NewFs)
 	Name() string
@@ -78,34 +88,231 @@ type Fs interface {
 	// String returns a description of the FS
 	String() string
 
-	// List the Fs into a channel
-	List() ObjectsChan
+	// List the objects in the Fs into out
+	//
+	// This runs synchronously - the caller will normally run it in
+	// a goroutine (see Lister).  Any error should be passed to
+	// out.SetError and out.Finished must be called when done.
+	List(ctx context.Context, out ListOpts)
 
 	// ListDir lists the Fs directories/buckets/containers into a channel
-	ListDir() DirChan
+	ListDir(ctx context.Context) DirChan
 
 	// NewFsObject finds the Object at remote.  Returns nil if can't be found
-	NewFsObject(remote string) Object
+	NewFsObject(ctx context.Context, remote string) Object
 
 	// Put in to the remote path with the modTime given of the given size
 	//
+	// If size is -1 then the length of in isn't known in advance
+	// and it should be read until io.EOF.
+	//
+	// Options such as MetadataOption may be passed in to set the
+	// content type and user metadata of the object.
+	//
 	// May create the object even if it returns an error - if so
 	// will return the object and the error, otherwise will return
 	// nil and the error
-	Put(in io.Reader, remote string, modTime time.Time, size int64) (Object, error)
+	Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...PutOption) (Object, error)
 
 	// Mkdir makes the directory (container, bucket)
 	//
 	// Shouldn't return an error if it already exists
-	Mkdir() error
+	Mkdir(ctx context.Context) error
 
 	// Rmdir removes the directory (container, bucket) if empty
 	//
 	// Return an error if it doesn't exist or isn't empty
-	Rmdir() error
+	Rmdir(ctx context.Context) error
 
 	// Precision of the ModTimes in this Fs
 	Precision() time.Duration
+
+	// Returns the supported hash types of the filesystem
+	Hashes() HashSet
+
+	// Features returns the optional features of this Fs
+	Features() *Features
+}
+
+// Features describe the optional features of the Fs
+//
+// The functions are nil if the Fs doesn't support them.
+type Features struct {
+	CaseInsensitive         bool // has case insensitive files
+	DuplicateFiles          bool // allows duplicate files
+	CanHaveEmptyDirectories bool // can have empty directories
+
+	// Purge all files in the root and the root directory
+	//
+	// See Purger
+	Purge func(ctx context.Context) error
+
+	// Copy src to this remote using server side copy operations.
+	//
+	// See Copier
+	Copy func(ctx context.Context, src Object, remote string) (Object, error)
+
+	// Move src to this remote using server side move operations.
+	//
+	// See Mover
+	Move func(ctx context.Context, src Object, remote string) (Object, error)
+
+	// DirMove moves src to this remote using server side move
+	// operations.
+	//
+	// See DirMover
+	DirMove func(ctx context.Context, src Fs) error
+
+	// UnWrap returns the Fs that this Fs is wrapping
+	//
+	// See UnWrapper
+	UnWrap func() Fs
+
+	// ListDirEntries lists the objects and directories directly
+	// in dir
+	//
+	// See DirLister
+	ListDirEntries func(ctx context.Context, dir string) (Objects, []*Dir, error)
+
+	// About gets quota information from the Fs
+	//
+	// See Abouter
+	About func(ctx context.Context) (*Usage, error)
+
+	// PublicLink makes a link which can be used to read remote
+	// without any credentials
+	//
+	// See PublicLinker
+	PublicLink func(ctx context.Context, remote string, expire time.Duration) (link string, err error)
+
+	// CleanUp the trash in the Fs
+	//
+	// See CleanUpper
+	CleanUp func(ctx context.Context) error
+
+	// MakeDir makes the directory dir below the root and any
+	// parents it needs
+	//
+	// See DirMaker
+	MakeDir func(ctx context.Context, dir string) error
+
+	// RemoveDir removes the directory dir below the root if it
+	// is empty
+	//
+	// See DirMaker
+	RemoveDir func(ctx context.Context, dir string) error
+
+	// SetDirModTime sets the modification time of the directory
+	// dir below the root
+	//
+	// See DirModTimeSetter
+	SetDirModTime func(ctx context.Context, dir string, modTime time.Time) error
+
+	// DirCacheFlush forgets any cached directory IDs so changes
+	// made through another Fs are seen
+	//
+	// See DirCacheFlusher
+	DirCacheFlush func()
+}
+
+// Fill fills in the functions in ft from the optional interfaces
+// that f implements and returns ft
+func (ft *Features) Fill(f Fs) *Features {
+	if do, ok := f.(Purger); ok {
+		ft.Purge = do.Purge
+	}
+	if do, ok := f.(Copier); ok {
+		ft.Copy = do.Copy
+	}
+	if do, ok := f.(Mover); ok {
+		ft.Move = do.Move
+	}
+	if do, ok := f.(DirMover); ok {
+		ft.DirMove = do.DirMove
+	}
+	if do, ok := f.(UnWrapper); ok {
+		ft.UnWrap = do.UnWrap
+	}
+	if do, ok := f.(DirLister); ok {
+		ft.ListDirEntries = do.ListDirEntries
+	}
+	if do, ok := f.(Abouter); ok {
+		ft.About = do.About
+	}
+	if do, ok := f.(PublicLinker); ok {
+		ft.PublicLink = do.PublicLink
+	}
+	if do, ok := f.(CleanUpper); ok {
+		ft.CleanUp = do.CleanUp
+	}
+	if do, ok := f.(DirMaker); ok {
+		ft.MakeDir = do.MakeDir
+		ft.RemoveDir = do.RemoveDir
+	}
+	if do, ok := f.(DirModTimeSetter); ok {
+		ft.SetDirModTime = do.SetDirModTime
+	}
+	if do, ok := f.(DirCacheFlusher); ok {
+		ft.DirCacheFlush = do.DirCacheFlush
+	}
+	return ft
+}
+
+// Mask removes the features from ft which the Fs f doesn't have
+// and returns ft
+//
+// This is for wrappers which can only do what the Fs they wrap can
+// do.  UnWrap describes the wrapper itself so is left alone.
+func (ft *Features) Mask(f Fs) *Features {
+	mask := f.Features()
+	ft.CaseInsensitive = ft.CaseInsensitive && mask.CaseInsensitive
+	ft.DuplicateFiles = ft.DuplicateFiles && mask.DuplicateFiles
+	ft.CanHaveEmptyDirectories = ft.CanHaveEmptyDirectories && mask.CanHaveEmptyDirectories
+	if mask.Purge == nil {
+		ft.Purge = nil
+	}
+	if mask.Copy == nil {
+		ft.Copy = nil
+	}
+	if mask.Move == nil {
+		ft.Move = nil
+	}
+	if mask.DirMove == nil {
+		ft.DirMove = nil
+	}
+	if mask.ListDirEntries == nil {
+		ft.ListDirEntries = nil
+	}
+	if mask.About == nil {
+		ft.About = nil
+	}
+	if mask.PublicLink == nil {
+		ft.PublicLink = nil
+	}
+	if mask.CleanUp == nil {
+		ft.CleanUp = nil
+	}
+	if mask.MakeDir == nil {
+		ft.MakeDir = nil
+	}
+	if mask.RemoveDir == nil {
+		ft.RemoveDir = nil
+	}
+	if mask.SetDirModTime == nil {
+		ft.SetDirModTime = nil
+	}
+	if mask.DirCacheFlush == nil {
+		ft.DirCacheFlush = nil
+	}
+	return ft
+}
+
+// Wrap sets ft to the features of f, for an Fs which passes
+// everything through to f, with UnWrap returning f and returns ft
+func (ft *Features) Wrap(f Fs) *Features {
+	*ft = *f.Features()
+	ft.UnWrap = func() Fs { return f }
+	return ft
 }
 
 // Object is a filesystem like object provided by an Fs
@@ -119,31 +326,48 @@ type Object interface {
 	// Remote returns the remote path
 	Remote() string
 
-	// Md5sum returns the md5 checksum of the file
-	// If no Md5sum is available it returns ""
-	Md5sum() (string, error)
+	// Hash returns the selected checksum of the file
+	// If no checksum is available it returns ""
+	Hash(ctx context.Context, hashType HashType) (string, error)
 
 	// ModTime returns the modification date of the file
 	// It should return a best guess if one isn't available
-	ModTime() time.Time
+	ModTime(ctx context.Context) time.Time
 
 	// SetModTime sets the metadata on the object to set the modification date
-	SetModTime(time.Time)
+	SetModTime(ctx context.Context, modTime time.Time)
 
 	// Size returns the size of the file
 	Size() int64
 
 	// Open opens the file for read.  Call Close() on the returned io.ReadCloser
-	Open() (io.ReadCloser, error)
+	//
+	// Options such as SeekOption and RangeOption may be passed in
+	// to read only part of the object.
+	Open(ctx context.Context, options ...OpenOption) (io.ReadCloser, error)
 
 	// Update in to the object with the modTime given of the given size
-	Update(in io.Reader, modTime time.Time, size int64) error
+	//
+	// If size is -1 then the length of in isn't known in advance
+	//
+	// Options are as for Fs.Put.
+	Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...PutOption) error
 
 	// Storable says whether this object can be stored
 	Storable() bool
 
 	// Removes this object
-	Remove() error
+	Remove(ctx context.Context) error
+}
+
+// Metadataer is an optional interface for Object
+type Metadataer interface {
+	// MimeType returns the content type of the Object or "" if
+	// it isn't known
+	MimeType(ctx context.Context) string
+
+	// Metadata returns the user defined metadata of the Object
+	Metadata(ctx context.Context) (Metadata, error)
 }
 
 // Purger is an optional interfaces for Fs
@@ -154,7 +378,7 @@ type Purger interface {
 	// quicker than just running Remove() on the result of List()
 	//
 	// Return an error if it doesn't exist
-	Purge() error
+	Purge(ctx context.Context) error
 }
 
 // Copier is an optional interface for Fs
@@ -168,7 +392,7 @@ type Copier interface {
 	// Will only be called if src.Fs().Name() == f.Name()
 	//
 	// If it isn't possible then return fs.ErrorCantCopy
-	Copy(src Object, remote string) (Object, error)
+	Copy(ctx context.Context, src Object, remote string) (Object, error)
 }
 
 // Mover is an optional interface for Fs
@@ -182,7 +406,7 @@ type Mover interface {
 	// Will only be called if src.Fs().Name() == f.Name()
 	//
 	// If it isn't possible then return fs.ErrorCantMove
-	Move(src Object, remote string) (Object, error)
+	Move(ctx context.Context, src Object, remote string) (Object, error)
 }
 
 // DirMover is an optional interface for Fs
@@ -195,7 +419,59 @@ type DirMover interface {
 	// If it isn't possible then return fs.ErrorCantDirMove
 	//
 	// If destination exists then return fs.ErrorDirExists
-	DirMove(src Fs) error
+	DirMove(ctx context.Context, src Fs) error
+}
+
+// DirLister is an optional interface for Fs
+type DirLister interface {
+	// ListDirEntries lists the objects and directories directly
+	// in dir, which is relative to the root of the Fs, "" being
+	// the root itself.
+	//
+	// Object.Remote() and Dir.Name are relative to the root of
+	// the Fs, as for List.  They may be returned in any order.
+	//
+	// Implement this if you can list a single directory without
+	// reading everything below it, so that sync doesn't have to
+	// hold the whole listing in memory.
+	//
+	// Return ErrorDirNotFound if dir doesn't exist
+	ListDirEntries(ctx context.Context, dir string) (Objects, []*Dir, error)
+}
+
+// DirMaker is an optional interface for Fs which can have empty
+// directories
+type DirMaker interface {
+	// MakeDir makes the directory dir, which is relative to the
+	// root of the Fs, and any parent directories it needs.
+	//
+	// Shouldn't return an error if it already exists
+	MakeDir(ctx context.Context, dir string) error
+
+	// RemoveDir removes the directory dir, which is relative to
+	// the root of the Fs.
+	//
+	// Return an error if it doesn't exist or isn't empty
+	RemoveDir(ctx context.Context, dir string) error
+}
+
+// DirModTimeSetter is an optional interface for Fs
+type DirModTimeSetter interface {
+	// SetDirModTime sets the modification time of the directory
+	// dir, which is relative to the root of the Fs.
+	//
+	// Return ErrorDirNotFound if it doesn't exist
+	SetDirModTime(ctx context.Context, dir string, modTime time.Time) error
+}
+
+// DirCacheFlusher is an optional interface for Fs
+type DirCacheFlusher interface {
+	// DirCacheFlush forgets any cached directory IDs.
+	//
+	// Implement this if directories are looked up by ID and the
+	// IDs are cached, as another Fs on the same remote, eg one
+	// made to do a DirMove, may change them.
+	DirCacheFlush()
 }
 
 // UnWrapper is an optional interfaces for Fs
@@ -204,6 +480,66 @@ type UnWrapper interface {
 	UnWrap() Fs
 }
 
+// Abouter is an optional interface for Fs
+type Abouter interface {
+	// About gets quota information from the Fs
+	About(ctx context.Context) (*Usage, error)
+}
+
+// CleanUpper is an optional interfaces for Fs
+type CleanUpper interface {
+	// CleanUp the trash in the Fs
+	//
+	// Implement this if you have a way of emptying the trash or
+	// otherwise cleaning up old versions of files.
+	//
+	// It should observe --dry-run, logging what it would do
+	// instead of doing it.
+	CleanUp(ctx context.Context) error
+}
+
+// PublicLinker is an optional interface for Fs
+type PublicLinker interface {
+	// PublicLink makes a link which can be used to read the
+	// object or directory at remote without any credentials.
+	//
+	// Remotes which can make links which expire will make them
+	// valid for expire, the others ignore it.
+	PublicLink(ctx context.Context, remote string, expire time.Duration) (link string, err error)
+}
+
+// Usage is returned by Abouter.About
+//
+// A value which the remote doesn't report is left as nil
+type Usage struct {
+	Total   *int64 `json:"total,omitempty"`   // quota of bytes that can be used
+	Used    *int64 `json:"used,omitempty"`    // bytes in use
+	Trashed *int64 `json:"trashed,omitempty"` // bytes in the trash
+	Free    *int64 `json:"free,omitempty"`    // bytes which can be uploaded before reaching the quota
+}
+
+// NewUsageValue makes a pointer to value for filling in a Usage
+func NewUsageValue(value int64) *int64 {
+	return &value
+}
+
+// ListOpts describes the interface used for Fs.List operations
+type ListOpts interface {
+	// Add an object to the output.
+	// If the function returns true, the operation has been aborted.
+	// Multiple goroutines can safely add objects concurrently.
+	Add(obj Object) (abort bool)
+
+	// SetError will set an error state, and will cause the listing to
+	// be aborted.
+	// Multiple goroutines can set the error state concurrently,
+	// but only the first will be returned to the caller.
+	SetError(err error)
+
+	// Finished should be called when listing is finished
+	Finished()
+}
+
 // ObjectsChan is a channel of Objects
 type ObjectsChan chan Object
 
@@ -274,6 +610,21 @@ func NewFs(path string) (Fs, error) {
 	return fs.NewFs(configName, fsPath)
 }
 
+// SplitRemote splits a remote of the form remote:path/to/file into
+// the parent directory, eg "remote:path/to/", and the leaf name, eg
+// "file".  The leaf will be "" if remote ends in "/".
+func SplitRemote(remote string) (parent string, leaf string) {
+	prefix, fsPath := "", remote
+	parts := matcher.FindStringSubmatch(remote)
+	if parts != nil && !isDriveLetter(parts[1]) {
+		prefix, fsPath = parts[1]+":", parts[2]
+	}
+	// change native directory separators to / if there are any
+	fsPath = filepath.ToSlash(fsPath)
+	i := strings.LastIndex(fsPath, "/")
+	return prefix + fsPath[:i+1], fsPath[i+1:]
+}
+
 // OutputLog logs for an object
 func OutputLog(o interface{}, text string, args ...interface{}) {
 	description := ""
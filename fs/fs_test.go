@@ -0,0 +1,86 @@
+package fs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSplitRemote(t *testing.T) {
+	for _, test := range []struct {
+		remote, wantParent, wantLeaf string
+	}{
+		{"", "", ""},
+		{"file.txt", "", "file.txt"},
+		{"dir/file.txt", "dir/", "file.txt"},
+		{"/dir/file.txt", "/dir/", "file.txt"},
+		{"remote:", "remote:", ""},
+		{"remote:file.txt", "remote:", "file.txt"},
+		{"remote:bucket/path/to/file.txt", "remote:bucket/path/to/", "file.txt"},
+		{"remote:bucket/dir/", "remote:bucket/dir/", ""},
+	} {
+		gotParent, gotLeaf := SplitRemote(test.remote)
+		if gotParent != test.wantParent || gotLeaf != test.wantLeaf {
+			t.Errorf("%q: want (%q, %q) got (%q, %q)", test.remote, test.wantParent, test.wantLeaf, gotParent, gotLeaf)
+		}
+	}
+}
+
+// purgeFs is an Fs which can only Purge
+type purgeFs struct {
+	Fs
+	features *Features
+}
+
+func (f *purgeFs) Purge(ctx context.Context) error { return nil }
+func (f *purgeFs) Features() *Features             { return f.features }
+
+func newPurgeFs() *purgeFs {
+	f := &purgeFs{}
+	f.features = (&Features{CaseInsensitive: true}).Fill(f)
+	return f
+}
+
+func TestFeaturesFill(t *testing.T) {
+	ft := newPurgeFs().Features()
+	if !ft.CaseInsensitive || ft.DuplicateFiles || ft.CanHaveEmptyDirectories {
+		t.Errorf("flags wrong: %+v", ft)
+	}
+	if ft.Purge == nil {
+		t.Error("expecting Purge")
+	}
+	if ft.Copy != nil || ft.Move != nil || ft.DirMove != nil || ft.UnWrap != nil || ft.ListDirEntries != nil {
+		t.Error("expecting only Purge")
+	}
+}
+
+func TestFeaturesMask(t *testing.T) {
+	f := newPurgeFs()
+	ft := &Features{
+		CaseInsensitive:         true,
+		CanHaveEmptyDirectories: true,
+		Purge:                   func(ctx context.Context) error { return nil },
+		Copy:                    func(ctx context.Context, src Object, remote string) (Object, error) { return nil, nil },
+		UnWrap:                  func() Fs { return f },
+	}
+	ft.Mask(f)
+	if !ft.CaseInsensitive || ft.CanHaveEmptyDirectories {
+		t.Errorf("flags wrong: %+v", ft)
+	}
+	if ft.Purge == nil || ft.Copy != nil {
+		t.Error("expecting Purge and no Copy")
+	}
+	if ft.UnWrap == nil {
+		t.Error("expecting UnWrap to be left alone")
+	}
+}
+
+func TestFeaturesWrap(t *testing.T) {
+	f := newPurgeFs()
+	ft := (&Features{}).Wrap(f)
+	if !ft.CaseInsensitive || ft.Purge == nil || ft.Copy != nil {
+		t.Errorf("features not passed through: %+v", ft)
+	}
+	if ft.UnWrap == nil || ft.UnWrap() != f {
+		t.Error("expecting UnWrap to return the wrapped Fs")
+	}
+}
@@ -0,0 +1,136 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestHashSet(t *testing.T) {
+	var h HashSet
+
+	if h.Count() != 0 {
+		t.Errorf("expected empty set, got %v", h)
+	}
+	if h.GetOne() != HashNone {
+		t.Errorf("expected HashNone from empty set, got %v", h.GetOne())
+	}
+
+	h = h.Add(HashMD5)
+	if h.Count() != 1 || !h.Contains(HashMD5) || h.Contains(HashSHA1) {
+		t.Errorf("expected [MD5], got %v", h)
+	}
+	if h.GetOne() != HashMD5 {
+		t.Errorf("expected MD5 from GetOne, got %v", h.GetOne())
+	}
+
+	h = h.Add(HashSHA1)
+	if h.Count() != 2 || !h.Contains(HashSHA1) {
+		t.Errorf("expected [MD5, SHA-1], got %v", h)
+	}
+	if h.GetOne() != HashSHA1 {
+		t.Errorf("expected strongest SHA-1 from GetOne, got %v", h.GetOne())
+	}
+	if got := h.String(); got != "[MD5, SHA-1]" {
+		t.Errorf("String: want %q got %q", "[MD5, SHA-1]", got)
+	}
+	if !NewHashSet(HashSHA1).SubsetOf(h) || h.SubsetOf(NewHashSet(HashSHA1)) {
+		t.Errorf("SubsetOf gave wrong answer for %v", h)
+	}
+
+	for _, test := range []struct {
+		a, b HashSet
+		want HashSet
+	}{
+		{NewHashSet(HashMD5), NewHashSet(HashSHA1), NewHashSet()},
+		{NewHashSet(HashMD5, HashSHA1), NewHashSet(HashSHA1), NewHashSet(HashSHA1)},
+		{SupportedHashes, SupportedHashes, SupportedHashes},
+		{NewHashSet(), SupportedHashes, NewHashSet()},
+	} {
+		if got := test.a.Overlap(test.b); got != test.want {
+			t.Errorf("%v.Overlap(%v): want %v got %v", test.a, test.b, test.want, got)
+		}
+	}
+}
+
+var hashTestSet = []struct {
+	input  []byte
+	output map[HashType]string
+}{
+	{
+		input: []byte{},
+		output: map[HashType]string{
+			HashMD5:  "d41d8cd98f00b204e9800998ecf8427e",
+			HashSHA1: "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+		},
+	},
+	{
+		input: []byte("hello world"),
+		output: map[HashType]string{
+			HashMD5:  "5eb63bbbe01eeed093cb22bb8f5acdc3",
+			HashSHA1: "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed",
+		},
+	},
+}
+
+func TestMultiHasher(t *testing.T) {
+	for _, test := range hashTestSet {
+		mh := NewMultiHasher()
+		n, err := io.Copy(mh, bytes.NewBuffer(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int(n) != len(test.input) {
+			t.Errorf("wrote %d bytes, expected %d", n, len(test.input))
+		}
+		sums := mh.Sums()
+		for hashType, want := range test.output {
+			if sums[hashType] != want {
+				t.Errorf("%v of %q: want %q got %q", hashType, test.input, want, sums[hashType])
+			}
+		}
+	}
+}
+
+func TestHashStream(t *testing.T) {
+	for _, test := range hashTestSet {
+		sums, err := HashStream(bytes.NewBuffer(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for hashType, want := range test.output {
+			if sums[hashType] != want {
+				t.Errorf("%v of %q: want %q got %q", hashType, test.input, want, sums[hashType])
+			}
+		}
+
+		// Should fail on an unsupported hash type
+		_, err = HashStreamTypes(bytes.NewBuffer(test.input), HashSet(1<<10))
+		if err == nil {
+			t.Errorf("expecting error for unsupported hash type")
+		}
+	}
+}
+
+func TestHashTypeSet(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want HashType
+		err  bool
+	}{
+		{"MD5", HashMD5, false},
+		{"md5", HashMD5, false},
+		{"SHA-1", HashSHA1, false},
+		{"sha1", HashSHA1, false},
+		{"crc32", HashNone, true},
+	} {
+		var ht HashType
+		err := ht.Set(test.in)
+		if (err != nil) != test.err {
+			t.Errorf("%q: want error %v got %v", test.in, test.err, err)
+		}
+		if ht != test.want {
+			t.Errorf("%q: want %v got %v", test.in, test.want, ht)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
@@ -9,8 +10,9 @@ import (
 // Limited defines a Fs which can only return the Objects passed in
 // from the Fs passed in
 type Limited struct {
-	objects []Object
-	fs      Fs
+	objects  []Object
+	fs       Fs
+	features *Features // optional features
 }
 
 // NewLimited maks a limited Fs limited to the objects passed in
@@ -19,6 +21,12 @@ func NewLimited(fs Fs, objects ...Object) Fs {
 		objects: objects,
 		fs:      fs,
 	}
+	wrapped := fs.Features()
+	f.features = (&Features{
+		CaseInsensitive:         wrapped.CaseInsensitive,
+		DuplicateFiles:          wrapped.DuplicateFiles,
+		CanHaveEmptyDirectories: wrapped.CanHaveEmptyDirectories,
+	}).Fill(f).Mask(fs)
 	return f
 }
 
@@ -37,27 +45,25 @@ func (f *Limited) String() string {
 	return fmt.Sprintf("%s limited to %d objects", f.fs.String(), len(f.objects))
 }
 
-// List the Fs into a channel
-func (f *Limited) List() ObjectsChan {
-	out := make(ObjectsChan, Config.Checkers)
-	go func() {
-		for _, obj := range f.objects {
-			out <- obj
+// List the objects into out
+func (f *Limited) List(ctx context.Context, out ListOpts) {
+	defer out.Finished()
+	for _, obj := range f.objects {
+		if out.Add(obj) {
+			return
 		}
-		close(out)
-	}()
-	return out
+	}
 }
 
 // ListDir lists the Fs directories/buckets/containers into a channel
-func (f *Limited) ListDir() DirChan {
+func (f *Limited) ListDir(ctx context.Context) DirChan {
 	out := make(DirChan, Config.Checkers)
 	close(out)
 	return out
 }
 
 // NewFsObject finds the Object at remote.  Returns nil if can't be found
-func (f *Limited) NewFsObject(remote string) Object {
+func (f *Limited) NewFsObject(ctx context.Context, remote string) Object {
 	for _, obj := range f.objects {
 		if obj.Remote() == remote {
 			return obj
@@ -71,22 +77,22 @@ func (f *Limited) NewFsObject(remote string) Object {
 // May create the object even if it returns an error - if so
 // will return the object and the error, otherwise will return
 // nil and the error
-func (f *Limited) Put(in io.Reader, remote string, modTime time.Time, size int64) (Object, error) {
-	obj := f.NewFsObject(remote)
+func (f *Limited) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...PutOption) (Object, error) {
+	obj := f.NewFsObject(ctx, remote)
 	if obj == nil {
 		return nil, fmt.Errorf("Can't create %q in limited fs", remote)
 	}
-	return obj, obj.Update(in, modTime, size)
+	return obj, obj.Update(ctx, in, modTime, size, options...)
 }
 
 // Mkdir make the directory (container, bucket)
-func (f *Limited) Mkdir() error {
+func (f *Limited) Mkdir(ctx context.Context) error {
 	// All directories are already made - just ignore
 	return nil
 }
 
 // Rmdir removes the directory (container, bucket) if empty
-func (f *Limited) Rmdir() error {
+func (f *Limited) Rmdir(ctx context.Context) error {
 	// Ignore this in a limited fs
 	return nil
 }
@@ -96,6 +102,19 @@ func (f *Limited) Precision() time.Duration {
 	return f.fs.Precision()
 }
 
+// Hashes returns the supported hash sets.
+func (f *Limited) Hashes() HashSet {
+	return f.fs.Hashes()
+}
+
+// Features returns the optional features of this Fs
+//
+// These are the features of the wrapped Fs which still make sense
+// when limited to the objects.
+func (f *Limited) Features() *Features {
+	return f.features
+}
+
 // Copy src to this remote using server side copy operations.
 //
 // This is stored with the remote path given
@@ -105,12 +124,12 @@ func (f *Limited) Precision() time.Duration {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantCopy
-func (f *Limited) Copy(src Object, remote string) (Object, error) {
-	fCopy, ok := f.fs.(Copier)
-	if !ok {
+func (f *Limited) Copy(ctx context.Context, src Object, remote string) (Object, error) {
+	doCopy := f.fs.Features().Copy
+	if doCopy == nil {
 		return nil, ErrorCantCopy
 	}
-	return fCopy.Copy(src, remote)
+	return doCopy(ctx, src, remote)
 }
 
 // Move src to this remote using server side move operations.
@@ -122,12 +141,21 @@ func (f *Limited) Copy(src Object, remote string) (Object, error) {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantMove
-func (f *Limited) Move(src Object, remote string) (Object, error) {
-	fMove, ok := f.fs.(Mover)
-	if !ok {
+func (f *Limited) Move(ctx context.Context, src Object, remote string) (Object, error) {
+	doMove := f.fs.Features().Move
+	if doMove == nil {
 		return nil, ErrorCantMove
 	}
-	return fMove.Move(src, remote)
+	return doMove(ctx, src, remote)
+}
+
+// About gets quota information from the wrapped Fs
+func (f *Limited) About(ctx context.Context) (*Usage, error) {
+	doAbout := f.fs.Features().About
+	if doAbout == nil {
+		return nil, ErrorNotImplemented
+	}
+	return doAbout(ctx)
 }
 
 // UnWrap returns the Fs that this Fs is wrapping
@@ -141,4 +169,5 @@ var (
 	_ Copier    = (*Limited)(nil)
 	_ Mover     = (*Limited)(nil)
 	_ UnWrapper = (*Limited)(nil)
+	_ Abouter   = (*Limited)(nil)
 )
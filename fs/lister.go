@@ -0,0 +1,128 @@
+// Listing utilities
+
+package fs
+
+import (
+	"context"
+	"sync"
+)
+
+// listerResult is returned by the Lister - either an Object or an error
+type listerResult struct {
+	Obj Object
+	Err error
+}
+
+// Lister objects are used for controlling listing of Fs objects
+//
+// It implements ListOpts and turns the output of Fs.List into a
+// stream of Objects which can be read with Get.
+type Lister struct {
+	mu       sync.RWMutex
+	ctx      context.Context
+	results  chan listerResult
+	abort    chan struct{}
+	aborted  sync.Once
+	finished bool
+}
+
+// NewLister creates a Lister object.
+func NewLister() *Lister {
+	return &Lister{
+		ctx:     context.Background(),
+		results: make(chan listerResult, Config.Checkers),
+		abort:   make(chan struct{}),
+	}
+}
+
+// Start starts a go routine listing the Fs passed in.  It returns the
+// same Lister that was passed in for convenience.
+//
+// If ctx is cancelled the listing is aborted and Get will return
+// ctx.Err().
+func (o *Lister) Start(ctx context.Context, f Fs) *Lister {
+	o.ctx = ctx
+	go f.List(ctx, o)
+	return o
+}
+
+// Add an object to the output.
+// If the function returns true, the operation has been aborted.
+// Multiple goroutines can safely add objects concurrently.
+func (o *Lister) Add(obj Object) (abort bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.finished {
+		return true
+	}
+	select {
+	case o.results <- listerResult{Obj: obj}:
+		return false
+	case <-o.ctx.Done():
+		return true
+	case <-o.abort:
+		return true
+	}
+}
+
+// SetError will set an error state, and will cause the listing to
+// be aborted.
+// Multiple goroutines can set the error state concurrently,
+// but only the first will be returned to the caller.
+func (o *Lister) SetError(err error) {
+	o.mu.RLock()
+	if err != nil && !o.finished {
+		select {
+		case o.results <- listerResult{Err: err}:
+		case <-o.ctx.Done():
+		case <-o.abort:
+		}
+	}
+	o.mu.RUnlock()
+	o.Finished()
+}
+
+// Finished should be called when listing is finished
+func (o *Lister) Finished() {
+	o.mu.Lock()
+	if !o.finished {
+		o.finished = true
+		close(o.results)
+	}
+	o.mu.Unlock()
+}
+
+// Get an object from the listing.
+//
+// It returns a nil Object and a nil error when the listing is
+// finished.  If the listing failed or was cancelled it returns the
+// error.  The caller should keep calling Get until it returns a nil
+// Object or an error, or cancel the context passed to Start.
+func (o *Lister) Get() (Object, error) {
+	result, ok := <-o.results
+	if !ok {
+		return nil, o.ctx.Err()
+	}
+	if result.Err != nil {
+		// Unblock any other goroutines adding to the listing
+		o.aborted.Do(func() { close(o.abort) })
+		return nil, result.Err
+	}
+	return result.Obj, nil
+}
+
+// GetAll gets all the objects from the listing, returning the first
+// error if there was one.
+func (o *Lister) GetAll() (objs []Object, err error) {
+	for {
+		obj, err := o.Get()
+		if err != nil {
+			return nil, err
+		}
+		if obj == nil {
+			break
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
@@ -0,0 +1,70 @@
+// Content type and user metadata for objects
+
+package fs
+
+import (
+	"fmt"
+	"mime"
+	"path"
+)
+
+// Metadata is the user defined key value metadata of an Object
+//
+// The keys are lower case and don't include any prefix the remote
+// uses to store them, eg X-Amz-Meta- or X-Object-Meta-.  The
+// metadata rclone uses itself, eg to store the modification time,
+// isn't included.
+type Metadata map[string]string
+
+// PutOption is an interface describing options for Put and Update
+type PutOption interface {
+	fmt.Stringer
+
+	// Mandatory returns whether this option can be ignored or not
+	Mandatory() bool
+}
+
+// MetadataOption asks Put and Update to store the content type and
+// user metadata given with the object if the remote is able to.
+//
+// Either may be empty.
+type MetadataOption struct {
+	MimeType string
+	Metadata Metadata
+}
+
+// String formats the option into human readable form
+func (o *MetadataOption) String() string {
+	return fmt.Sprintf("MetadataOption(%q,%v)", o.MimeType, o.Metadata)
+}
+
+// Mandatory returns whether the option must be parsed or can be ignored
+func (o *MetadataOption) Mandatory() bool {
+	return false
+}
+
+// PutOptionsMetadata returns the content type and user metadata of
+// the MetadataOption in options, or "" and nil if there isn't one.
+func PutOptionsMetadata(options ...PutOption) (mimeType string, metadata Metadata) {
+	for _, option := range options {
+		if x, ok := option.(*MetadataOption); ok {
+			mimeType, metadata = x.MimeType, x.Metadata
+		}
+	}
+	return mimeType, metadata
+}
+
+// MimeType returns the content type to upload o with
+//
+// This is the content type of the MetadataOption in options if there
+// is one, otherwise it is a guess from the extension.
+func MimeType(o Object, options ...PutOption) string {
+	if mimeType, _ := PutOptionsMetadata(options...); mimeType != "" {
+		return mimeType
+	}
+	mimeType := mime.TypeByExtension(path.Ext(o.Remote()))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return mimeType
+}
@@ -0,0 +1,54 @@
+package fs
+
+import (
+	"testing"
+)
+
+// remoteObject is an Object which only knows its Remote
+type remoteObject struct {
+	Object
+	remote string
+}
+
+func (o remoteObject) Remote() string { return o.remote }
+
+func TestPutOptionsMetadata(t *testing.T) {
+	mimeType, metadata := PutOptionsMetadata()
+	if mimeType != "" || metadata != nil {
+		t.Errorf("expected no metadata, got %q, %v", mimeType, metadata)
+	}
+
+	mimeType, metadata = PutOptionsMetadata(&MetadataOption{
+		MimeType: "text/x-potato",
+		Metadata: Metadata{"colour": "brown"},
+	})
+	if mimeType != "text/x-potato" {
+		t.Errorf("want mime type %q got %q", "text/x-potato", mimeType)
+	}
+	if len(metadata) != 1 || metadata["colour"] != "brown" {
+		t.Errorf("want metadata colour=brown got %v", metadata)
+	}
+}
+
+func TestMimeType(t *testing.T) {
+	for _, test := range []struct {
+		remote string
+		want   string
+	}{
+		{"file.html", "text/html; charset=utf-8"},
+		{"dir/file.png", "image/png"},
+		{"file.unknown-extension", "application/octet-stream"},
+		{"file", "application/octet-stream"},
+	} {
+		got := MimeType(remoteObject{remote: test.remote})
+		if got != test.want {
+			t.Errorf("%q: want %q got %q", test.remote, test.want, got)
+		}
+	}
+
+	// The content type passed in should override the guess
+	got := MimeType(remoteObject{remote: "file.html"}, &MetadataOption{MimeType: "text/x-potato"})
+	if got != "text/x-potato" {
+		t.Errorf("want %q got %q", "text/x-potato", got)
+	}
+}
@@ -3,10 +3,17 @@
 package fs
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
-	"mime"
+	"io/ioutil"
+	"os"
 	"path"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -33,48 +40,57 @@ func CalculateModifyWindow(fs ...Fs) {
 	Debug(fs[0], "Modify window is %s", Config.ModifyWindow)
 }
 
-// Md5sumsEqual checks to see if src == dst, but ignores empty strings
-func Md5sumsEqual(src, dst string) bool {
+// HashEquals checks to see if src == dst, but ignores empty strings
+// and returns true if either is empty.
+func HashEquals(src, dst string) bool {
 	if src == "" || dst == "" {
 		return true
 	}
 	return src == dst
 }
 
-// CheckMd5sums checks the two files to see if the MD5sums are the same
+// CheckHashes checks the two files to see if they have common
+// known hash types and compares them
 //
-// Returns two bools, the first of which is equality and the second of
-// which is true if either of the MD5SUMs were unset.
+// Returns
 //
-// May return an error which will already have been logged
+// equal - which is equality of the hashes
+//
+// hash - the HashType. This is HashNone if either of the hashes were
+// unset or a compatible hash couldn't be found.
+//
+// err - may return an error which will already have been logged
 //
 // If an error is returned it will return equal as false
-func CheckMd5sums(src, dst Object) (equal bool, unset bool, err error) {
-	srcMd5, err := src.Md5sum()
+func CheckHashes(ctx context.Context, src, dst Object) (equal bool, hash HashType, err error) {
+	common := src.Fs().Hashes().Overlap(dst.Fs().Hashes())
+	if common.Count() == 0 {
+		return true, HashNone, nil
+	}
+	hash = common.GetOne()
+	srcHash, err := src.Hash(ctx, hash)
 	if err != nil {
 		Stats.Error()
-		ErrorLog(src, "Failed to calculate src md5: %s", err)
-		return false, false, err
+		ErrorLog(src, "Failed to calculate src hash: %s", err)
+		return false, hash, err
 	}
-	if srcMd5 == "" {
-		return true, true, nil
+	if srcHash == "" {
+		return true, HashNone, nil
 	}
-	dstMd5, err := dst.Md5sum()
+	dstHash, err := dst.Hash(ctx, hash)
 	if err != nil {
 		Stats.Error()
-		ErrorLog(dst, "Failed to calculate dst md5: %s", err)
-		return false, false, err
+		ErrorLog(dst, "Failed to calculate dst hash: %s", err)
+		return false, hash, err
 	}
-	if dstMd5 == "" {
-		return true, true, nil
+	if dstHash == "" {
+		return true, HashNone, nil
 	}
-	// Debug("Src MD5 %s", srcMd5)
-	// Debug("Dst MD5 %s", obj.Hash)
-	return Md5sumsEqual(srcMd5, dstMd5), false, nil
+	return srcHash == dstHash, hash, nil
 }
 
 // Equal checks to see if the src and dst objects are equal by looking at
-// size, mtime and MD5SUM
+// size, mtime and hash
 //
 // If the src and dst size are different then it is considered to be
 // not equal.  If --size-only is in effect then this is the only check
@@ -84,13 +100,13 @@ func CheckMd5sums(src, dst Object) (equal bool, unset bool, err error) {
 // considered to be equal.  This check is skipped if using --checksum.
 //
 // If the size is the same and mtime is different, unreadable or
-// --checksum is set and the MD5SUM is the same then the file is
+// --checksum is set and the hash is the same then the file is
 // considered to be equal.  In this case the mtime on the dst is
 // updated if --checksum is not set.
 //
 // Otherwise the file is considered to be not equal including if there
 // were errors reading info.
-func Equal(src, dst Object) bool {
+func Equal(ctx context.Context, src, dst Object) bool {
 	if src.Size() != dst.Size() {
 		Debug(src, "Sizes differ")
 		return false
@@ -107,8 +123,8 @@ func Equal(src, dst Object) bool {
 			return true
 		}
 		// Size the same so check the mtime
-		srcModTime = src.ModTime()
-		dstModTime := dst.ModTime()
+		srcModTime = src.ModTime(ctx)
+		dstModTime := dst.ModTime(ctx)
 		dt := dstModTime.Sub(srcModTime)
 		ModifyWindow := Config.ModifyWindow
 		if dt >= ModifyWindow || dt <= -ModifyWindow {
@@ -120,45 +136,36 @@ func Equal(src, dst Object) bool {
 	}
 
 	// mtime is unreadable or different but size is the same so
-	// check the MD5SUM
-	same, md5unset, _ := CheckMd5sums(src, dst)
+	// check the hash
+	same, hash, _ := CheckHashes(ctx, src, dst)
 	if !same {
-		Debug(src, "Md5sums differ")
+		Debug(src, "%v differ", hash)
 		return false
 	}
 
 	if !Config.CheckSum {
-		// Size and MD5 the same but mtime different so update the
+		// Size and hash the same but mtime different so update the
 		// mtime of the dst object here
-		dst.SetModTime(srcModTime)
+		dst.SetModTime(ctx, srcModTime)
 	}
 
-	if md5unset {
+	if hash == HashNone {
 		Debug(src, "Size of src and dst objects identical")
 	} else {
-		Debug(src, "Size and MD5SUM of src and dst objects identical")
+		Debug(src, "Size and %v of src and dst objects identical", hash)
 	}
 	return true
 }
 
-// MimeType returns a guess at the mime type from the extension
-func MimeType(o Object) string {
-	mimeType := mime.TypeByExtension(path.Ext(o.Remote()))
-	if mimeType == "" {
-		mimeType = "application/octet-stream"
-	}
-	return mimeType
-}
-
 // Used to remove a failed copy
 //
 // Returns whether the file was succesfully removed or not
-func removeFailedCopy(dst Object) bool {
+func removeFailedCopy(ctx context.Context, dst Object) bool {
 	if dst == nil {
 		return false
 	}
 	Debug(dst, "Removing failed copy")
-	removeErr := dst.Remove()
+	removeErr := dst.Remove(ctx)
 	if removeErr != nil {
 		Debug(dst, "Failed to remove failed copy: %s", removeErr)
 		return false
@@ -166,12 +173,14 @@ func removeFailedCopy(dst Object) bool {
 	return true
 }
 
-// Copy src object to dst or f if nil
+// Copy src object to dst or f if nil, storing it at remote in f
 //
 // If dst is nil then the object must not exist already.  If you do
 // call Copy() with dst nil on a pre-existing file then some filing
 // systems (eg Drive) may duplicate the file.
-func Copy(f Fs, dst, src Object) {
+//
+// Any error returned has already been counted and logged.
+func Copy(ctx context.Context, f Fs, dst Object, remote string, src Object) error {
 	const maxTries = 10
 	tries := 0
 	doUpdate := dst != nil
@@ -180,9 +189,9 @@ tryAgain:
 	// Try server side copy first - if has optional interface and
 	// is same underlying remote
 	actionTaken := "Copied (server side copy)"
-	if fCopy, ok := f.(Copier); ok && src.Fs().Name() == f.Name() {
+	if doCopy := f.Features().Copy; doCopy != nil && src.Fs().Name() == f.Name() {
 		var newDst Object
-		newDst, err = fCopy.Copy(src, src.Remote())
+		newDst, err = doCopy(ctx, src, remote)
 		if err == nil {
 			dst = newDst
 		}
@@ -192,11 +201,11 @@ tryAgain:
 	// If can't server side copy, do it manually
 	if err == ErrorCantCopy {
 		var in0 io.ReadCloser
-		in0, err = src.Open()
+		in0, err = src.Open(ctx)
 		if err != nil {
 			Stats.Error()
 			ErrorLog(src, "Failed to open: %s", err)
-			return
+			return err
 		}
 
 		// On big files add a buffer
@@ -206,20 +215,33 @@ tryAgain:
 
 		in := NewAccount(in0, src) // account the transfer
 
+		// Preserve the content type and metadata if we can
+		var options []PutOption
+		if do, ok := src.(Metadataer); ok {
+			metadata, metaErr := do.Metadata(ctx)
+			if metaErr != nil {
+				Log(src, "Failed to read metadata: %v", metaErr)
+			}
+			options = append(options, &MetadataOption{
+				MimeType: do.MimeType(ctx),
+				Metadata: metadata,
+			})
+		}
+
 		if doUpdate {
 			actionTaken = "Copied (updated existing)"
-			err = dst.Update(in, src.ModTime(), src.Size())
+			err = dst.Update(ctx, in, src.ModTime(ctx), src.Size(), options...)
 		} else {
 			actionTaken = "Copied (new)"
-			dst, err = f.Put(in, src.Remote(), src.ModTime(), src.Size())
+			dst, err = f.Put(ctx, in, remote, src.ModTime(ctx), src.Size(), options...)
 		}
 		inErr = in.Close()
 	}
-	// Retry if err returned a retry error
-	if r, ok := err.(Retry); ok && r.Retry() && tries < maxTries {
+	// Retry if err returned a retry error unless we have been cancelled
+	if r, ok := err.(Retry); ok && r.Retry() && tries < maxTries && ctx.Err() == nil {
 		tries++
 		Log(src, "Received error: %v - retrying %d/%d", err, tries, maxTries)
-		if removeFailedCopy(dst) {
+		if removeFailedCopy(ctx, dst) {
 			// If we removed dst, then nil it out and note we are not updating
 			dst = nil
 			doUpdate = false
@@ -232,8 +254,8 @@ tryAgain:
 	if err != nil {
 		Stats.Error()
 		ErrorLog(src, "Failed to copy: %s", err)
-		removeFailedCopy(dst)
-		return
+		removeFailedCopy(ctx, dst)
+		return err
 	}
 
 	// Verify sizes are the same after transfer
@@ -241,40 +263,45 @@ tryAgain:
 		Stats.Error()
 		err = fmt.Errorf("Corrupted on transfer: sizes differ %d vs %d", src.Size(), dst.Size())
 		ErrorLog(dst, "%s", err)
-		removeFailedCopy(dst)
-		return
+		removeFailedCopy(ctx, dst)
+		return err
 	}
 
-	// Verify md5sums are the same after transfer - ignoring blank md5sums
-	if !Config.SizeOnly {
-		srcMd5sum, md5sumErr := src.Md5sum()
-		if md5sumErr != nil {
+	// Verify hashes are the same after transfer - ignoring blank hashes
+	common := src.Fs().Hashes().Overlap(dst.Fs().Hashes())
+	if !Config.SizeOnly && common.Count() > 0 {
+		// Get common hash type
+		hashType := common.GetOne()
+
+		srcSum, err := src.Hash(ctx, hashType)
+		if err != nil {
 			Stats.Error()
-			ErrorLog(src, "Failed to read md5sum: %s", md5sumErr)
-		} else if srcMd5sum != "" {
-			dstMd5sum, md5sumErr := dst.Md5sum()
-			if md5sumErr != nil {
+			ErrorLog(src, "Failed to read src hash: %s", err)
+		} else if srcSum != "" {
+			dstSum, err := dst.Hash(ctx, hashType)
+			if err != nil {
 				Stats.Error()
-				ErrorLog(dst, "Failed to read md5sum: %s", md5sumErr)
-			} else if !Md5sumsEqual(srcMd5sum, dstMd5sum) {
+				ErrorLog(dst, "Failed to read hash: %s", err)
+			} else if !HashEquals(srcSum, dstSum) {
 				Stats.Error()
-				err = fmt.Errorf("Corrupted on transfer: md5sums differ %q vs %q", srcMd5sum, dstMd5sum)
+				err = fmt.Errorf("Corrupted on transfer: %v hash differ %q vs %q", hashType, srcSum, dstSum)
 				ErrorLog(dst, "%s", err)
-				removeFailedCopy(dst)
-				return
+				removeFailedCopy(ctx, dst)
+				return err
 			}
 		}
 	}
 
 	Debug(src, actionTaken)
+	return nil
 }
 
 // Check to see if src needs to be copied to dst and if so puts it in out
-func checkOne(pair ObjectPair, out ObjectPairChan) {
+func checkOne(ctx context.Context, pair ObjectPair, out ObjectPairChan) {
 	src, dst := pair.src, pair.dst
 	if dst == nil {
 		Debug(src, "Couldn't find file - need to transfer")
-		out <- pair
+		sendPair(ctx, out, pair)
 		return
 	}
 	// Check to see if can store this
@@ -282,89 +309,147 @@ func checkOne(pair ObjectPair, out ObjectPairChan) {
 		return
 	}
 	// Check to see if changed or not
-	if Equal(src, dst) {
+	if Equal(ctx, src, dst) {
 		Debug(src, "Unchanged skipping")
 		return
 	}
-	out <- pair
+	sendPair(ctx, out, pair)
+}
+
+// sendPair sends pair to out unless the context is cancelled first
+//
+// It returns false if the context was cancelled
+func sendPair(ctx context.Context, out ObjectPairChan, pair ObjectPair) bool {
+	select {
+	case out <- pair:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 // PairChecker reads Objects~s on in send to out if they need transferring.
 //
+// If ctx is cancelled it drains in without checking anything.
+//
 // FIXME potentially doing lots of MD5SUMS at once
-func PairChecker(in ObjectPairChan, out ObjectPairChan, wg *sync.WaitGroup) {
+func PairChecker(ctx context.Context, in ObjectPairChan, out ObjectPairChan, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for pair := range in {
+		if ctx.Err() != nil {
+			continue
+		}
 		src := pair.src
 		Stats.Checking(src)
-		checkOne(pair, out)
+		checkOne(ctx, pair, out)
 		Stats.DoneChecking(src)
 	}
 }
 
 // PairCopier reads Objects on in and copies them.
-func PairCopier(in ObjectPairChan, fdst Fs, wg *sync.WaitGroup) {
+//
+// If ctx is cancelled it drains in without copying anything.
+func PairCopier(ctx context.Context, in ObjectPairChan, fdst Fs, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for pair := range in {
+		if ctx.Err() != nil {
+			continue
+		}
 		src := pair.src
 		Stats.Transferring(src)
 		if Config.DryRun {
 			Debug(src, "Not copying as --dry-run")
 		} else {
-			Copy(fdst, pair.dst, src)
+			Copy(ctx, fdst, pair.dst, src.Remote(), src)
 		}
 		Stats.DoneTransferring(src)
 	}
 }
 
+// Move src object to dst or fdst if nil, storing it at remote in fdst
+//
+// It uses a server side move if fdst supports it and is the same
+// remote as src, otherwise it copies src and then deletes it.
+//
+// Any error returned has already been counted and logged.
+func Move(ctx context.Context, fdst Fs, dst Object, remote string, src Object) error {
+	if doMove := fdst.Features().Move; doMove != nil && src.Fs().Name() == fdst.Name() {
+		// Delete destination if it exists
+		if dst != nil {
+			err := dst.Remove(ctx)
+			if err != nil {
+				Stats.Error()
+				ErrorLog(dst, "Couldn't delete: %v", err)
+				return err
+			}
+		}
+		_, err := doMove(ctx, src, remote)
+		switch err {
+		case nil:
+			Debug(src, "Moved")
+			return nil
+		case ErrorCantMove:
+			Debug(src, "Can't move server side - copying instead")
+			dst = nil
+		default:
+			Stats.Error()
+			ErrorLog(src, "Couldn't move: %v", err)
+			return err
+		}
+	}
+	err := Copy(ctx, fdst, dst, remote, src)
+	if err != nil {
+		return err
+	}
+	err = src.Remove(ctx)
+	if err != nil {
+		Stats.Error()
+		ErrorLog(src, "Couldn't delete: %v", err)
+		return err
+	}
+	Debug(src, "Deleted after copying")
+	return nil
+}
+
 // PairMover reads Objects on in and moves them if possible, or copies
-// them if not
-func PairMover(in ObjectPairChan, fdst Fs, wg *sync.WaitGroup) {
+// and deletes them if not
+//
+// If ctx is cancelled it drains in without moving anything.
+func PairMover(ctx context.Context, in ObjectPairChan, fdst Fs, wg *sync.WaitGroup) {
 	defer wg.Done()
-	// See if we have Move available
-	fdstMover, haveMover := fdst.(Mover)
 	for pair := range in {
+		if ctx.Err() != nil {
+			continue
+		}
 		src := pair.src
-		dst := pair.dst
 		Stats.Transferring(src)
 		if Config.DryRun {
 			Debug(src, "Not moving as --dry-run")
-		} else if haveMover {
-			// Delete destination if it exists
-			if pair.dst != nil {
-				err := dst.Remove()
-				if err != nil {
-					Stats.Error()
-					ErrorLog(dst, "Couldn't delete: %v", err)
-				}
-			}
-			_, err := fdstMover.Move(src, src.Remote())
-			if err != nil {
-				Stats.Error()
-				ErrorLog(dst, "Couldn't move: %v", err)
-			} else {
-				Debug(src, "Moved")
-			}
 		} else {
-			Copy(fdst, pair.dst, src)
+			Move(ctx, fdst, pair.dst, src.Remote(), src)
 		}
 		Stats.DoneTransferring(src)
 	}
 }
 
 // DeleteFiles removes all the files passed in the channel
-func DeleteFiles(toBeDeleted ObjectsChan) {
+//
+// If ctx is cancelled the remaining files are drained from the
+// channel without being deleted.
+func DeleteFiles(ctx context.Context, toBeDeleted ObjectsChan) {
 	var wg sync.WaitGroup
 	wg.Add(Config.Transfers)
 	for i := 0; i < Config.Transfers; i++ {
 		go func() {
 			defer wg.Done()
 			for dst := range toBeDeleted {
-				if Config.DryRun {
+				if ctx.Err() != nil {
+					continue
+				} else if Config.DryRun {
 					Debug(dst, "Not deleting as --dry-run")
 				} else {
 					Stats.Checking(dst)
-					err := dst.Remove()
+					err := dst.Remove(ctx)
 					Stats.DoneChecking(dst)
 					if err != nil {
 						Stats.Error()
@@ -381,13 +466,24 @@ func DeleteFiles(toBeDeleted ObjectsChan) {
 }
 
 // Read a map of Object.Remote to Object for the given Fs
-func readFilesMap(fs Fs) map[string]Object {
-	files := make(map[string]Object)
-	for o := range fs.List() {
+//
+// If the listing fails then the error is returned along with the
+// partial map
+func readFilesMap(ctx context.Context, fs Fs) (files map[string]Object, err error) {
+	files = make(map[string]Object)
+	list := NewLister().Start(ctx, fs)
+	for {
+		o, err := list.Get()
+		if err != nil {
+			return files, err
+		}
+		if o == nil {
+			break
+		}
 		remote := o.Remote()
 		if _, ok := files[remote]; !ok {
 			// Make sure we don't delete excluded files if not required
-			if Config.Filter.DeleteExcluded || Config.Filter.IncludeObject(o) {
+			if Config.Filter.DeleteExcluded || Config.Filter.IncludeObject(ctx, o) {
 				files[remote] = o
 			} else {
 				Debug(o, "Excluded from sync (and deletion)")
@@ -397,7 +493,7 @@ func readFilesMap(fs Fs) map[string]Object {
 			Log(o, "Duplicate file detected")
 		}
 	}
-	return files
+	return files, nil
 }
 
 // Same returns true if fdst and fsrc point to the same underlying Fs
@@ -405,73 +501,374 @@ func Same(fdst, fsrc Fs) bool {
 	return fdst.Name() == fsrc.Name() && fdst.Root() == fsrc.Root()
 }
 
+// syncer holds the state of a sync, copy or move
+type syncer struct {
+	ctx          context.Context
+	fdst, fsrc   Fs
+	Delete       bool
+	srcLister    *dirLister
+	dstLister    *dirLister
+	toBeChecked  ObjectPairChan
+	toBeUploaded ObjectPairChan
+	toBeDeleted  Objects // files in fdst which aren't in fsrc
+	srcListErr   error
+	dstListErr   error
+	dirs         bool         // set if directories are made and removed in fdst
+	toBeRemoved  []string     // directories in fdst which aren't in fsrc
+	dirModTimes  []dirModTime // modification times to set on directories in fdst
+}
+
+// dirModTime is a directory and the modification time it should have
+type dirModTime struct {
+	dir     string
+	modTime time.Time
+}
+
+// syncDir matches up the objects directly in dir in the source and
+// the destination, then recurses into the subdirectories in sorted
+// order.
+//
+// Only one directory of each listing is held in memory at once,
+// unless the Fs has no ListDirEntries in which case dirLister holds
+// the whole of its listing.
+//
+// If inSrc is false then dir only exists in the destination.
+//
+// It returns false if the sync was cancelled.
+func (s *syncer) syncDir(dir string, inSrc bool) bool {
+	var (
+		srcObjects, dstObjects Objects
+		srcDirs, dstDirs       []*Dir
+		srcErr, dstErr         error
+		wg                     sync.WaitGroup
+	)
+	if inSrc {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			srcObjects, srcDirs, srcErr = s.srcLister.List(s.ctx, dir)
+		}()
+	}
+	dstObjects, dstDirs, dstErr = s.dstLister.List(s.ctx, dir)
+	wg.Wait()
+	if s.ctx.Err() != nil {
+		return false
+	}
+	if srcErr != nil {
+		Stats.Error()
+		ErrorLog(s.fsrc, "Error listing source: %v", srcErr)
+		s.srcListErr = srcErr
+		return true
+	}
+	if dstErr != nil {
+		Stats.Error()
+		ErrorLog(s.fdst, "Error listing destination: %v", dstErr)
+		s.dstListErr = dstErr
+		return true
+	}
+
+	// Merge the sorted objects
+	srcObjects = uniqueObjects(srcObjects)
+	dstObjects = uniqueObjects(dstObjects)
+	for i, j := 0, 0; i < len(srcObjects) || j < len(dstObjects); {
+		var src, dst Object
+		switch {
+		case j >= len(dstObjects):
+			src = srcObjects[i]
+			i++
+		case i >= len(srcObjects):
+			dst = dstObjects[j]
+			j++
+		case srcObjects[i].Remote() < dstObjects[j].Remote():
+			src = srcObjects[i]
+			i++
+		case srcObjects[i].Remote() > dstObjects[j].Remote():
+			dst = dstObjects[j]
+			j++
+		default:
+			src, dst = srcObjects[i], dstObjects[j]
+			i++
+			j++
+		}
+		if !s.syncObject(src, dst) {
+			return false
+		}
+	}
+
+	// Merge the sorted directories and recurse into them
+	srcDirs = uniqueDirs(srcDirs)
+	dstDirs = uniqueDirs(dstDirs)
+	for i, j := 0, 0; i < len(srcDirs) || j < len(dstDirs); {
+		var srcDir, dstDir *Dir
+		switch {
+		case j >= len(dstDirs):
+			srcDir = srcDirs[i]
+			i++
+		case i >= len(srcDirs):
+			dstDir = dstDirs[j]
+			j++
+		case srcDirs[i].Name < dstDirs[j].Name:
+			srcDir = srcDirs[i]
+			i++
+		case srcDirs[i].Name > dstDirs[j].Name:
+			dstDir = dstDirs[j]
+			j++
+		default:
+			srcDir, dstDir = srcDirs[i], dstDirs[j]
+			i++
+			j++
+		}
+		if !s.syncSubDir(srcDir, dstDir) {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueObjects removes any objects with the same name as the one
+// before them from the sorted objects, logging them, so only the
+// first of any duplicates is synced
+func uniqueObjects(objects Objects) Objects {
+	if len(objects) < 2 {
+		return objects
+	}
+	unique := objects[:1]
+	for _, o := range objects[1:] {
+		if o.Remote() == unique[len(unique)-1].Remote() {
+			Log(o, "Duplicate file detected")
+			continue
+		}
+		unique = append(unique, o)
+	}
+	return unique
+}
+
+// uniqueDirs removes any directories with the same name as the one
+// before them from the sorted directories, logging them, so a
+// directory is never synced twice or mistaken for one only in the
+// destination
+func uniqueDirs(dirs []*Dir) []*Dir {
+	if len(dirs) < 2 {
+		return dirs
+	}
+	unique := dirs[:1]
+	for _, dir := range dirs[1:] {
+		if dir.Name == unique[len(unique)-1].Name {
+			Log(dir.Name, "Duplicate directory detected")
+			continue
+		}
+		unique = append(unique, dir)
+	}
+	return unique
+}
+
+// syncSubDir decides what to do with a source directory and the
+// destination directory of the same name, either of which may be
+// nil, then syncs its contents.
+//
+// It returns false if the sync was cancelled.
+func (s *syncer) syncSubDir(srcDir, dstDir *Dir) bool {
+	if srcDir == nil {
+		// Directories only in the destination only need
+		// reading if their contents are to be deleted
+		if !s.Delete {
+			return true
+		}
+		if s.dirs {
+			s.toBeRemoved = append(s.toBeRemoved, dstDir.Name)
+		}
+		return s.syncDir(dstDir.Name, false)
+	}
+	if s.dirs {
+		if dstDir == nil {
+			s.makeDir(srcDir.Name)
+		}
+		if s.fdst.Features().SetDirModTime != nil && !srcDir.When.IsZero() {
+			needSet := dstDir == nil
+			if !needSet {
+				dt := srcDir.When.Sub(dstDir.When)
+				needSet = dt >= Config.ModifyWindow || dt <= -Config.ModifyWindow
+			}
+			if needSet {
+				s.dirModTimes = append(s.dirModTimes, dirModTime{srcDir.Name, srcDir.When})
+			}
+		}
+	}
+	return s.syncDir(srcDir.Name, true)
+}
+
+// makeDir makes the directory dir in the destination
+func (s *syncer) makeDir(dir string) {
+	if Config.DryRun {
+		Log(s.fdst, "Not making directory %q as --dry-run", dir)
+		return
+	}
+	err := s.fdst.Features().MakeDir(s.ctx, dir)
+	if err != nil {
+		Stats.Error()
+		ErrorLog(s.fdst, "Couldn't make directory %q: %v", dir, err)
+		return
+	}
+	Debug(s.fdst, "Made directory %q", dir)
+}
+
+// removeDirs removes the directories which are only in the
+// destination, deepest first so they are empty when removed
+func (s *syncer) removeDirs() {
+	for i := len(s.toBeRemoved) - 1; i >= 0; i-- {
+		if s.ctx.Err() != nil {
+			return
+		}
+		dir := s.toBeRemoved[i]
+		if Config.DryRun {
+			Log(s.fdst, "Not removing directory %q as --dry-run", dir)
+			continue
+		}
+		err := s.fdst.Features().RemoveDir(s.ctx, dir)
+		if err != nil {
+			Stats.Error()
+			ErrorLog(s.fdst, "Couldn't remove directory %q: %v", dir, err)
+			continue
+		}
+		Debug(s.fdst, "Removed directory %q", dir)
+	}
+}
+
+// setDirModTimes sets the modification times of the directories in
+// the destination to those of the source
+func (s *syncer) setDirModTimes() {
+	for _, d := range s.dirModTimes {
+		if s.ctx.Err() != nil {
+			return
+		}
+		if Config.DryRun {
+			Log(s.fdst, "Not setting modification time of directory %q as --dry-run", d.dir)
+			continue
+		}
+		err := s.fdst.Features().SetDirModTime(s.ctx, d.dir, d.modTime)
+		if err != nil {
+			Stats.Error()
+			ErrorLog(s.fdst, "Couldn't set modification time of directory %q: %v", d.dir, err)
+			continue
+		}
+		Debug(s.fdst, "Set modification time of directory %q", d.dir)
+	}
+}
+
+// syncObject decides what to do with a source object and the
+// destination object of the same name, either of which may be nil.
+//
+// It returns false if the sync was cancelled.
+func (s *syncer) syncObject(src, dst Object) bool {
+	// Make sure we don't delete excluded files if not required
+	if dst != nil && !Config.Filter.DeleteExcluded && !Config.Filter.IncludeObject(s.ctx, dst) {
+		Debug(dst, "Excluded from sync (and deletion)")
+		dst = nil
+	}
+	if src != nil && !Config.Filter.IncludeObject(s.ctx, src) {
+		Debug(src, "Excluding from sync")
+		src = nil
+	}
+	switch {
+	case src != nil && dst != nil:
+		return sendPair(s.ctx, s.toBeChecked, ObjectPair{src, dst})
+	case src != nil:
+		// No need to check since doesn't exist
+		return sendPair(s.ctx, s.toBeUploaded, ObjectPair{src, nil})
+	case dst != nil && s.Delete:
+		s.toBeDeleted = append(s.toBeDeleted, dst)
+	}
+	return true
+}
+
 // Syncs fsrc into fdst
 //
 // If Delete is true then it deletes any files in fdst that aren't in fsrc
 //
 // If DoMove is true then files will be moved instead of copied
-func syncCopyMove(fdst, fsrc Fs, Delete bool, DoMove bool) error {
+//
+// The source and destination are read a directory at a time in
+// sorted order so memory use depends on the size of the largest
+// directory rather than the size of the whole tree.  Only the files
+// to be deleted are kept until the end.  This needs the
+// ListDirEntries feature - the whole listing of an Fs without it is
+// read into memory first.
+//
+// If fdst can make directories and no filters are in use then the
+// directories of fsrc are made in fdst, even if they are empty, and
+// if Delete is set the directories which aren't in fsrc are removed.
+// If fdst can set directory modification times they are set to those
+// of fsrc once everything else is done.
+func syncCopyMove(ctx context.Context, fdst, fsrc Fs, Delete bool, DoMove bool) error {
 	if Same(fdst, fsrc) {
 		ErrorLog(fdst, "Nothing to do as source and destination are the same")
 		return nil
 	}
 
-	err := fdst.Mkdir()
+	err := fdst.Mkdir(ctx)
 	if err != nil {
 		Stats.Error()
 		return err
 	}
 
-	Log(fdst, "Building file list")
-
-	// Read the destination files first
-	// FIXME could do this in parallel and make it use less memory
-	delFiles := readFilesMap(fdst)
-
-	// Read source files checking them off against dest files
-	toBeChecked := make(ObjectPairChan, Config.Transfers)
-	toBeUploaded := make(ObjectPairChan, Config.Transfers)
+	s := &syncer{
+		ctx:          ctx,
+		fdst:         fdst,
+		fsrc:         fsrc,
+		Delete:       Delete,
+		srcLister:    newDirLister(fsrc),
+		dstLister:    newDirLister(fdst),
+		toBeChecked:  make(ObjectPairChan, Config.Transfers),
+		toBeUploaded: make(ObjectPairChan, Config.Transfers),
+		dirs:         fdst.Features().MakeDir != nil && Config.Filter.InActive(),
+	}
 
 	var checkerWg sync.WaitGroup
 	checkerWg.Add(Config.Checkers)
 	for i := 0; i < Config.Checkers; i++ {
-		go PairChecker(toBeChecked, toBeUploaded, &checkerWg)
+		go PairChecker(ctx, s.toBeChecked, s.toBeUploaded, &checkerWg)
 	}
 
 	var copierWg sync.WaitGroup
 	copierWg.Add(Config.Transfers)
 	for i := 0; i < Config.Transfers; i++ {
 		if DoMove {
-			go PairMover(toBeUploaded, fdst, &copierWg)
+			go PairMover(ctx, s.toBeUploaded, fdst, &copierWg)
 		} else {
-			go PairCopier(toBeUploaded, fdst, &copierWg)
+			go PairCopier(ctx, s.toBeUploaded, fdst, &copierWg)
 		}
 	}
 
+	// The list errors are written before toBeChecked is closed
+	// so are safe to read once the checkers have finished
 	go func() {
-		for src := range fsrc.List() {
-			if !Config.Filter.IncludeObject(src) {
-				Debug(src, "Excluding from sync")
-			} else {
-				remote := src.Remote()
-				if dst, dstFound := delFiles[remote]; dstFound {
-					delete(delFiles, remote)
-					toBeChecked <- ObjectPair{src, dst}
-				} else {
-					// No need to check since doesn't exist
-					toBeUploaded <- ObjectPair{src, nil}
-				}
-			}
-		}
-		close(toBeChecked)
+		defer close(s.toBeChecked)
+		s.syncDir("", true)
 	}()
 
 	Log(fdst, "Waiting for checks to finish")
 	checkerWg.Wait()
-	close(toBeUploaded)
+	close(s.toBeUploaded)
 	Log(fdst, "Waiting for transfers to finish")
 	copierWg.Wait()
 
+	// Stop here if we were cancelled
+	if err := ctx.Err(); err != nil {
+		ErrorLog(fdst, "Sync cancelled: %v", err)
+		return err
+	}
+
+	// A truncated source listing would delete files which exist
+	if s.srcListErr != nil {
+		if Delete {
+			ErrorLog(fdst, "Not deleting files as there were errors listing the source")
+		}
+		return s.srcListErr
+	}
+	if s.dstListErr != nil {
+		return s.dstListErr
+	}
+
 	// Delete files if asked
 	if Delete {
 		if Stats.Errored() {
@@ -482,36 +879,45 @@ func syncCopyMove(fdst, fsrc Fs, Delete bool, DoMove bool) error {
 		// Delete the spare files
 		toDelete := make(ObjectsChan, Config.Transfers)
 		go func() {
-			for _, fs := range delFiles {
-				toDelete <- fs
+			defer close(toDelete)
+			for _, o := range s.toBeDeleted {
+				select {
+				case toDelete <- o:
+				case <-ctx.Done():
+					return
+				}
 			}
-			close(toDelete)
 		}()
-		DeleteFiles(toDelete)
+		DeleteFiles(ctx, toDelete)
+		s.removeDirs()
 	}
-	return nil
+
+	// Set the directory modification times last as changing the
+	// contents of a directory updates them
+	s.setDirModTimes()
+	return ctx.Err()
 }
 
 // Sync fsrc into fdst
-func Sync(fdst, fsrc Fs) error {
-	return syncCopyMove(fdst, fsrc, true, false)
+func Sync(ctx context.Context, fdst, fsrc Fs) error {
+	return syncCopyMove(ctx, fdst, fsrc, true, false)
 }
 
 // CopyDir copies fsrc into fdst
-func CopyDir(fdst, fsrc Fs) error {
-	return syncCopyMove(fdst, fsrc, false, false)
+func CopyDir(ctx context.Context, fdst, fsrc Fs) error {
+	return syncCopyMove(ctx, fdst, fsrc, false, false)
 }
 
 // MoveDir moves fsrc into fdst
-func MoveDir(fdst, fsrc Fs) error {
+func MoveDir(ctx context.Context, fdst, fsrc Fs) error {
 	if Same(fdst, fsrc) {
 		ErrorLog(fdst, "Nothing to do as source and destination are the same")
 		return nil
 	}
 
 	// First attempt to use DirMover
-	if fdstDirMover, ok := fdst.(DirMover); ok && fsrc.Name() == fdst.Name() {
-		err := fdstDirMover.DirMove(fsrc)
+	if doDirMove := fdst.Features().DirMove; doDirMove != nil && fsrc.Name() == fdst.Name() {
+		err := doDirMove(ctx, fsrc)
 		Debug(fdst, "Using server side directory move")
 		switch err {
 		case ErrorCantDirMove, ErrorDirExists:
@@ -527,19 +933,154 @@ func MoveDir(fdst, fsrc Fs) error {
 	}
 
 	// Now move the files
-	err := syncCopyMove(fdst, fsrc, false, true)
+	err := syncCopyMove(ctx, fdst, fsrc, false, true)
 	if err != nil || Stats.Errored() {
 		ErrorLog(fdst, "Not deleting files as there were IO errors")
 		return err
 	}
-	return Purge(fsrc)
+	return Purge(ctx, fsrc)
+}
+
+// moveOrCopyFile copies or moves the single file srcFileName in fsrc
+// to dstFileName in fdst
+//
+// The transfer is skipped if the destination file already matches
+// the source, though when moving the source is still deleted.
+func moveOrCopyFile(ctx context.Context, fdst, fsrc Fs, dstFileName, srcFileName string, doMove bool) error {
+	if Same(fdst, fsrc) && dstFileName == srcFileName {
+		return fmt.Errorf("Can't copy or move %q to itself", srcFileName)
+	}
+	srcObj := fsrc.NewFsObject(ctx, srcFileName)
+	if srcObj == nil {
+		return fmt.Errorf("Couldn't find %q in %v", srcFileName, fsrc)
+	}
+	dstObj := fdst.NewFsObject(ctx, dstFileName)
+	if dstObj != nil && Equal(ctx, srcObj, dstObj) {
+		Debug(srcObj, "Unchanged skipping")
+		if !doMove {
+			return nil
+		}
+		if Config.DryRun {
+			Log(srcObj, "Not deleting as --dry-run")
+			return nil
+		}
+		err := srcObj.Remove(ctx)
+		if err != nil {
+			Stats.Error()
+			ErrorLog(srcObj, "Couldn't delete: %v", err)
+			return err
+		}
+		Debug(srcObj, "Deleted as already at destination")
+		return nil
+	}
+	Stats.Transferring(srcObj)
+	defer Stats.DoneTransferring(srcObj)
+	switch {
+	case Config.DryRun:
+		Log(srcObj, "Not copying or moving to %q as --dry-run", dstFileName)
+		return nil
+	case doMove:
+		return Move(ctx, fdst, dstObj, dstFileName, srcObj)
+	default:
+		return Copy(ctx, fdst, dstObj, dstFileName, srcObj)
+	}
+}
+
+// CopyFile copies the single file srcFileName in fsrc to dstFileName
+// in fdst, which may be a different name
+func CopyFile(ctx context.Context, fdst, fsrc Fs, dstFileName, srcFileName string) error {
+	return moveOrCopyFile(ctx, fdst, fsrc, dstFileName, srcFileName, false)
+}
+
+// MoveFile moves the single file srcFileName in fsrc to dstFileName
+// in fdst, which may be a different name
+func MoveFile(ctx context.Context, fdst, fsrc Fs, dstFileName, srcFileName string) error {
+	return moveOrCopyFile(ctx, fdst, fsrc, dstFileName, srcFileName, true)
 }
 
-// Check the files in fsrc and fdst according to Size and MD5SUM
-func Check(fdst, fsrc Fs) error {
+// CheckOpt holds the options for Check
+type CheckOpt struct {
+	OneWay   bool // only check the files in fsrc are in fdst, not the other way round
+	Download bool // compare the contents of files with no common hash by downloading them
+
+	// If set these have the paths of the files in each category
+	// written to them, one per line
+	Match        io.Writer // files which are the same on both sides
+	Differ       io.Writer // files which differ
+	MissingOnSrc io.Writer // files only in fdst
+	MissingOnDst io.Writer // files only in fsrc
+	Error        io.Writer // files which couldn't be checked because of an error
+}
+
+// report writes remote to w if it is set
+func (opt *CheckOpt) report(w io.Writer, remote string) {
+	if w != nil {
+		syncFprintf(w, "%s\n", remote)
+	}
+}
+
+// readersDiffer reads in1 and in2 to the end or the first difference
+// and returns whether they differ
+func readersDiffer(in1, in2 io.Reader) (differ bool, err error) {
+	const bufSize = 64 * 1024
+	buf1 := make([]byte, bufSize)
+	buf2 := make([]byte, bufSize)
+	for {
+		n1, err1 := io.ReadFull(in1, buf1)
+		n2, err2 := io.ReadFull(in2, buf2)
+		if err1 != nil && err1 != io.EOF && err1 != io.ErrUnexpectedEOF {
+			return true, err1
+		}
+		if err2 != nil && err2 != io.EOF && err2 != io.ErrUnexpectedEOF {
+			return true, err2
+		}
+		if n1 != n2 || !bytes.Equal(buf1[:n1], buf2[:n2]) {
+			return true, nil
+		}
+		if err1 != nil {
+			// Both ended at the same place
+			return false, nil
+		}
+	}
+}
+
+// checkIdentical downloads dst and src and compares their contents
+func checkIdentical(ctx context.Context, dst, src Object) (differ bool, err error) {
+	in1, err := dst.Open(ctx)
+	if err != nil {
+		return true, fmt.Errorf("failed to open %q: %v", dst, err)
+	}
+	acc1 := NewAccount(in1, dst) // account the transfer
+	defer func() {
+		_ = acc1.Close() // ignore error
+	}()
+	in2, err := src.Open(ctx)
+	if err != nil {
+		return true, fmt.Errorf("failed to open %q: %v", src, err)
+	}
+	acc2 := NewAccount(in2, src) // account the transfer
+	defer func() {
+		_ = acc2.Close() // ignore error
+	}()
+	return readersDiffer(acc1, acc2)
+}
+
+// Check the files in fsrc and fdst according to Size and hash
+//
+// Files with no hash in common are reported as matching on size
+// alone unless opt.Download is set, when their contents are
+// downloaded and compared.  If opt.OneWay is set then files only in
+// fdst aren't reported.  opt may be nil for the defaults.
+//
+// It returns an error if any differences or errors were found.
+func Check(ctx context.Context, fdst, fsrc Fs, opt *CheckOpt) error {
+	if opt == nil {
+		opt = &CheckOpt{}
+	}
 	var (
 		wg                 sync.WaitGroup
 		dstFiles, srcFiles map[string]Object
+		dstErr, srcErr     error
 	)
 
 	wg.Add(2)
@@ -547,7 +1088,7 @@ func Check(fdst, fsrc Fs) error {
 		defer wg.Done()
 		// Read the destination files
 		Log(fdst, "Building file list")
-		dstFiles = readFilesMap(fdst)
+		dstFiles, dstErr = readFilesMap(ctx, fdst)
 		Debug(fdst, "Done building file list")
 	}()
 
@@ -555,12 +1096,27 @@ func Check(fdst, fsrc Fs) error {
 		defer wg.Done()
 		// Read the source files
 		Log(fsrc, "Building file list")
-		srcFiles = readFilesMap(fsrc)
+		srcFiles, srcErr = readFilesMap(ctx, fsrc)
 		Debug(fdst, "Done building file list")
 	}()
 
 	wg.Wait()
 
+	// Comparing against a partial listing would give bogus results
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if dstErr != nil {
+		Stats.Error()
+		ErrorLog(fdst, "Error listing: %v", dstErr)
+		return dstErr
+	}
+	if srcErr != nil {
+		Stats.Error()
+		ErrorLog(fsrc, "Error listing: %v", srcErr)
+		return srcErr
+	}
+
 	// FIXME could do this as it goes along and make it use less
 	// memory.
 
@@ -575,24 +1131,35 @@ func Check(fdst, fsrc Fs) error {
 		}
 	}
 
-	Log(fdst, "%d files not in %v", len(dstFiles), fsrc)
-	for _, dst := range dstFiles {
-		Stats.Error()
-		ErrorLog(dst, "File not in %v", fsrc)
+	var differences, errors, noHashes int32
+	if !opt.OneWay {
+		Log(fdst, "%d files not in %v", len(dstFiles), fsrc)
+		for _, dst := range dstFiles {
+			Stats.Error()
+			ErrorLog(dst, "File not in %v", fsrc)
+			opt.report(opt.MissingOnSrc, dst.Remote())
+			differences++
+		}
 	}
 
 	Log(fsrc, "%d files not in %s", len(srcFiles), fdst)
 	for _, src := range srcFiles {
 		Stats.Error()
 		ErrorLog(src, "File not in %v", fdst)
+		opt.report(opt.MissingOnDst, src.Remote())
+		differences++
 	}
 
 	checks := make(chan []Object, Config.Transfers)
 	go func() {
+		defer close(checks)
 		for _, check := range commonFiles {
-			checks <- check
+			select {
+			case checks <- check:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(checks)
 	}()
 
 	var checkerWg sync.WaitGroup
@@ -607,50 +1174,275 @@ func Check(fdst, fsrc Fs) error {
 					Stats.DoneChecking(src)
 					Stats.Error()
 					ErrorLog(src, "Sizes differ")
+					opt.report(opt.Differ, src.Remote())
+					atomic.AddInt32(&differences, 1)
 					continue
 				}
-				same, _, err := CheckMd5sums(src, dst)
-				Stats.DoneChecking(src)
-				if err != nil {
-					continue
+				same, hash, err := CheckHashes(ctx, src, dst)
+				if err == nil && hash == HashNone {
+					if opt.Download {
+						var differ bool
+						differ, err = checkIdentical(ctx, dst, src)
+						if err != nil {
+							Stats.Error()
+							ErrorLog(src, "Failed to compare contents: %v", err)
+						}
+						same = !differ
+					} else {
+						atomic.AddInt32(&noHashes, 1)
+					}
 				}
-				if !same {
+				Stats.DoneChecking(src)
+				switch {
+				case err != nil:
+					opt.report(opt.Error, src.Remote())
+					atomic.AddInt32(&errors, 1)
+				case !same:
 					Stats.Error()
-					ErrorLog(src, "Md5sums differ")
+					if opt.Download && hash == HashNone {
+						ErrorLog(src, "Contents differ")
+					} else {
+						ErrorLog(src, "%v differ", hash)
+					}
+					opt.report(opt.Differ, src.Remote())
+					atomic.AddInt32(&differences, 1)
+				default:
+					opt.report(opt.Match, src.Remote())
+					Debug(src, "OK")
 				}
-				Debug(src, "OK")
 			}
 		}()
 	}
 
 	Log(fdst, "Waiting for checks to finish")
 	checkerWg.Wait()
-	Log(fdst, "%d differences found", Stats.GetErrors())
-	if Stats.GetErrors() > 0 {
-		return fmt.Errorf("%d differences found", Stats.GetErrors())
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if noHashes > 0 {
+		Log(fdst, "%d files had no hash in common so only their sizes were checked - use --download to compare their contents", noHashes)
+	}
+	return checkSummary(fdst, differences, errors)
+}
+
+// checkSummary logs the result of checking f and returns an error if
+// any differences or errors were found
+func checkSummary(f Fs, differences, errors int32) error {
+	if errors > 0 {
+		Log(f, "%d files couldn't be checked because of errors", errors)
+	}
+	Log(f, "%d differences found", differences)
+	if differences > 0 || errors > 0 {
+		return fmt.Errorf("%d differences found and %d errors", differences, errors)
 	}
 	return nil
 }
 
+// Paths containing these are escaped in sum files, with a "\" at the
+// start of the line to show it, as the md5sum and sha1sum tools do
+var (
+	sumPathEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+	sumPathUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r")
+)
+
+// sumLine formats sum and remote as a line of a sum file
+func sumLine(width int, sum, remote string) string {
+	escape := ""
+	if strings.ContainsAny(remote, "\\\n\r") {
+		escape = `\`
+		remote = sumPathEscaper.Replace(remote)
+	}
+	return fmt.Sprintf("%s%*s  %s\n", escape, width, sum, remote)
+}
+
+// readSumFile reads sums of type ht in the format produced by the
+// md5sum and sha1sum tools, returning a map of path to sum
+//
+// A "*" marking binary mode and a leading "./" on the paths are
+// ignored as are blank lines.  Lines starting with "\" have escaped
+// paths.  A path listed more than once is logged as an error and
+// counted and its first sum is used.
+func readSumFile(in io.Reader, ht HashType) (map[string]string, error) {
+	sums := make(map[string]string)
+	width := HashWidth[ht]
+	scanner := bufio.NewScanner(in)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		escaped := strings.HasPrefix(line, `\`)
+		if escaped {
+			line = line[1:]
+		}
+		if width == 0 || len(line) < width+3 || line[width] != ' ' || (line[width+1] != ' ' && line[width+1] != '*') {
+			return nil, fmt.Errorf("line %d: not in %v sum format: %q", lineNumber, ht, line)
+		}
+		sum := strings.ToLower(line[:width])
+		if _, err := hex.DecodeString(sum); err != nil {
+			return nil, fmt.Errorf("line %d: bad %v sum %q", lineNumber, ht, sum)
+		}
+		remote := strings.TrimPrefix(line[width+2:], "./")
+		if escaped {
+			remote = sumPathUnescaper.Replace(remote)
+		}
+		if previous, found := sums[remote]; found {
+			Stats.Error()
+			ErrorLog(remote, "line %d: duplicate path with %v sum %s - already had %s", lineNumber, ht, sum, previous)
+			continue
+		}
+		sums[remote] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sums: %v", err)
+	}
+	return sums, nil
+}
+
+// CheckSum checks the files in f against the sums of type ht read
+// from in, which is in the format produced by the md5sum and sha1sum
+// tools
+//
+// The files listed in the sums are treated as the source and the
+// files in f as the destination when reporting to opt.  If
+// opt.Download is set then sums which f doesn't store are calculated
+// by downloading the files.  opt may be nil for the defaults.
+//
+// It returns an error if any differences or errors were found.
+func CheckSum(ctx context.Context, f Fs, ht HashType, in io.Reader, opt *CheckOpt) error {
+	if opt == nil {
+		opt = &CheckOpt{}
+	}
+	sums, err := readSumFile(in, ht)
+	if err != nil {
+		return err
+	}
+
+	Log(f, "Building file list")
+	files, err := readFilesMap(ctx, f)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err != nil {
+		Stats.Error()
+		ErrorLog(f, "Error listing: %v", err)
+		return err
+	}
+
+	var differences, errors int32
+	for remote := range sums {
+		if _, ok := files[remote]; !ok {
+			Stats.Error()
+			ErrorLog(remote, "File not in %v", f)
+			opt.report(opt.MissingOnDst, remote)
+			differences++
+		}
+	}
+	if !opt.OneWay {
+		for remote, o := range files {
+			if _, ok := sums[remote]; !ok {
+				Stats.Error()
+				ErrorLog(o, "File not in %v sums", ht)
+				opt.report(opt.MissingOnSrc, remote)
+				differences++
+			}
+		}
+	}
+
+	checks := make(chan Object, Config.Transfers)
+	go func() {
+		defer close(checks)
+		for remote, o := range files {
+			if _, ok := sums[remote]; !ok {
+				continue
+			}
+			select {
+			case checks <- o:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var checkerWg sync.WaitGroup
+	checkerWg.Add(Config.Checkers)
+	for i := 0; i < Config.Checkers; i++ {
+		go func() {
+			defer checkerWg.Done()
+			for o := range checks {
+				Stats.Checking(o)
+				sum, err := hashSum(ctx, ht, o, opt.Download)
+				Stats.DoneChecking(o)
+				switch {
+				case err != nil:
+					Stats.Error()
+					ErrorLog(o, "Failed to read %v: %v", ht, err)
+					opt.report(opt.Error, o.Remote())
+					atomic.AddInt32(&errors, 1)
+				case sum == "":
+					Stats.Error()
+					ErrorLog(o, "No %v available - use --download to calculate it", ht)
+					opt.report(opt.Error, o.Remote())
+					atomic.AddInt32(&errors, 1)
+				case !strings.EqualFold(sum, sums[o.Remote()]):
+					Stats.Error()
+					ErrorLog(o, "%v differ", ht)
+					opt.report(opt.Differ, o.Remote())
+					atomic.AddInt32(&differences, 1)
+				default:
+					opt.report(opt.Match, o.Remote())
+					Debug(o, "OK")
+				}
+			}
+		}()
+	}
+
+	Log(f, "Waiting for checks to finish")
+	checkerWg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return checkSummary(f, differences, errors)
+}
+
 // ListFn lists the Fs to the supplied function
 //
 // Lists in parallel which may get them out of order
-func ListFn(f Fs, fn func(Object)) error {
-	in := f.List()
-	var wg sync.WaitGroup
+//
+// Returns the error from the listing if it failed
+func ListFn(ctx context.Context, f Fs, fn func(Object)) error {
+	list := NewLister().Start(ctx, f)
+	var (
+		wg      sync.WaitGroup
+		errMu   sync.Mutex
+		listErr error
+	)
 	wg.Add(Config.Checkers)
 	for i := 0; i < Config.Checkers; i++ {
 		go func() {
 			defer wg.Done()
-			for o := range in {
-				if Config.Filter.IncludeObject(o) {
+			for {
+				o, err := list.Get()
+				if err != nil {
+					errMu.Lock()
+					listErr = err
+					errMu.Unlock()
+					return
+				}
+				if o == nil {
+					return
+				}
+				if ctx.Err() == nil && Config.Filter.IncludeObject(ctx, o) {
 					fn(o)
 				}
 			}
 		}()
 	}
 	wg.Wait()
-	return nil
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return listErr
 }
 
 // mutex for synchronized output
@@ -670,8 +1462,8 @@ func syncFprintf(w io.Writer, format string, a ...interface{}) {
 // Shows size and path - obeys includes and excludes
 //
 // Lists in parallel which may get them out of order
-func List(f Fs, w io.Writer) error {
-	return ListFn(f, func(o Object) {
+func List(ctx context.Context, f Fs, w io.Writer) error {
+	return ListFn(ctx, f, func(o Object) {
 		syncFprintf(w, "%9d %s\n", o.Size(), o.Remote())
 	})
 }
@@ -681,10 +1473,10 @@ func List(f Fs, w io.Writer) error {
 // Shows size, mod time and path - obeys includes and excludes
 //
 // Lists in parallel which may get them out of order
-func ListLong(f Fs, w io.Writer) error {
-	return ListFn(f, func(o Object) {
+func ListLong(ctx context.Context, f Fs, w io.Writer) error {
+	return ListFn(ctx, f, func(o Object) {
 		Stats.Checking(o)
-		modTime := o.ModTime()
+		modTime := o.ModTime(ctx)
 		Stats.DoneChecking(o)
 		syncFprintf(w, "%9d %s %s\n", o.Size(), modTime.Local().Format("2006-01-02 15:04:05.000000000"), o.Remote())
 	})
@@ -696,24 +1488,119 @@ func ListLong(f Fs, w io.Writer) error {
 // excludes
 //
 // Lists in parallel which may get them out of order
-func Md5sum(f Fs, w io.Writer) error {
-	return ListFn(f, func(o Object) {
+func Md5sum(ctx context.Context, f Fs, w io.Writer) error {
+	return HashLister(ctx, HashMD5, false, f, w)
+}
+
+// hashSum returns the hash of type ht of o
+//
+// If download is set and o doesn't store the hash then it is
+// calculated by reading the contents of o.
+func hashSum(ctx context.Context, ht HashType, o Object, download bool) (string, error) {
+	sum, err := o.Hash(ctx, ht)
+	if !download || (err == nil && sum != "") || (err != nil && err != ErrHashUnsupported) {
+		return sum, err
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to open: %v", err)
+	}
+	acc := NewAccount(in, o) // account the transfer
+	defer func() {
+		_ = acc.Close() // ignore error
+	}()
+	sums, err := HashStreamTypes(acc, NewHashSet(ht))
+	if err != nil {
+		return "", fmt.Errorf("failed to read: %v", err)
+	}
+	return sums[ht], nil
+}
+
+// HashLister lists the hashes of type ht of the objects in f to the
+// supplied writer in the same format as the md5sum and sha1sum
+// commands - obeys includes and excludes
+//
+// If download is set then hashes which f doesn't store are
+// calculated by downloading the objects.
+//
+// Lists in parallel which may get them out of order
+func HashLister(ctx context.Context, ht HashType, download bool, f Fs, w io.Writer) error {
+	return ListFn(ctx, f, func(o Object) {
 		Stats.Checking(o)
-		md5sum, err := o.Md5sum()
+		sum, err := hashSum(ctx, ht, o, download)
 		Stats.DoneChecking(o)
+		if err == ErrHashUnsupported {
+			sum = "UNSUPPORTED"
+		} else if err != nil {
+			Debug(o, "Failed to read %v: %v", ht, err)
+			sum = "ERROR"
+		}
+		syncFprintf(w, "%s", sumLine(HashWidth[ht], sum, o.Remote()))
+	})
+}
+
+// Cat writes the contents of the files in f to w - obeys includes and
+// excludes
+//
+// If offset > 0 each file is read from that many bytes in and if
+// offset < 0 only the last -offset bytes are read.  If count > 0 at
+// most count bytes are written from each file.  Ranged reads are used
+// so only the bytes wanted are fetched where the remote supports it.
+//
+// The files are read in parallel but written to w one at a time.
+func Cat(ctx context.Context, f Fs, w io.Writer, offset, count int64) error {
+	var mu sync.Mutex
+	var options []OpenOption
+	switch {
+	case offset < 0:
+		options = append(options, &RangeOption{Start: -1, End: -offset})
+	case count > 0:
+		options = append(options, &RangeOption{Start: offset, End: offset + count - 1})
+	case offset > 0:
+		options = append(options, &SeekOption{Offset: offset})
+	}
+	return ListFn(ctx, f, func(o Object) {
+		// Remotes may reject a range on an empty object or one
+		// starting past the end so read those without one
+		size := o.Size()
+		openOptions := options
+		if size == 0 {
+			openOptions = nil
+		} else if size > 0 && offset >= size {
+			return
+		}
+		Stats.Transferring(o)
+		defer Stats.DoneTransferring(o)
+		in0, err := o.Open(ctx, openOptions...)
+		if err != nil {
+			Stats.Error()
+			ErrorLog(o, "Failed to open: %v", err)
+			return
+		}
+		if count > 0 {
+			in0 = NewLimitedReadCloser(in0, count)
+		}
+		in := NewAccount(in0, o) // account the transfer
+		mu.Lock()
+		defer mu.Unlock()
+		_, err = io.Copy(w, in)
 		if err != nil {
-			Debug(o, "Failed to read MD5: %v", err)
-			md5sum = "ERROR"
+			Stats.Error()
+			ErrorLog(o, "Failed to send to output: %v", err)
+		}
+		err = in.Close()
+		if err != nil {
+			Stats.Error()
+			ErrorLog(o, "Failed to close: %v", err)
 		}
-		syncFprintf(w, "%32s  %s\n", md5sum, o.Remote())
 	})
 }
 
 // Count counts the objects and their sizes in the Fs
 //
 // Obeys includes and excludes
-func Count(f Fs) (objects int64, size int64, err error) {
-	err = ListFn(f, func(o Object) {
+func Count(ctx context.Context, f Fs) (objects int64, size int64, err error) {
+	err = ListFn(ctx, f, func(o Object) {
 		atomic.AddInt64(&objects, 1)
 		atomic.AddInt64(&size, o.Size())
 	})
@@ -721,16 +1608,247 @@ func Count(f Fs) (objects int64, size int64, err error) {
 }
 
 // ListDir lists the directories/buckets/containers in the Fs to the supplied writer
-func ListDir(f Fs, w io.Writer) error {
-	for dir := range f.ListDir() {
+func ListDir(ctx context.Context, f Fs, w io.Writer) error {
+	for dir := range f.ListDir(ctx) {
 		syncFprintf(w, "%12d %13s %9d %s\n", dir.Bytes, dir.When.Format("2006-01-02 15:04:05"), dir.Count, dir.Name)
 	}
 	return nil
 }
 
+// ListJSONItem is an object or directory in the output of ListJSON
+type ListJSONItem struct {
+	Path     string            `json:"path"`               // path relative to the root of the Fs
+	Name     string            `json:"name"`               // leaf name
+	Size     int64             `json:"size"`               // size in bytes, -1 for unknown
+	ModTime  string            `json:"modTime,omitempty"`  // RFC3339 modification time if known
+	MimeType string            `json:"mimeType,omitempty"` // content type of objects
+	IsDir    bool              `json:"isDir"`              // set if this is a directory
+	Hashes   map[string]string `json:"hashes,omitempty"`   // hashes of objects keyed by hash name
+}
+
+// newListJSONItem makes the ListJSONItem for o, including its hashes
+// if showHash is set
+func newListJSONItem(ctx context.Context, o Object, showHash bool) *ListJSONItem {
+	item := &ListJSONItem{
+		Path:    o.Remote(),
+		Name:    path.Base(o.Remote()),
+		Size:    o.Size(),
+		ModTime: o.ModTime(ctx).Format(time.RFC3339Nano),
+	}
+	if do, ok := o.(Metadataer); ok {
+		item.MimeType = do.MimeType(ctx)
+	}
+	if item.MimeType == "" {
+		item.MimeType = MimeType(o)
+	}
+	if !showHash {
+		return item
+	}
+	for _, hashType := range o.Fs().Hashes().Array() {
+		sum, err := o.Hash(ctx, hashType)
+		if err != nil {
+			Stats.Error()
+			ErrorLog(o, "Failed to read %v hash: %v", hashType, err)
+			continue
+		}
+		if sum == "" {
+			continue
+		}
+		if item.Hashes == nil {
+			item.Hashes = make(map[string]string)
+		}
+		item.Hashes[hashType.String()] = sum
+	}
+	return item
+}
+
+// ListJSON lists the objects and directories in f to w as a JSON
+// array of ListJSONItem - obeys includes and excludes
+//
+// Only the top level is listed unless recurse is set.  Hashes are
+// only included if showHash is set as some remotes have to read the
+// whole object to make them.  Each item is written on its own line as
+// soon as it is read so, if f has the ListDirEntries feature, only one
+// directory is held in memory at once.
+func ListJSON(ctx context.Context, f Fs, w io.Writer, recurse, showHash bool) error {
+	lister := newDirLister(f)
+	first := true
+	write := func(item *ListJSONItem) error {
+		out, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		sep := ",\n"
+		if first {
+			sep, first = "\n", false
+		}
+		_, err = fmt.Fprintf(w, "%s%s", sep, out)
+		return err
+	}
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		objects, dirs, err := lister.List(ctx, dir)
+		if err != nil {
+			return err
+		}
+		for _, d := range dirs {
+			item := &ListJSONItem{
+				Path:  d.Name,
+				Name:  path.Base(d.Name),
+				Size:  d.Bytes,
+				IsDir: true,
+			}
+			if !d.When.IsZero() {
+				item.ModTime = d.When.Format(time.RFC3339Nano)
+			}
+			if err = write(item); err != nil {
+				return err
+			}
+			if recurse {
+				if err = walk(d.Name); err != nil {
+					return err
+				}
+			}
+		}
+		for _, o := range objects {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !Config.Filter.IncludeObject(ctx, o) {
+				continue
+			}
+			if err = write(newListJSONItem(ctx, o, showHash)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if _, err := fmt.Fprint(w, "["); err != nil {
+		return err
+	}
+	if err := walk(""); err != nil {
+		return err
+	}
+	_, err := fmt.Fprint(w, "\n]\n")
+	return err
+}
+
+// About prints the quota information for the Fs to the supplied
+// writer, as JSON if asJSON is set
+func About(ctx context.Context, f Fs, w io.Writer, asJSON bool) error {
+	doAbout := f.Features().About
+	if doAbout == nil {
+		return fmt.Errorf("%v doesn't support about", f)
+	}
+	usage, err := doAbout(ctx)
+	if err != nil {
+		return fmt.Errorf("About failed: %v", err)
+	}
+	if asJSON {
+		out, err := json.MarshalIndent(usage, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	}
+	for _, line := range []struct {
+		name  string
+		value *int64
+	}{
+		{"Total", usage.Total},
+		{"Used", usage.Used},
+		{"Trashed", usage.Trashed},
+		{"Free", usage.Free},
+	} {
+		if line.value != nil {
+			fmt.Fprintf(w, "%-8s %v (%d bytes)\n", line.name+":", SizeSuffix(*line.value), *line.value)
+		}
+	}
+	return nil
+}
+
+// PublicLink makes a link which can be used to read remote in f
+// without any credentials, valid for expire if the remote supports
+// links which expire
+func PublicLink(ctx context.Context, f Fs, remote string, expire time.Duration) (string, error) {
+	doPublicLink := f.Features().PublicLink
+	if doPublicLink == nil {
+		return "", fmt.Errorf("%v doesn't support public links", f)
+	}
+	link, err := doPublicLink(ctx, remote, expire)
+	if err != nil {
+		return "", fmt.Errorf("PublicLink failed: %v", err)
+	}
+	return link, nil
+}
+
+// Rcat reads data from in and uploads it to dstFileName in fdst with
+// the modification time given
+//
+// The length of in needn't be known in advance - it is read until
+// io.EOF.  in is closed when the upload is finished.
+func Rcat(ctx context.Context, fdst Fs, dstFileName string, in io.ReadCloser, modTime time.Time) (dst Object, err error) {
+	Stats.transferringName(dstFileName)
+	defer Stats.doneTransferringName(dstFileName)
+
+	if Config.DryRun {
+		Log(fdst, "Not uploading %q as --dry-run", dstFileName)
+		// Drain the input so the writer doesn't get a broken pipe
+		_, err = io.Copy(ioutil.Discard, in)
+		closeErr := in.Close()
+		if err == nil {
+			err = closeErr
+		}
+		return nil, err
+	}
+
+	acc := NewAccountSizeName(in, -1, dstFileName) // account the transfer
+	dst, err = fdst.Put(ctx, acc, dstFileName, modTime, -1)
+	closeErr := acc.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		Stats.Error()
+		ErrorLog(fdst, "Failed to upload %q: %s", dstFileName, err)
+		removeFailedCopy(ctx, dst)
+		return nil, err
+	}
+	Debug(dst, "Uploaded from stream")
+	return dst, nil
+}
+
+// SpoolToTempFile copies in to a temporary file so that the length
+// of the stream is known.  It is for backends which can't upload
+// streams of unknown size.
+//
+// It returns the file rewound ready for reading and the number of
+// bytes copied.  The caller must call cleanup when finished with the
+// file which closes and removes it.
+func SpoolToTempFile(in io.Reader, prefix string) (fd *os.File, size int64, cleanup func(), err error) {
+	fd, err = ioutil.TempFile("", prefix)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup = func() {
+		_ = fd.Close()           // Ignore error may have been closed already
+		_ = os.Remove(fd.Name()) // Delete the file - may have been deleted already
+	}
+	size, err = io.Copy(fd, in)
+	if err == nil {
+		_, err = fd.Seek(0, 0)
+	}
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	return fd, size, cleanup, nil
+}
+
 // Mkdir makes a destination directory or container
-func Mkdir(f Fs) error {
-	err := f.Mkdir()
+func Mkdir(ctx context.Context, f Fs) error {
+	err := f.Mkdir(ctx)
 	if err != nil {
 		Stats.Error()
 		return err
@@ -739,11 +1857,11 @@ func Mkdir(f Fs) error {
 }
 
 // Rmdir removes a container but not if not empty
-func Rmdir(f Fs) error {
+func Rmdir(ctx context.Context, f Fs) error {
 	if Config.DryRun {
 		Log(f, "Not deleting as dry run is set")
 	} else {
-		err := f.Rmdir()
+		err := f.Rmdir(ctx)
 		if err != nil {
 			Stats.Error()
 			return err
@@ -755,15 +1873,15 @@ func Rmdir(f Fs) error {
 // Purge removes a container and all of its contents
 //
 // FIXME doesn't delete local directories
-func Purge(f Fs) error {
+func Purge(ctx context.Context, f Fs) error {
 	doFallbackPurge := true
 	var err error
-	if purger, ok := f.(Purger); ok {
+	if doPurge := f.Features().Purge; doPurge != nil {
 		doFallbackPurge = false
 		if Config.DryRun {
 			Debug(f, "Not purging as --dry-run set")
 		} else {
-			err = purger.Purge()
+			err = doPurge(ctx)
 			if err == ErrorCantPurge {
 				doFallbackPurge = true
 			}
@@ -771,9 +1889,66 @@ func Purge(f Fs) error {
 	}
 	if doFallbackPurge {
 		// DeleteFiles and Rmdir observe --dry-run
-		DeleteFiles(f.List())
-		err = Rmdir(f)
+		var listErr error
+		toBeDeleted := make(ObjectsChan, Config.Transfers)
+		go func() {
+			defer close(toBeDeleted)
+			list := NewLister().Start(ctx, f)
+			for {
+				o, err := list.Get()
+				if err != nil {
+					listErr = err
+					return
+				}
+				if o == nil {
+					return
+				}
+				toBeDeleted <- o
+			}
+		}()
+		DeleteFiles(ctx, toBeDeleted)
+		err = listErr
+		if err == nil {
+			err = Rmdir(ctx, f)
+		}
+	}
+	if err != nil {
+		Stats.Error()
+		return err
+	}
+	return nil
+}
+
+// Delete removes all the files in f which match the filters, leaving
+// the directories in place
+//
+// DeleteFiles observes --dry-run
+func Delete(ctx context.Context, f Fs) error {
+	var listErr error
+	toBeDeleted := make(ObjectsChan, Config.Transfers)
+	go func() {
+		defer close(toBeDeleted)
+		listErr = ListFn(ctx, f, func(o Object) {
+			toBeDeleted <- o
+		})
+	}()
+	DeleteFiles(ctx, toBeDeleted)
+	if listErr != nil {
+		Stats.Error()
+		ErrorLog(f, "Not all files were listed so some weren't deleted: %v", listErr)
+		return listErr
+	}
+	return nil
+}
+
+// CleanUp removes the trash for the Fs
+func CleanUp(ctx context.Context, f Fs) error {
+	doCleanUp := f.Features().CleanUp
+	if doCleanUp == nil {
+		return fmt.Errorf("%v doesn't support cleanup", f)
 	}
+	// doCleanUp observes --dry-run so it can say what it would delete
+	err := doCleanUp(ctx)
 	if err != nil {
 		Stats.Error()
 		return err
@@ -0,0 +1,101 @@
+package fs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadersDiffer(t *testing.T) {
+	long := strings.Repeat("0123456789", 20000)
+	for _, test := range []struct {
+		a, b string
+		want bool
+	}{
+		{"", "", false},
+		{"hello", "hello", false},
+		{"hello", "hellp", true},
+		{"hello", "hello world", true},
+		{"hello world", "hello", true},
+		{"", "a", true},
+		{long, long, false},
+		{long, long[:len(long)-1] + "X", true},
+		{long, long + "X", true},
+	} {
+		// One stream arriving a byte at a time shouldn't matter
+		differ, err := readersDiffer(strings.NewReader(test.a), iotest.OneByteReader(strings.NewReader(test.b)))
+		if err != nil {
+			t.Fatalf("readersDiffer failed: %v", err)
+		}
+		if differ != test.want {
+			t.Errorf("%.20q vs %.20q: want differ %v got %v", test.a, test.b, test.want, differ)
+		}
+	}
+}
+
+func TestReadSumFile(t *testing.T) {
+	const sum = "d41d8cd98f00b204e9800998ecf8427e"
+	for _, test := range []struct {
+		in   string
+		want map[string]string
+		err  bool
+	}{
+		{"", map[string]string{}, false},
+		{sum + "  file.txt\n", map[string]string{"file.txt": sum}, false},
+		{sum + " *dir/bin file\r\n\n", map[string]string{"dir/bin file": sum}, false},
+		{strings.ToUpper(sum) + "  ./file.txt", map[string]string{"file.txt": sum}, false},
+		{sum + " file.txt\n", nil, true},
+		{sum[1:] + "  file.txt\n", nil, true},
+		{"x" + sum[1:] + "  file.txt\n", nil, true},
+		{`\` + sum + `  back\\slash\nnew line` + "\n", map[string]string{"back\\slash\nnew line": sum}, false},
+		{sum + `  not\nescaped` + "\n", map[string]string{`not\nescaped`: sum}, false},
+	} {
+		got, err := readSumFile(strings.NewReader(test.in), HashMD5)
+		if (err != nil) != test.err {
+			t.Errorf("%q: want error %v got %v", test.in, test.err, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: want %v got %v", test.in, test.want, got)
+		}
+	}
+}
+
+func TestReadSumFileDuplicates(t *testing.T) {
+	const sum1 = "d41d8cd98f00b204e9800998ecf8427e"
+	const sum2 = "0cc175b9c0f1b6a831c399e269772661"
+	in := sum1 + "  file.txt\n" + sum2 + "  ./file.txt\n" + sum2 + "  other.txt\n"
+	before := Stats.GetErrors()
+	got, err := readSumFile(strings.NewReader(in), HashMD5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]string{"file.txt": sum1, "other.txt": sum2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v got %v", want, got)
+	}
+	if n := Stats.GetErrors() - before; n != 1 {
+		t.Errorf("want 1 error counted got %d", n)
+	}
+}
+
+func TestSumLine(t *testing.T) {
+	const sum = "d41d8cd98f00b204e9800998ecf8427e"
+	for _, remote := range []string{
+		"file.txt",
+		"dir/file with spaces.txt",
+		`back\slash`,
+		"new\nline",
+		"carriage\rreturn",
+	} {
+		line := sumLine(HashWidth[HashMD5], sum, remote)
+		got, err := readSumFile(strings.NewReader(line), HashMD5)
+		if err != nil {
+			t.Errorf("%q: failed to read %q: %v", remote, line, err)
+		} else if got[remote] != sum || len(got) != 1 {
+			t.Errorf("%q: didn't read back %q: got %v", remote, line, got)
+		}
+	}
+	if got, want := sumLine(HashWidth[HashMD5], sum, `a\b`), `\`+sum+`  a\\b`+"\n"; got != want {
+		t.Errorf("want %q got %q", want, got)
+	}
+}
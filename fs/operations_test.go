@@ -5,7 +5,11 @@ package fs_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -106,7 +110,7 @@ func TestCopyWithDryRun(t *testing.T) {
 	WriteFile("sub dir/hello world", "hello world", t1)
 
 	fs.Config.DryRun = true
-	err := fs.CopyDir(fremote, flocal)
+	err := fs.CopyDir(context.Background(), fremote, flocal)
 	fs.Config.DryRun = false
 	if err != nil {
 		t.Fatalf("Copy failed: %v", err)
@@ -120,9 +124,21 @@ func TestCopyWithDryRun(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, fremote, []fstest.Item{}, fs.Config.ModifyWindow)
 }
 
+// Check a cancelled context stops the copy
+func TestCopyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := fs.CopyDir(ctx, fremote, flocal)
+	if err != context.Canceled {
+		t.Fatalf("Expecting context.Canceled but got %v", err)
+	}
+
+	fstest.CheckListingWithPrecision(t, fremote, []fstest.Item{}, fs.Config.ModifyWindow)
+}
+
 // Now without dry run
 func TestCopy(t *testing.T) {
-	err := fs.CopyDir(fremote, flocal)
+	err := fs.CopyDir(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Copy failed: %v", err)
 	}
@@ -144,7 +160,7 @@ func TestServerSideCopy(t *testing.T) {
 	defer finaliseCopy()
 	t.Logf("Server side copy (if possible) %v -> %v", fremote, fremoteCopy)
 
-	err = fs.CopyDir(fremoteCopy, fremote)
+	err = fs.CopyDir(context.Background(), fremoteCopy, fremote)
 	if err != nil {
 		t.Fatalf("Server Side Copy failed: %v", err)
 	}
@@ -159,7 +175,7 @@ func TestServerSideCopy(t *testing.T) {
 
 func TestLsd(t *testing.T) {
 	var buf bytes.Buffer
-	err := fs.ListDir(fremote, &buf)
+	err := fs.ListDir(context.Background(), fremote, &buf)
 	if err != nil {
 		t.Fatalf("ListDir failed: %v", err)
 	}
@@ -184,7 +200,7 @@ func TestCopyAfterDelete(t *testing.T) {
 }
 
 func TestCopyRedownload(t *testing.T) {
-	err := fs.CopyDir(flocal, fremote)
+	err := fs.CopyDir(context.Background(), flocal, fremote)
 	if err != nil {
 		t.Fatalf("Copy failed: %v", err)
 	}
@@ -214,7 +230,7 @@ func TestSyncBasedOnCheckSum(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, flocal, local_items, fs.Config.ModifyWindow)
 
 	fs.Stats.ResetCounters()
-	err := fs.Sync(fremote, flocal)
+	err := fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Initial sync failed: %v", err)
 	}
@@ -237,7 +253,7 @@ func TestSyncBasedOnCheckSum(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, flocal, local_items, fs.Config.ModifyWindow)
 
 	fs.Stats.ResetCounters()
-	err = fs.Sync(fremote, flocal)
+	err = fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -268,7 +284,7 @@ func TestSyncSizeOnly(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, flocal, local_items, fs.Config.ModifyWindow)
 
 	fs.Stats.ResetCounters()
-	err := fs.Sync(fremote, flocal)
+	err := fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Initial sync failed: %v", err)
 	}
@@ -289,7 +305,7 @@ func TestSyncSizeOnly(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, flocal, local_items, fs.Config.ModifyWindow)
 
 	fs.Stats.ResetCounters()
-	err = fs.Sync(fremote, flocal)
+	err = fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -312,7 +328,7 @@ func TestSyncAfterChangingModtimeOnly(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Chtimes failed: %v", err)
 	}
-	err = fs.Sync(fremote, flocal)
+	err = fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -325,7 +341,7 @@ func TestSyncAfterChangingModtimeOnly(t *testing.T) {
 
 func TestSyncAfterAddingAFile(t *testing.T) {
 	WriteFile("potato", "------------------------------------------------------------", t3)
-	err := fs.Sync(fremote, flocal)
+	err := fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -339,7 +355,7 @@ func TestSyncAfterAddingAFile(t *testing.T) {
 
 func TestSyncAfterChangingFilesSizeOnly(t *testing.T) {
 	WriteFile("potato", "smaller but same date", t3)
-	err := fs.Sync(fremote, flocal)
+	err := fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -356,13 +372,13 @@ func TestSyncAfterChangingContentsOnly(t *testing.T) {
 	if fremote.Precision() == fs.ModTimeNotSupported {
 		t.Logf("ModTimeNotSupported so forcing file to be a different size")
 		WriteFile("potato", "different size to make sure it syncs", t2)
-		err := fs.Sync(fremote, flocal)
+		err := fs.Sync(context.Background(), fremote, flocal)
 		if err != nil {
 			t.Fatalf("Sync failed: %v", err)
 		}
 	}
 	WriteFile("potato", "SMALLER BUT SAME DATE", t2)
-	err := fs.Sync(fremote, flocal)
+	err := fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -382,7 +398,7 @@ func TestSyncAfterRemovingAFileAndAddingAFileDryRun(t *testing.T) {
 		t.Fatalf("Remove failed: %v", err)
 	}
 	fs.Config.DryRun = true
-	err = fs.Sync(fremote, flocal)
+	err = fs.Sync(context.Background(), fremote, flocal)
 	fs.Config.DryRun = false
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
@@ -400,9 +416,40 @@ func TestSyncAfterRemovingAFileAndAddingAFileDryRun(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, fremote, before, fs.Config.ModifyWindow)
 }
 
+// errorListFs is an Fs whose listing always fails
+type errorListFs struct {
+	fs.Fs
+}
+
+// Features returns no optional features so the listing is done with List
+func (f errorListFs) Features() *fs.Features {
+	return &fs.Features{}
+}
+
+// List returns an error instead of any objects
+func (f errorListFs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
+	out.SetError(errors.New("list failed"))
+}
+
+// Sync with a source which fails to list shouldn't delete anything
+func TestSyncWithListError(t *testing.T) {
+	err := fs.Sync(context.Background(), fremote, errorListFs{flocal})
+	if err == nil {
+		t.Fatalf("Expecting error from Sync")
+	}
+	fs.Stats.ResetErrors()
+
+	before := []fstest.Item{
+		{Path: "empty space", Size: 0, ModTime: t2, Md5sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "potato", Size: 21, ModTime: t2, Md5sum: "e4cb6955d9106df6263c45fcfc10f163"},
+	}
+	fstest.CheckListingWithPrecision(t, fremote, before, fs.Config.ModifyWindow)
+}
+
 // Sync after removing a file and adding a file
 func TestSyncAfterRemovingAFileAndAddingAFile(t *testing.T) {
-	err := fs.Sync(fremote, flocal)
+	err := fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -414,6 +461,191 @@ func TestSyncAfterRemovingAFileAndAddingAFile(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
 }
 
+// listOnlyFs hides the optional features of the Fs it wraps so it
+// can only be read with List
+type listOnlyFs struct {
+	fs.Fs
+}
+
+// Features returns no optional features
+func (f listOnlyFs) Features() *fs.Features {
+	return &fs.Features{}
+}
+
+// Sync nested directories from a source without DirLister then
+// remove them again
+func TestSyncNestedDirectories(t *testing.T) {
+	WriteFile("a/b/c/potato3", "hello", t1)
+	WriteFile("a/potato4", "hello world", t2)
+	WriteFile("a/b2/potato5", "hello", t2)
+	err := fs.Sync(context.Background(), fremote, listOnlyFs{flocal})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	items := []fstest.Item{
+		{Path: "a/b/c/potato3", Size: 5, ModTime: t1, Md5sum: "5d41402abc4b2a76b9719d911017c592"},
+		{Path: "a/b2/potato5", Size: 5, ModTime: t2, Md5sum: "5d41402abc4b2a76b9719d911017c592"},
+		{Path: "a/potato4", Size: 11, ModTime: t2, Md5sum: "5eb63bbbe01eeed093cb22bb8f5acdc3"},
+		{Path: "empty space", Size: 0, ModTime: t2, Md5sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "potato2", Size: 60, ModTime: t1, Md5sum: "d6548b156ea68a4e003e786df99eee76"},
+	}
+	fstest.CheckListingWithPrecision(t, flocal, items, fs.Config.ModifyWindow)
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+
+	err = os.RemoveAll(localName + "/a")
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	err = fs.Sync(context.Background(), fremote, flocal)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	items = []fstest.Item{
+		{Path: "empty space", Size: 0, ModTime: t2, Md5sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "potato2", Size: 60, ModTime: t1, Md5sum: "d6548b156ea68a4e003e786df99eee76"},
+	}
+	fstest.CheckListingWithPrecision(t, flocal, items, fs.Config.ModifyWindow)
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+}
+
+// duplicateFs lists every object and directory in the Fs it wraps
+// twice, as a remote which allows duplicate names might
+type duplicateFs struct {
+	fs.Fs
+}
+
+// Features returns only ListDirEntries so the duplicates are seen
+func (f duplicateFs) Features() *fs.Features {
+	return &fs.Features{ListDirEntries: f.ListDirEntries}
+}
+
+// ListDirEntries lists dir with every object and directory in it
+// twice
+func (f duplicateFs) ListDirEntries(ctx context.Context, dir string) (fs.Objects, []*fs.Dir, error) {
+	objects, dirs, err := fs.ListDirSorted(ctx, f.Fs, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var duplicated fs.Objects
+	for _, o := range objects {
+		duplicated = append(duplicated, o, o)
+	}
+	var duplicatedDirs []*fs.Dir
+	for _, dir := range dirs {
+		duplicatedDirs = append(duplicatedDirs, dir, dir)
+	}
+	return duplicated, duplicatedDirs, nil
+}
+
+// Duplicate files and directories shouldn't be deleted from the
+// destination or transferred again from the source
+func TestSyncWithDuplicates(t *testing.T) {
+	WriteFile("dup dir/potato6", "hello", t1)
+	err := fs.Sync(context.Background(), fremote, flocal)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	items := []fstest.Item{
+		{Path: "dup dir/potato6", Size: 5, ModTime: t1, Md5sum: "5d41402abc4b2a76b9719d911017c592"},
+		{Path: "empty space", Size: 0, ModTime: t2, Md5sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "potato2", Size: 60, ModTime: t1, Md5sum: "d6548b156ea68a4e003e786df99eee76"},
+	}
+	for _, test := range []struct {
+		what       string
+		fdst, fsrc fs.Fs
+	}{
+		{"destination", duplicateFs{fremote}, flocal},
+		{"source", fremote, duplicateFs{flocal}},
+	} {
+		fs.Stats.ResetCounters()
+		err = fs.Sync(context.Background(), test.fdst, test.fsrc)
+		if err != nil {
+			t.Fatalf("Sync with duplicates in %s failed: %v", test.what, err)
+		}
+		if transfers := fs.Stats.GetTransfers(); transfers != 0 {
+			t.Errorf("Sync with duplicates in %s: expecting no transfers but got %d", test.what, transfers)
+		}
+		fstest.CheckListingWithPrecision(t, flocal, items, fs.Config.ModifyWindow)
+		fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+	}
+
+	// Tidy up for the following tests
+	err = os.RemoveAll(localName + "/dup dir")
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	err = fs.Sync(context.Background(), fremote, flocal)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	items = items[1:]
+	fstest.CheckListingWithPrecision(t, flocal, items, fs.Config.ModifyWindow)
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+}
+
+// Test that empty directories and their modification times are synced
+func TestSyncEmptyDirectories(t *testing.T) {
+	features := fremote.Features()
+	if features.MakeDir == nil || features.ListDirEntries == nil {
+		t.Skip("Remote can't make and list directories")
+	}
+	err := os.MkdirAll(localName+"/empty dir/sub", 0770)
+	if err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	err = os.Chtimes(localName+"/empty dir", t1, t1)
+	if err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	err = fs.Sync(context.Background(), fremote, flocal)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	_, dirs, err := features.ListDirEntries(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ListDirEntries failed: %v", err)
+	}
+	var emptyDir *fs.Dir
+	for _, dir := range dirs {
+		if dir.Name == "empty dir" {
+			emptyDir = dir
+		}
+	}
+	if emptyDir == nil {
+		t.Fatalf("Didn't find %q in %v", "empty dir", dirs)
+	}
+	if features.SetDirModTime != nil {
+		if dt, ok := fstest.CheckTimeEqualWithPrecision(emptyDir.When, t1, fs.Config.ModifyWindow); !ok {
+			t.Errorf("Modification time difference too big |%s| > %s (%s vs %s)", dt, fs.Config.ModifyWindow, emptyDir.When, t1)
+		}
+	}
+	_, dirs, err = features.ListDirEntries(context.Background(), "empty dir")
+	if err != nil {
+		t.Fatalf("ListDirEntries failed: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0].Name != "empty dir/sub" {
+		t.Errorf("Expecting %q but got %v", "empty dir/sub", dirs)
+	}
+
+	err = os.RemoveAll(localName + "/empty dir")
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	err = fs.Sync(context.Background(), fremote, flocal)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	_, dirs, err = features.ListDirEntries(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ListDirEntries failed: %v", err)
+	}
+	for _, dir := range dirs {
+		if dir.Name == "empty dir" {
+			t.Errorf("Expecting %q to be removed", "empty dir")
+		}
+	}
+}
+
 // Test with exclude
 func TestSyncWithExclude(t *testing.T) {
 	WriteFile("enormous", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", t1) // 100 bytes
@@ -421,7 +653,7 @@ func TestSyncWithExclude(t *testing.T) {
 	defer func() {
 		fs.Config.Filter.MaxSize = 0
 	}()
-	err := fs.Sync(fremote, flocal)
+	err := fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -436,7 +668,7 @@ func TestSyncWithExclude(t *testing.T) {
 	items = append(items, fstest.Item{
 		Path: "enormous", Size: 100, ModTime: t1, Md5sum: "8adc5937e635f6c9af646f0b23560fae",
 	})
-	err = fs.Sync(flocal, fremote)
+	err = fs.Sync(context.Background(), flocal, fremote)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -452,7 +684,7 @@ func TestSyncWithExcludeAndDeleleteExcluded(t *testing.T) {
 		fs.Config.Filter.DeleteExcluded = false
 	}
 	defer reset()
-	err := fs.Sync(fremote, flocal)
+	err := fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -463,7 +695,7 @@ func TestSyncWithExcludeAndDeleleteExcluded(t *testing.T) {
 
 	// Check sync the other way round to make sure enormous gets
 	// deleted even though it is excluded
-	err = fs.Sync(flocal, fremote)
+	err = fs.Sync(context.Background(), flocal, fremote)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -472,7 +704,7 @@ func TestSyncWithExcludeAndDeleleteExcluded(t *testing.T) {
 	// Tidy up - put potato2 back!
 	reset()
 	WriteFile("potato2", "------------------------------------------------------------", t1)
-	err = fs.Sync(fremote, flocal)
+	err = fs.Sync(context.Background(), fremote, flocal)
 	if err != nil {
 		t.Fatalf("Sync failed: %v", err)
 	}
@@ -493,23 +725,23 @@ func TestServerSideMove(t *testing.T) {
 	t.Logf("Server side move (if possible) %v -> %v", fremote, fremoteMove)
 
 	// Start with a copy
-	err = fs.CopyDir(fremoteMove, fremote)
+	err = fs.CopyDir(context.Background(), fremoteMove, fremote)
 	if err != nil {
 		t.Fatalf("Server Side Copy failed: %v", err)
 	}
 
 	// Remove one file
-	obj := fremoteMove.NewFsObject("potato2")
+	obj := fremoteMove.NewFsObject(context.Background(), "potato2")
 	if obj == nil {
 		t.Fatalf("Failed to find potato2")
 	}
-	err = obj.Remove()
+	err = obj.Remove(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to remove object: %v", err)
 	}
 
 	// Do server side move
-	err = fs.MoveDir(fremoteMove, fremote)
+	err = fs.MoveDir(context.Background(), fremoteMove, fremote)
 	if err != nil {
 		t.Fatalf("Server Side Move failed: %v", err)
 	}
@@ -523,7 +755,7 @@ func TestServerSideMove(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, fremoteMove, items, fs.Config.ModifyWindow)
 
 	// Move it back again, dst does not exist this time
-	err = fs.MoveDir(fremote, fremoteMove)
+	err = fs.MoveDir(context.Background(), fremote, fremoteMove)
 	if err != nil {
 		t.Fatalf("Server Side Move 2 failed: %v", err)
 	}
@@ -532,9 +764,63 @@ func TestServerSideMove(t *testing.T) {
 	fstest.CheckListingWithPrecision(t, fremoteMove, items[:0], fs.Config.ModifyWindow)
 }
 
+// copierFs can copy but not move, like a bucket based remote
+type copierFs struct {
+	fs.Fs
+	copies *int
+}
+
+// Features returns only Copy so moves must use it
+func (f copierFs) Features() *fs.Features {
+	return &fs.Features{Copy: f.Copy}
+}
+
+// Copy src to remote, counting the copies done
+func (f copierFs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	*f.copies++
+	in, err := src.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	return f.Fs.Put(ctx, in, remote, src.ModTime(ctx), src.Size())
+}
+
+// A move on a remote which can't move but can copy should be a
+// server side copy then a delete
+func TestMoveWithCopier(t *testing.T) {
+	fdst := copierFs{Fs: fremote, copies: new(int)}
+	src := fremote.NewFsObject(context.Background(), "potato2")
+	if src == nil {
+		t.Fatalf("Failed to find potato2")
+	}
+	err := fs.Move(context.Background(), fdst, nil, "potato3", src)
+	if err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if *fdst.copies != 1 {
+		t.Errorf("Expecting 1 server side copy but got %d", *fdst.copies)
+	}
+	items := []fstest.Item{
+		{Path: "empty space", Size: 0, ModTime: t2, Md5sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "potato3", Size: 60, ModTime: t1, Md5sum: "d6548b156ea68a4e003e786df99eee76"},
+	}
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+
+	// Move it back for the following tests
+	err = fs.Move(context.Background(), fdst, nil, "potato2", fremote.NewFsObject(context.Background(), "potato3"))
+	if err != nil {
+		t.Fatalf("Move back failed: %v", err)
+	}
+	items[1].Path = "potato2"
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+}
+
 func TestLs(t *testing.T) {
 	var buf bytes.Buffer
-	err := fs.List(fremote, &buf)
+	err := fs.List(context.Background(), fremote, &buf)
 	if err != nil {
 		t.Fatalf("List failed: %v", err)
 	}
@@ -549,7 +835,7 @@ func TestLs(t *testing.T) {
 
 func TestLsLong(t *testing.T) {
 	var buf bytes.Buffer
-	err := fs.ListLong(fremote, &buf)
+	err := fs.ListLong(context.Background(), fremote, &buf)
 	if err != nil {
 		t.Fatalf("List failed: %v", err)
 	}
@@ -589,9 +875,72 @@ func TestLsLong(t *testing.T) {
 	}
 }
 
+func TestListJSON(t *testing.T) {
+	in := ioutil.NopCloser(strings.NewReader("hello"))
+	obj, err := fs.Rcat(context.Background(), fremote, "lsjson dir/file.txt", in, t1)
+	if err != nil {
+		t.Fatalf("Rcat failed: %v", err)
+	}
+	listJSON := func(recurse, showHash bool) map[string]fs.ListJSONItem {
+		var buf bytes.Buffer
+		err := fs.ListJSON(context.Background(), fremote, &buf, recurse, showHash)
+		if err != nil {
+			t.Fatalf("ListJSON failed: %v", err)
+		}
+		var items []fs.ListJSONItem
+		err = json.Unmarshal(buf.Bytes(), &items)
+		if err != nil {
+			t.Fatalf("Bad JSON %q: %v", buf.String(), err)
+		}
+		found := make(map[string]fs.ListJSONItem)
+		for _, item := range items {
+			found[item.Path] = item
+		}
+		return found
+	}
+
+	found := listJSON(false, false)
+	if item, ok := found["lsjson dir"]; !ok || !item.IsDir || item.Name != "lsjson dir" {
+		t.Errorf("Bad or missing directory: %+v", item)
+	}
+	if item, ok := found["potato2"]; !ok || item.IsDir || item.Size != 60 || item.Name != "potato2" {
+		t.Errorf("Bad or missing potato2: %+v", item)
+	} else if item.Hashes != nil {
+		t.Errorf("Unexpected hashes without --hash: %v", item.Hashes)
+	}
+	if _, ok := found["lsjson dir/file.txt"]; ok {
+		t.Errorf("Found file in sub directory without recursion")
+	}
+
+	found = listJSON(false, true)
+	if fremote.Hashes().Contains(fs.HashMD5) {
+		if md5sum := found["potato2"].Hashes[fs.HashMD5.String()]; md5sum != "d6548b156ea68a4e003e786df99eee76" {
+			t.Errorf("Bad or missing MD5 for potato2 with hashes: %q", md5sum)
+		}
+	}
+
+	found = listJSON(true, false)
+	if item, ok := found["lsjson dir/file.txt"]; !ok || item.Name != "file.txt" || item.Size != 5 || item.MimeType != "text/plain; charset=utf-8" {
+		t.Errorf("Bad or missing file in sub directory: %+v", item)
+	} else {
+		modTime, err := time.Parse(time.RFC3339Nano, item.ModTime)
+		if err != nil {
+			t.Errorf("Bad modification time %q: %v", item.ModTime, err)
+		} else if dt, ok := fstest.CheckTimeEqualWithPrecision(modTime, t1, fremote.Precision()); !ok {
+			t.Errorf("Modification time difference too big |%s| > %s", dt, fremote.Precision())
+		}
+	}
+
+	// Tidy up for the following tests
+	err = obj.Remove(context.Background())
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+}
+
 func TestMd5sum(t *testing.T) {
 	var buf bytes.Buffer
-	err := fs.Md5sum(fremote, &buf)
+	err := fs.Md5sum(context.Background(), fremote, &buf)
 	if err != nil {
 		t.Fatalf("List failed: %v", err)
 	}
@@ -606,8 +955,158 @@ func TestMd5sum(t *testing.T) {
 	}
 }
 
+func TestHashLister(t *testing.T) {
+	var buf bytes.Buffer
+	err := fs.HashLister(context.Background(), fs.HashSHA1, true, fremote, &buf)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	res := buf.String()
+	if !strings.Contains(res, "da39a3ee5e6b4b0d3255bfef95601890afd80709  empty space\n") {
+		t.Errorf("empty space missing: %q", res)
+	}
+	if !strings.Contains(res, "9dc7f7d3279715991a22853f5981df582b7f9f6d  potato2\n") {
+		t.Errorf("potato2 missing: %q", res)
+	}
+}
+
+func TestCheckSum(t *testing.T) {
+	defer fs.Stats.ResetErrors()
+	sums := "" +
+		"da39a3ee5e6b4b0d3255bfef95601890afd80709  empty space\n" +
+		"0000000000000000000000000000000000000000  potato2\n" +
+		"9dc7f7d3279715991a22853f5981df582b7f9f6d  not there\n"
+	for _, oneWay := range []bool{false, true} {
+		var match, differ, missingOnSrc, missingOnDst, errored bytes.Buffer
+		opt := &fs.CheckOpt{
+			OneWay:       oneWay,
+			Download:     true,
+			Match:        &match,
+			Differ:       &differ,
+			MissingOnSrc: &missingOnSrc,
+			MissingOnDst: &missingOnDst,
+			Error:        &errored,
+		}
+		err := fs.CheckSum(context.Background(), fremote, fs.HashSHA1, strings.NewReader(sums), opt)
+		if err == nil {
+			t.Errorf("one-way %v: expecting differences to be reported", oneWay)
+		}
+		for _, test := range []struct {
+			name string
+			got  string
+			want string
+		}{
+			{"match", match.String(), "empty space\n"},
+			{"differ", differ.String(), "potato2\n"},
+			{"missing on src", missingOnSrc.String(), ""},
+			{"missing on dst", missingOnDst.String(), "not there\n"},
+			{"error", errored.String(), ""},
+		} {
+			if test.got != test.want {
+				t.Errorf("one-way %v: %s: want %q got %q", oneWay, test.name, test.want, test.got)
+			}
+		}
+	}
+
+	sums = "" +
+		"da39a3ee5e6b4b0d3255bfef95601890afd80709  empty space\n" +
+		"9dc7f7d3279715991a22853f5981df582b7f9f6d  potato2\n"
+	err := fs.CheckSum(context.Background(), fremote, fs.HashSHA1, strings.NewReader(sums), &fs.CheckOpt{Download: true})
+	if err != nil {
+		t.Errorf("CheckSum of matching sums failed: %v", err)
+	}
+}
+
+func TestCat(t *testing.T) {
+	in := ioutil.NopCloser(strings.NewReader("0123456789"))
+	obj, err := fs.Rcat(context.Background(), fremote, "cat file", in, t1)
+	if err != nil {
+		t.Fatalf("Rcat failed: %v", err)
+	}
+	f := fs.NewLimited(fremote, obj)
+	for _, test := range []struct {
+		offset int64
+		count  int64
+		want   string
+	}{
+		{0, 0, "0123456789"},
+		{3, 0, "3456789"},
+		{0, 4, "0123"},
+		{2, 3, "234"},
+		{-3, 0, "789"},
+		{-5, 2, "56"},
+		{10, 0, ""},
+		{20, 5, ""},
+	} {
+		var buf bytes.Buffer
+		err = fs.Cat(context.Background(), f, &buf, test.offset, test.count)
+		if err != nil {
+			t.Fatalf("Cat failed: %v", err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("offset %d count %d: want %q got %q", test.offset, test.count, test.want, got)
+		}
+	}
+
+	// Tidy up for the following tests
+	err = obj.Remove(context.Background())
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+}
+
+// noRangeObject fails to open if given any options, like a remote
+// which rejects a Range on an empty object
+type noRangeObject struct {
+	fs.Object
+}
+
+// Open the object, failing if there are any options
+func (o noRangeObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	if len(options) != 0 {
+		return nil, errors.New("unexpected options")
+	}
+	return o.Object.Open(ctx)
+}
+
+func TestCatEmpty(t *testing.T) {
+	in := ioutil.NopCloser(strings.NewReader(""))
+	obj, err := fs.Rcat(context.Background(), fremote, "cat empty", in, t1)
+	if err != nil {
+		t.Fatalf("Rcat failed: %v", err)
+	}
+	f := fs.NewLimited(fremote, noRangeObject{obj})
+	for _, test := range []struct {
+		offset int64
+		count  int64
+	}{
+		{0, 4},
+		{3, 0},
+		{-3, 0},
+	} {
+		fs.Stats.ResetCounters()
+		var buf bytes.Buffer
+		err = fs.Cat(context.Background(), f, &buf, test.offset, test.count)
+		if err != nil {
+			t.Fatalf("Cat failed: %v", err)
+		}
+		if n := fs.Stats.GetErrors(); n != 0 {
+			t.Errorf("offset %d count %d: expecting no errors but got %d", test.offset, test.count, n)
+		}
+		if got := buf.String(); got != "" {
+			t.Errorf("offset %d count %d: want empty got %q", test.offset, test.count, got)
+		}
+	}
+
+	// Tidy up for the following tests
+	err = obj.Remove(context.Background())
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+}
+
 func TestCount(t *testing.T) {
-	objects, size, err := fs.Count(fremote)
+	objects, size, err := fs.Count(context.Background(), fremote)
 	if err != nil {
 		t.Fatalf("Count failed: %v", err)
 	}
@@ -619,8 +1118,339 @@ func TestCount(t *testing.T) {
 	}
 }
 
+func TestTreeAndDu(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-tree")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	for name, contents := range map[string]string{"a/one": "one", "a/b/two": "two!!", "three": "0123456789"} {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(name), 0700)
+		if err != nil {
+			t.Fatalf("Failed to make dir: %v", err)
+		}
+		err = ioutil.WriteFile(name, []byte(contents), 0600)
+		if err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	f, err := fs.NewFs(dir)
+	if err != nil {
+		t.Fatalf("Failed to make %q: %v", dir, err)
+	}
+
+	var buf bytes.Buffer
+	err = fs.Tree(context.Background(), f, &buf, -1, true)
+	if err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	lines := strings.SplitN(buf.String(), "\n", 2)
+	want := "" +
+		"|-- [           8]  a/ (2 files)\n" +
+		"|   |-- [           5]  b/ (1 files)\n" +
+		"|   |   `-- [           5]  two\n" +
+		"|   `-- [           3]  one\n" +
+		"`-- [          10]  three\n" +
+		"\n" +
+		"2 directories, 3 files\n"
+	if !strings.HasPrefix(lines[0], "[          18]  ") || lines[1] != want {
+		t.Errorf("Tree: want %q got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	err = fs.Tree(context.Background(), f, &buf, 1, false)
+	if err != nil {
+		t.Fatalf("Tree failed: %v", err)
+	}
+	lines = strings.SplitN(buf.String(), "\n", 2)
+	want = "" +
+		"|-- [           8]  a/ (2 files)\n" +
+		"`-- [          10]  three\n" +
+		"\n" +
+		"1 directories, 1 files\n"
+	if lines[1] != want {
+		t.Errorf("Tree with max depth: want %q got %q", want, buf.String())
+	}
+
+	for _, test := range []struct {
+		maxDepth int
+		want     string
+	}{
+		{-1, "           5         1 a/b\n           8         2 a\n          18         3 .\n"},
+		{1, "           8         2 a\n          18         3 .\n"},
+		{0, "          18         3 .\n"},
+	} {
+		buf.Reset()
+		err = fs.Du(context.Background(), f, &buf, test.maxDepth, false)
+		if err != nil {
+			t.Fatalf("Du failed: %v", err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("Du max depth %d: want %q got %q", test.maxDepth, test.want, got)
+		}
+	}
+
+	// Only the objects which can be shown are kept
+	root, err := fs.NewDirTree(context.Background(), f, 1, true, false)
+	if err != nil {
+		t.Fatalf("NewDirTree failed: %v", err)
+	}
+	if len(root.Objects) != 1 || root.Objects[0].Remote() != "three" {
+		t.Errorf("NewDirTree: want just three in root got %v", root.Objects)
+	}
+	if len(root.Dirs) != 1 || root.Dirs[0].Count != 2 || root.Dirs[0].Bytes != 8 {
+		t.Fatalf("NewDirTree: bad directories %+v", root.Dirs)
+	}
+	if a := root.Dirs[0]; a.Objects != nil || a.Dirs != nil {
+		t.Errorf("NewDirTree: want nothing kept below max depth got %+v", a)
+	}
+	root, err = fs.NewDirTree(context.Background(), f, -1, false, false)
+	if err != nil {
+		t.Fatalf("NewDirTree failed: %v", err)
+	}
+	if root.Count != 3 || root.Objects != nil {
+		t.Errorf("NewDirTree: want 3 objects counted but none kept got %+v", root)
+	}
+}
+
+func TestAbout(t *testing.T) {
+	if flocal.Features().About == nil {
+		t.Skip("local has no About on this OS")
+	}
+	var buf bytes.Buffer
+	err := fs.About(context.Background(), flocal, &buf, false)
+	if err != nil {
+		t.Fatalf("About failed: %v", err)
+	}
+	res := buf.String()
+	for _, want := range []string{"Total:", "Used:", "Free:"} {
+		if !strings.Contains(res, want) {
+			t.Errorf("%s missing: %q", want, res)
+		}
+	}
+
+	buf.Reset()
+	err = fs.About(context.Background(), flocal, &buf, true)
+	if err != nil {
+		t.Fatalf("About failed: %v", err)
+	}
+	var usage fs.Usage
+	err = json.Unmarshal(buf.Bytes(), &usage)
+	if err != nil {
+		t.Fatalf("Bad JSON %q: %v", buf.String(), err)
+	}
+	if usage.Total == nil || usage.Used == nil || usage.Free == nil || usage.Trashed != nil {
+		t.Errorf("Wrong values in %q", buf.String())
+	}
+}
+
 func TestCheck(t *testing.T) {
-	// FIXME
+	makeFs := func(files map[string]string) fs.Fs {
+		dir, err := ioutil.TempDir("", "rclone-check")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		for name, contents := range files {
+			err = ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600)
+			if err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+		}
+		f, err := fs.NewFs(dir)
+		if err != nil {
+			t.Fatalf("Failed to make %q: %v", dir, err)
+		}
+		return f
+	}
+	fsrc := makeFs(map[string]string{"same": "hello", "differ": "hello", "only src": "x"})
+	defer os.RemoveAll(fsrc.Root())
+	fdst := makeFs(map[string]string{"same": "hello", "differ": "world", "only dst": "y"})
+	defer os.RemoveAll(fdst.Root())
+	defer fs.Stats.ResetErrors()
+
+	for _, oneWay := range []bool{false, true} {
+		var match, differ, missingOnSrc, missingOnDst, errored bytes.Buffer
+		opt := &fs.CheckOpt{
+			OneWay:       oneWay,
+			Match:        &match,
+			Differ:       &differ,
+			MissingOnSrc: &missingOnSrc,
+			MissingOnDst: &missingOnDst,
+			Error:        &errored,
+		}
+		err := fs.Check(context.Background(), fdst, fsrc, opt)
+		if err == nil {
+			t.Errorf("one-way %v: expecting differences to be reported", oneWay)
+		}
+		wantMissingOnSrc := "only dst\n"
+		if oneWay {
+			wantMissingOnSrc = ""
+		}
+		for _, test := range []struct {
+			name string
+			got  string
+			want string
+		}{
+			{"match", match.String(), "same\n"},
+			{"differ", differ.String(), "differ\n"},
+			{"missing on src", missingOnSrc.String(), wantMissingOnSrc},
+			{"missing on dst", missingOnDst.String(), "only src\n"},
+			{"error", errored.String(), ""},
+		} {
+			if test.got != test.want {
+				t.Errorf("one-way %v: %s: want %q got %q", oneWay, test.name, test.want, test.got)
+			}
+		}
+	}
+
+	err := fs.Check(context.Background(), fsrc, fsrc, &fs.CheckOpt{Download: true})
+	if err != nil {
+		t.Errorf("Check of identical remotes failed: %v", err)
+	}
+
+	// nil options are the defaults
+	err = fs.Check(context.Background(), fsrc, fsrc, nil)
+	if err != nil {
+		t.Errorf("Check with nil options failed: %v", err)
+	}
+}
+
+func TestRcat(t *testing.T) {
+	const contents = "a stream of unknown length"
+	in := ioutil.NopCloser(strings.NewReader(contents))
+	dst, err := fs.Rcat(context.Background(), fremote, "rcat file", in, t2)
+	if err != nil {
+		t.Fatalf("Rcat failed: %v", err)
+	}
+	if dst.Size() != int64(len(contents)) {
+		t.Errorf("want size %d got %d", len(contents), dst.Size())
+	}
+
+	items := []fstest.Item{
+		{Path: "empty space", Size: 0, ModTime: t2, Md5sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "potato2", Size: 60, ModTime: t1, Md5sum: "d6548b156ea68a4e003e786df99eee76"},
+		{Path: "rcat file", Size: int64(len(contents)), ModTime: t2, Md5sum: "f5b78850d4a933619d1a9ed0b83cb808"},
+	}
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+
+	// Tidy up for the following tests
+	err = dst.Remove(context.Background())
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	WriteFile("file1", "file1 contents", t1)
+	err := fs.CopyFile(context.Background(), fremote, flocal, "sub/file2", "file1")
+	if err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+	items := []fstest.Item{
+		{Path: "empty space", Size: 0, ModTime: t2, Md5sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "potato2", Size: 60, ModTime: t1, Md5sum: "d6548b156ea68a4e003e786df99eee76"},
+		{Path: "sub/file2", Size: 14, ModTime: t1, Md5sum: "0ef726ce9b1a7692357ff70dd321d595"},
+	}
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+	if flocal.NewFsObject(context.Background(), "file1") == nil {
+		t.Errorf("Source file1 missing after copy")
+	}
+
+	// Copying again should find the file unchanged
+	err = fs.CopyFile(context.Background(), fremote, flocal, "sub/file2", "file1")
+	if err != nil {
+		t.Fatalf("CopyFile 2 failed: %v", err)
+	}
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+
+	// Tidy up for the following tests
+	err = fremote.NewFsObject(context.Background(), "sub/file2").Remove(context.Background())
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	err = os.Remove(localName + "/file1")
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	WriteFile("file1", "file1 contents", t1)
+	err := fs.MoveFile(context.Background(), fremote, flocal, "sub/file2", "file1")
+	if err != nil {
+		t.Fatalf("MoveFile failed: %v", err)
+	}
+	items := []fstest.Item{
+		{Path: "empty space", Size: 0, ModTime: t2, Md5sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "potato2", Size: 60, ModTime: t1, Md5sum: "d6548b156ea68a4e003e786df99eee76"},
+		{Path: "sub/file2", Size: 14, ModTime: t1, Md5sum: "0ef726ce9b1a7692357ff70dd321d595"},
+	}
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+	if flocal.NewFsObject(context.Background(), "file1") != nil {
+		t.Errorf("Source file1 still present after move")
+	}
+
+	// Rename it within the remote
+	err = fs.MoveFile(context.Background(), fremote, fremote, "file3", "sub/file2")
+	if err != nil {
+		t.Fatalf("MoveFile 2 failed: %v", err)
+	}
+	items[2].Path = "file3"
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+
+	// Moving a file to itself is an error
+	err = fs.MoveFile(context.Background(), fremote, fremote, "file3", "file3")
+	if err == nil {
+		t.Errorf("Expecting error moving file to itself")
+	}
+
+	// Tidy up for the following tests
+	err = fremote.NewFsObject(context.Background(), "file3").Remove(context.Background())
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	WriteFile("small file", "small", t1)
+	err := fs.CopyFile(context.Background(), fremote, flocal, "sub/small file", "small file")
+	if err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+	fs.Config.Filter.MinSize = 1
+	fs.Config.Filter.MaxSize = 10
+	defer func() {
+		fs.Config.Filter.MinSize = 0
+		fs.Config.Filter.MaxSize = 0
+	}()
+	items := []fstest.Item{
+		{Path: "empty space", Size: 0, ModTime: t2, Md5sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "potato2", Size: 60, ModTime: t1, Md5sum: "d6548b156ea68a4e003e786df99eee76"},
+		{Path: "sub/small file", Size: 5, ModTime: t1, Md5sum: "eb5c1399a871211c7e7ed732d15e3a8b"},
+	}
+
+	// Nothing should be deleted with --dry-run
+	fs.Config.DryRun = true
+	err = fs.Delete(context.Background(), fremote)
+	fs.Config.DryRun = false
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	fstest.CheckListingWithPrecision(t, fremote, items, fs.Config.ModifyWindow)
+
+	// Only the file within the size limits should be deleted
+	err = fs.Delete(context.Background(), fremote)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	fstest.CheckListingWithPrecision(t, fremote, items[:2], fs.Config.ModifyWindow)
+
+	// Tidy up for the following tests
+	err = os.Remove(localName + "/small file")
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
 }
 
 // Clean the temporary directory
@@ -0,0 +1,221 @@
+// Define the options for Open
+
+package fs
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// OpenOption is an interface describing options for Open
+type OpenOption interface {
+	fmt.Stringer
+
+	// Header returns the option as an HTTP header
+	Header() (key string, value string)
+
+	// Mandatory returns whether this option can be ignored or not
+	Mandatory() bool
+}
+
+// SeekOption defines an HTTP Range option with start only.
+//
+// Reading starts at Offset bytes into the object and continues to
+// the end.
+type SeekOption struct {
+	Offset int64
+}
+
+// Header formats the option as an http header
+func (o *SeekOption) Header() (key string, value string) {
+	key = "Range"
+	value = fmt.Sprintf("bytes=%d-", o.Offset)
+	return key, value
+}
+
+// String formats the option into human readable form
+func (o *SeekOption) String() string {
+	return fmt.Sprintf("SeekOption(%d)", o.Offset)
+}
+
+// Mandatory returns whether the option must be parsed or can be ignored
+func (o *SeekOption) Mandatory() bool {
+	return true
+}
+
+// RangeOption defines an HTTP Range option with start and end.
+//
+// Start and End are inclusive byte offsets as in the HTTP Range
+// header.  If End is < 0 then the range runs to the end of the
+// object.  If Start is < 0 then the last End bytes of the object are
+// read.
+type RangeOption struct {
+	Start int64
+	End   int64
+}
+
+// Header formats the option as an http header
+func (o *RangeOption) Header() (key string, value string) {
+	key = "Range"
+	value = "bytes="
+	if o.Start >= 0 {
+		value += fmt.Sprint(o.Start)
+	}
+	value += "-"
+	if o.End >= 0 {
+		value += fmt.Sprint(o.End)
+	}
+	return key, value
+}
+
+// String formats the option into human readable form
+func (o *RangeOption) String() string {
+	return fmt.Sprintf("RangeOption(%d,%d)", o.Start, o.End)
+}
+
+// Mandatory returns whether the option must be parsed or can be ignored
+func (o *RangeOption) Mandatory() bool {
+	return true
+}
+
+// Decode interprets the RangeOption into an offset and a limit for
+// an object of the size given
+//
+// The offset is the start of the stream and the limit is how many
+// bytes should be read from it.  If the limit is -1 then the stream
+// should be read to the end.
+func (o *RangeOption) Decode(size int64) (offset, limit int64) {
+	if o.Start >= 0 {
+		offset = o.Start
+		if o.End >= 0 {
+			limit = o.End - o.Start + 1
+			if limit < 0 {
+				limit = 0
+			}
+		} else {
+			limit = -1
+		}
+	} else {
+		if o.End >= 0 {
+			offset = size - o.End
+		} else {
+			offset = 0
+		}
+		limit = -1
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset, limit
+}
+
+// OpenOptionsDecode works out the offset and limit of the read
+// described by options for an object of the size given
+//
+// If the limit is -1 then the stream should be read to the end.  It
+// returns an error if a mandatory option isn't understood.
+func OpenOptionsDecode(size int64, options ...OpenOption) (offset, limit int64, err error) {
+	limit = -1
+	for _, option := range options {
+		switch x := option.(type) {
+		case *SeekOption:
+			offset, limit = x.Offset, -1
+		case *RangeOption:
+			offset, limit = x.Decode(size)
+		default:
+			if option.Mandatory() {
+				return 0, -1, fmt.Errorf("Unsupported mandatory option: %v", option)
+			}
+		}
+	}
+	return offset, limit, nil
+}
+
+// OpenOptionHeaders adds the headers for the options to headers,
+// creating it if it is nil, and returns it
+func OpenOptionHeaders(headers map[string]string, options ...OpenOption) map[string]string {
+	for _, option := range options {
+		key, value := option.Header()
+		if key == "" || value == "" {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+// OpenOptionAddHTTPHeaders sets the headers for the options on the
+// http.Header passed in
+func OpenOptionAddHTTPHeaders(headers http.Header, options ...OpenOption) {
+	for key, value := range OpenOptionHeaders(nil, options...) {
+		headers.Set(key, value)
+	}
+}
+
+// limitedReadCloser adds a Close method to an io.LimitedReader
+type limitedReadCloser struct {
+	*io.LimitedReader
+	io.Closer
+}
+
+// NewLimitedReadCloser returns a ReadCloser which reads at most limit
+// bytes from in and closes in when closed.  If limit is < 0 then in
+// is returned unchanged.
+func NewLimitedReadCloser(in io.ReadCloser, limit int64) io.ReadCloser {
+	if limit < 0 {
+		return in
+	}
+	return &limitedReadCloser{
+		LimitedReader: &io.LimitedReader{R: in, N: limit},
+		Closer:        in,
+	}
+}
+
+// OpenOptionsReadCloser is the generic fallback for backends which
+// can't do ranged reads natively
+//
+// in should be a stream of the whole object of the size given.  It
+// skips ahead to the offset described by options and limits the
+// stream to the requested length.  If there are no options then in
+// is returned unchanged.
+func OpenOptionsReadCloser(in io.ReadCloser, size int64, options ...OpenOption) (io.ReadCloser, error) {
+	if len(options) == 0 {
+		return in, nil
+	}
+	offset, limit, err := OpenOptionsDecode(size, options...)
+	if err != nil {
+		_ = in.Close() // ignore error
+		return nil, err
+	}
+	if offset > 0 {
+		_, err = io.CopyN(ioutil.Discard, in, offset)
+		if err == io.EOF {
+			// Seeking beyond the end gives an empty stream
+			err = nil
+		}
+		if err != nil {
+			_ = in.Close() // ignore error
+			return nil, err
+		}
+	}
+	return NewLimitedReadCloser(in, limit), nil
+}
+
+// OpenOptionsResponse returns the body of res, the reply to a GET
+// made with the headers for options, as the part of an object of
+// the size given which options asked for
+//
+// Servers are allowed to ignore a Range header and reply with the
+// whole object, so if the reply isn't 206 Partial Content the part
+// wanted is read out of the body with OpenOptionsReadCloser.
+func OpenOptionsResponse(res *http.Response, size int64, options ...OpenOption) (io.ReadCloser, error) {
+	if res.StatusCode == http.StatusPartialContent {
+		return res.Body, nil
+	}
+	return OpenOptionsReadCloser(res.Body, size, options...)
+}
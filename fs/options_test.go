@@ -0,0 +1,94 @@
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestRangeOptionHeader(t *testing.T) {
+	for _, test := range []struct {
+		in   OpenOption
+		want string
+	}{
+		{&SeekOption{Offset: 0}, "bytes=0-"},
+		{&SeekOption{Offset: 7}, "bytes=7-"},
+		{&RangeOption{Start: 1, End: 5}, "bytes=1-5"},
+		{&RangeOption{Start: 1, End: -1}, "bytes=1-"},
+		{&RangeOption{Start: -1, End: 5}, "bytes=-5"},
+	} {
+		key, value := test.in.Header()
+		if key != "Range" || value != test.want {
+			t.Errorf("%v: want Range: %q got %s: %q", test.in, test.want, key, value)
+		}
+	}
+}
+
+func TestOpenOptionsReadCloser(t *testing.T) {
+	const contents = "0123456789"
+	size := int64(len(contents))
+	for _, test := range []struct {
+		options []OpenOption
+		want    string
+	}{
+		{nil, contents},
+		{[]OpenOption{&SeekOption{Offset: 3}}, "3456789"},
+		{[]OpenOption{&SeekOption{Offset: 20}}, ""},
+		{[]OpenOption{&RangeOption{Start: 2, End: 4}}, "234"},
+		{[]OpenOption{&RangeOption{Start: 8, End: 20}}, "89"},
+		{[]OpenOption{&RangeOption{Start: 5, End: -1}}, "56789"},
+		{[]OpenOption{&RangeOption{Start: -1, End: 3}}, "789"},
+		{[]OpenOption{&RangeOption{Start: -1, End: 30}}, contents},
+	} {
+		in := ioutil.NopCloser(bytes.NewBufferString(contents))
+		rc, err := OpenOptionsReadCloser(in, size, test.options...)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", test.options, err)
+		}
+		got, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("%v: read error: %v", test.options, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%v: want %q got %q", test.options, test.want, got)
+		}
+		err = rc.Close()
+		if err != nil {
+			t.Errorf("%v: close error: %v", test.options, err)
+		}
+	}
+}
+
+func TestOpenOptionsResponse(t *testing.T) {
+	const contents = "0123456789"
+	size := int64(len(contents))
+	for _, test := range []struct {
+		status  int
+		body    string
+		options []OpenOption
+		want    string
+	}{
+		{http.StatusOK, contents, nil, contents},
+		{http.StatusPartialContent, "234", []OpenOption{&RangeOption{Start: 2, End: 4}}, "234"},
+		// Range ignored by the server
+		{http.StatusOK, contents, []OpenOption{&RangeOption{Start: 2, End: 4}}, "234"},
+		{http.StatusOK, contents, []OpenOption{&SeekOption{Offset: 7}}, "789"},
+	} {
+		res := &http.Response{
+			StatusCode: test.status,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(test.body)),
+		}
+		rc, err := OpenOptionsResponse(res, size, test.options...)
+		if err != nil {
+			t.Fatalf("%d %v: unexpected error: %v", test.status, test.options, err)
+		}
+		got, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("%d %v: read error: %v", test.status, test.options, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%d %v: want %q got %q", test.status, test.options, test.want, got)
+		}
+	}
+}
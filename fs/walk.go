@@ -0,0 +1,143 @@
+// Read an Fs a directory at a time
+
+package fs
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// dirEntries holds the contents of a single directory
+type dirEntries struct {
+	objects Objects
+	dirs    []*Dir
+}
+
+// dirLister reads an Fs a directory at a time
+//
+// If the Fs has the ListDirEntries feature then each directory is
+// read from the remote when it is asked for.  If not then the whole
+// Fs is listed on the first call and split up into directories which
+// are handed out, and forgotten, one at a time.
+//
+// It isn't safe for concurrent use.
+type dirLister struct {
+	f       Fs
+	listDir func(ctx context.Context, dir string) (Objects, []*Dir, error) // nil if f can't list a directory
+	entries map[string]*dirEntries                                         // full listing if f can't
+}
+
+// newDirLister makes a dirLister for f
+func newDirLister(f Fs) *dirLister {
+	return &dirLister{
+		f:       f,
+		listDir: f.Features().ListDirEntries,
+	}
+}
+
+// List returns the objects and directories directly in dir sorted
+// by name
+//
+// Any entries with the same name are left in the order they were
+// listed.  A directory which doesn't exist is returned as empty.
+func (l *dirLister) List(ctx context.Context, dir string) (objects Objects, dirs []*Dir, err error) {
+	if l.listDir != nil {
+		objects, dirs, err = l.listDir(ctx, dir)
+		if err == ErrorDirNotFound {
+			err = nil
+		}
+	} else {
+		if l.entries == nil {
+			err = l.readAll(ctx)
+		}
+		if entries, ok := l.entries[dir]; ok {
+			objects, dirs = entries.objects, entries.dirs
+			delete(l.entries, dir)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Stable(objectsByRemote(objects))
+	sort.Stable(dirsByName(dirs))
+	return objects, dirs, nil
+}
+
+// ListDirSorted returns the objects and directories directly in dir,
+// which is relative to the root of f, sorted by name
+//
+// If f doesn't have the ListDirEntries feature then the whole of f is
+// listed to find them.  A directory which doesn't exist is returned
+// as empty.
+func ListDirSorted(ctx context.Context, f Fs, dir string) (objects Objects, dirs []*Dir, err error) {
+	return newDirLister(f).List(ctx, dir)
+}
+
+// readAll lists the whole of the Fs and splits it up into directories
+func (l *dirLister) readAll(ctx context.Context) error {
+	l.entries = make(map[string]*dirEntries)
+	list := NewLister().Start(ctx, l.f)
+	for {
+		o, err := list.Get()
+		if err != nil {
+			return err
+		}
+		if o == nil {
+			return nil
+		}
+		dir := parentDir(o.Remote())
+		entries, ok := l.entries[dir]
+		if !ok {
+			entries = &dirEntries{}
+			l.entries[dir] = entries
+			l.addDir(dir)
+		}
+		entries.objects = append(entries.objects, o)
+	}
+}
+
+// addDir adds the newly seen dir to its parent, and the parent to its
+// parent if that is new too, and so on up to the root
+func (l *dirLister) addDir(dir string) {
+	for dir != "" {
+		parent := parentDir(dir)
+		entries, ok := l.entries[parent]
+		if !ok {
+			entries = &dirEntries{}
+			l.entries[parent] = entries
+		}
+		entries.dirs = append(entries.dirs, &Dir{
+			Name:  dir,
+			Bytes: -1,
+			Count: -1,
+		})
+		if ok {
+			return
+		}
+		dir = parent
+	}
+}
+
+// parentDir returns the directory remote is in, "" being the root
+func parentDir(remote string) string {
+	i := strings.LastIndex(remote, "/")
+	if i < 0 {
+		return ""
+	}
+	return remote[:i]
+}
+
+// objectsByRemote sorts Objects by Remote()
+type objectsByRemote Objects
+
+func (os objectsByRemote) Len() int           { return len(os) }
+func (os objectsByRemote) Swap(i, j int)      { os[i], os[j] = os[j], os[i] }
+func (os objectsByRemote) Less(i, j int) bool { return os[i].Remote() < os[j].Remote() }
+
+// dirsByName sorts Dirs by Name
+type dirsByName []*Dir
+
+func (ds dirsByName) Len() int           { return len(ds) }
+func (ds dirsByName) Swap(i, j int)      { ds[i], ds[j] = ds[j], ds[i] }
+func (ds dirsByName) Less(i, j int) bool { return ds[i].Name < ds[j].Name }
@@ -4,6 +4,7 @@ package fstest
 // FIXME put name of test FS in Fs structure
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
 	"math/rand"
@@ -26,6 +27,7 @@ func init() {
 type Item struct {
 	Path    string
 	Md5sum  string
+	Hashes  map[fs.HashType]string
 	ModTime time.Time
 	Size    int64
 	WinPath string
@@ -49,23 +51,35 @@ func (i *Item) CheckModTime(t *testing.T, obj fs.Object, modTime time.Time, prec
 	}
 }
 
+// CheckHashes checks all the hashes the object's Fs supports against
+// the ones expected
+func (i *Item) CheckHashes(t *testing.T, obj fs.Object) {
+	for _, hashType := range obj.Fs().Hashes().Array() {
+		want := i.Hashes[hashType]
+		if hashType == fs.HashMD5 && want == "" {
+			want = i.Md5sum
+		}
+		sum, err := obj.Hash(context.Background(), hashType)
+		if err != nil {
+			t.Fatalf("%s: Failed to read %v hash: %v", obj.Remote(), hashType, err)
+		}
+		if !fs.HashEquals(want, sum) {
+			t.Errorf("%s: %v hash incorrect - expecting %q got %q", obj.Remote(), hashType, want, sum)
+		}
+	}
+}
+
 // Check checks all the attributes of the object are correct
 func (i *Item) Check(t *testing.T, obj fs.Object, precision time.Duration) {
 	if obj == nil {
 		t.Fatalf("Object is nil")
 	}
 	// Check attributes
-	Md5sum, err := obj.Md5sum()
-	if err != nil {
-		t.Fatalf("Failed to read md5sum for %q: %v", obj.Remote(), err)
-	}
-	if !fs.Md5sumsEqual(i.Md5sum, Md5sum) {
-		t.Errorf("%s: Md5sum incorrect - expecting %q got %q", obj.Remote(), i.Md5sum, Md5sum)
-	}
+	i.CheckHashes(t, obj)
 	if i.Size != obj.Size() {
 		t.Errorf("%s: Size incorrect - expecting %d got %d", obj.Remote(), i.Size, obj.Size())
 	}
-	i.CheckModTime(t, obj, obj.ModTime(), precision)
+	i.CheckModTime(t, obj, obj.ModTime(context.Background()), precision)
 }
 
 // Items represents all items for checking
@@ -119,13 +133,14 @@ func (is *Items) Done(t *testing.T) {
 // expected contents with the given precision.
 func CheckListingWithPrecision(t *testing.T, f fs.Fs, items []Item, precision time.Duration) {
 	is := NewItems(items)
-	oldErrors := fs.Stats.GetErrors()
 	var objs []fs.Object
+	var err error
 	const retries = 10
 	for i := 1; i <= retries; i++ {
-		objs = nil
-		for obj := range f.List() {
-			objs = append(objs, obj)
+		objs, err = fs.NewLister().Start(context.Background(), f).GetAll()
+		// An empty directory may not exist so may fail to list
+		if err != nil && len(items) != 0 {
+			t.Fatalf("Error listing: %v", err)
 		}
 		if len(objs) == len(items) {
 			break
@@ -141,10 +156,6 @@ func CheckListingWithPrecision(t *testing.T, f fs.Fs, items []Item, precision ti
 		is.Find(t, obj, precision)
 	}
 	is.Done(t)
-	// Don't notice an error when listing an empty directory
-	if len(items) == 0 && oldErrors == 0 && fs.Stats.GetErrors() == 1 {
-		fs.Stats.ResetErrors()
-	}
 }
 
 // CheckListing checks the fs to see if it has the expected contents
@@ -233,9 +244,9 @@ func RandomRemote(remoteName string, subdir bool) (fs.Fs, func(), error) {
 	}
 
 	finalise := func() {
-		_ = fs.Purge(remote) // ignore error
+		_ = fs.Purge(context.Background(), remote) // ignore error
 		if parentRemote != nil {
-			err = fs.Purge(parentRemote) // ignore error
+			err = fs.Purge(context.Background(), parentRemote) // ignore error
 			if err != nil {
 				log.Printf("Failed to purge %v: %v", parentRemote, err)
 			}
@@ -247,7 +258,7 @@ func RandomRemote(remoteName string, subdir bool) (fs.Fs, func(), error) {
 
 // TestMkdir tests Mkdir works
 func TestMkdir(t *testing.T, remote fs.Fs) {
-	err := fs.Mkdir(remote)
+	err := fs.Mkdir(context.Background(), remote)
 	if err != nil {
 		t.Fatalf("Mkdir failed: %v", err)
 	}
@@ -256,7 +267,7 @@ func TestMkdir(t *testing.T, remote fs.Fs) {
 
 // TestPurge tests Purge works
 func TestPurge(t *testing.T, remote fs.Fs) {
-	err := fs.Purge(remote)
+	err := fs.Purge(context.Background(), remote)
 	if err != nil {
 		t.Fatalf("Purge failed: %v", err)
 	}
@@ -265,7 +276,7 @@ func TestPurge(t *testing.T, remote fs.Fs) {
 
 // TestRmdir tests Rmdir works
 func TestRmdir(t *testing.T, remote fs.Fs) {
-	err := fs.Rmdir(remote)
+	err := fs.Rmdir(context.Background(), remote)
 	if err != nil {
 		t.Fatalf("Rmdir failed: %v", err)
 	}
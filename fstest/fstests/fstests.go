@@ -7,10 +7,10 @@ package fstests
 
 import (
 	"bytes"
-	"crypto/md5"
-	"encoding/hex"
+	"context"
 	"flag"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"strings"
@@ -38,8 +38,9 @@ var (
 		Path:    `hello? sausage/êé/Hello, 世界/ " ' @ < > & ?/z.txt`,
 		WinPath: `hello_ sausage/êé/Hello, 世界/ _ ' @ _ _ & _/z.txt`,
 	}
-	dumpHeaders = flag.Bool("dump-headers", false, "Dump HTTP headers - may contain sensitive info")
-	dumpBodies  = flag.Bool("dump-bodies", false, "Dump HTTP headers and bodies - may contain sensitive info")
+	file1Contents = ""
+	dumpHeaders   = flag.Bool("dump-headers", false, "Dump HTTP headers - may contain sensitive info")
+	dumpBodies    = flag.Bool("dump-bodies", false, "Dump HTTP headers and bodies - may contain sensitive info")
 )
 
 func init() {
@@ -101,7 +102,7 @@ func TestFsRmdirEmpty(t *testing.T) {
 // TestFsRmdirNotFound tests deleting a non existent directory
 func TestFsRmdirNotFound(t *testing.T) {
 	skipIfNotOk(t)
-	err := remote.Rmdir()
+	err := remote.Rmdir(context.Background())
 	if err == nil {
 		t.Fatalf("Expecting error on Rmdir non existent")
 	}
@@ -123,7 +124,7 @@ func TestFsListEmpty(t *testing.T) {
 // TestFsListDirEmpty tests listing the directories from an empty directory
 func TestFsListDirEmpty(t *testing.T) {
 	skipIfNotOk(t)
-	for obj := range remote.ListDir() {
+	for obj := range remote.ListDir(context.Background()) {
 		t.Errorf("Found unexpected item %q", obj.Name)
 	}
 }
@@ -131,7 +132,7 @@ func TestFsListDirEmpty(t *testing.T) {
 // TestFsNewFsObjectNotFound tests not finding a object
 func TestFsNewFsObjectNotFound(t *testing.T) {
 	skipIfNotOk(t)
-	if remote.NewFsObject("potato") != nil {
+	if remote.NewFsObject(context.Background(), "potato") != nil {
 		t.Fatal("Didn't expect to find object")
 	}
 }
@@ -140,7 +141,7 @@ func findObject(t *testing.T, Name string) fs.Object {
 	var obj fs.Object
 	const retries = 10
 	for i := 1; i <= retries; i++ {
-		obj = remote.NewFsObject(Name)
+		obj = remote.NewFsObject(context.Background(), Name)
 		if obj != nil {
 			break
 		}
@@ -153,27 +154,30 @@ func findObject(t *testing.T, Name string) fs.Object {
 	return obj
 }
 
-func testPut(t *testing.T, file *fstest.Item) {
-	buf := bytes.NewBufferString(fstest.RandomString(100))
-	hash := md5.New()
+func testPut(t *testing.T, file *fstest.Item) string {
+	contents := fstest.RandomString(100)
+	buf := bytes.NewBufferString(contents)
+	hash := fs.NewMultiHasher()
 	in := io.TeeReader(buf, hash)
 
 	file.Size = int64(buf.Len())
-	obj, err := remote.Put(in, file.Path, file.ModTime, file.Size)
+	obj, err := remote.Put(context.Background(), in, file.Path, file.ModTime, file.Size)
 	if err != nil {
 		t.Fatal("Put error", err)
 	}
-	file.Md5sum = hex.EncodeToString(hash.Sum(nil))
+	file.Hashes = hash.Sums()
+	file.Md5sum = file.Hashes[fs.HashMD5]
 	file.Check(t, obj, remote.Precision())
 	// Re-read the object and check again
 	obj = findObject(t, file.Path)
 	file.Check(t, obj, remote.Precision())
+	return contents
 }
 
 // TestFsPutFile1 tests putting a file
 func TestFsPutFile1(t *testing.T) {
 	skipIfNotOk(t)
-	testPut(t, &file1)
+	file1Contents = testPut(t, &file1)
 }
 
 // TestFsPutFile2 tests putting a file into a subdirectory
@@ -186,7 +190,7 @@ func TestFsPutFile2(t *testing.T) {
 func TestFsListDirFile2(t *testing.T) {
 	skipIfNotOk(t)
 	found := false
-	for obj := range remote.ListDir() {
+	for obj := range remote.ListDir(context.Background()) {
 		if obj.Name != `hello? sausage` && obj.Name != `hello_ sausage` {
 			t.Errorf("Found unexpected item %q", obj.Name)
 		} else {
@@ -206,7 +210,7 @@ func TestFsListDirRoot(t *testing.T) {
 		t.Fatalf("Failed to make remote %q: %v", RemoteName, err)
 	}
 	found := false
-	for obj := range rootRemote.ListDir() {
+	for obj := range rootRemote.ListDir(context.Background()) {
 		if obj.Name == subRemoteLeaf {
 			found = true
 		}
@@ -229,10 +233,9 @@ func TestFsListRoot(t *testing.T) {
 	found2 := false
 	f2 := subRemoteLeaf + "/" + file2.Path
 	f2Alt := subRemoteLeaf + "/" + file2.WinPath
-	count := 0
-	errors := fs.Stats.GetErrors()
-	for obj := range rootRemote.List() {
-		count++
+	objs, err := fs.NewLister().Start(context.Background(), rootRemote).GetAll()
+	count := len(objs)
+	for _, obj := range objs {
 		if obj.Remote() == f1 {
 			found1 = true
 		}
@@ -240,15 +243,14 @@ func TestFsListRoot(t *testing.T) {
 			found2 = true
 		}
 	}
-	errors -= fs.Stats.GetErrors()
 	if count == 0 {
-		if errors == 0 {
+		if err == nil {
 			t.Error("Expecting error if count==0")
 		}
 		return
 	}
 	if found1 && found2 {
-		if errors != 0 {
+		if err != nil {
 			t.Error("Not expecting error if found")
 		}
 		return
@@ -275,14 +277,65 @@ func TestFsListFile1and2(t *testing.T) {
 	fstest.CheckListing(t, remote, []fstest.Item{file1, file2})
 }
 
+// TestFsListDirEntries tests reading the remote a directory at a time
+func TestFsListDirEntries(t *testing.T) {
+	skipIfNotOk(t)
+
+	// Check have ListDirEntries
+	listDirEntries := remote.Features().ListDirEntries
+	if listDirEntries == nil {
+		t.Skip("FS has no ListDirEntries feature")
+	}
+
+	var found []string
+	var walk func(dir string)
+	walk = func(dir string) {
+		objs, dirs, err := listDirEntries(context.Background(), dir)
+		if err != nil {
+			t.Fatalf("ListDirEntries %q failed: %v", dir, err)
+		}
+		for _, obj := range objs {
+			remote := obj.Remote()
+			parent := ""
+			if i := strings.LastIndex(remote, "/"); i >= 0 {
+				parent = remote[:i]
+			}
+			if parent != dir {
+				t.Errorf("Listing %q returned %q from another directory", dir, remote)
+			}
+			found = append(found, remote)
+		}
+		for _, d := range dirs {
+			walk(d.Name)
+		}
+	}
+	walk("")
+	found1, found2 := false, false
+	for _, remote := range found {
+		found1 = found1 || remote == file1.Path
+		found2 = found2 || remote == file2.Path || remote == file2.WinPath
+	}
+	if len(found) != 2 || !found1 || !found2 {
+		t.Errorf("Expecting %q and %q but found %q", file1.Path, file2.Path, found)
+	}
+
+	objs, dirs, err := listDirEntries(context.Background(), "not found dir")
+	if err != nil && err != fs.ErrorDirNotFound {
+		t.Errorf("Expecting nil or ErrorDirNotFound but got: %v", err)
+	}
+	if len(objs) != 0 || len(dirs) != 0 {
+		t.Errorf("Expecting nothing in missing directory but got %v and %v", objs, dirs)
+	}
+}
+
 // TestFsCopy tests Copy
 func TestFsCopy(t *testing.T) {
 	skipIfNotOk(t)
 
 	// Check have Copy
-	_, ok := remote.(fs.Copier)
-	if !ok {
-		t.Skip("FS has no Copier interface")
+	doCopy := remote.Features().Copy
+	if doCopy == nil {
+		t.Skip("FS has no Copy feature")
 	}
 
 	var file1Copy = file1
@@ -290,7 +343,7 @@ func TestFsCopy(t *testing.T) {
 
 	// do the copy
 	src := findObject(t, file1.Path)
-	dst, err := remote.(fs.Copier).Copy(src, file1Copy.Path)
+	dst, err := doCopy(context.Background(), src, file1Copy.Path)
 	if err != nil {
 		t.Fatalf("Copy failed: %v (%#v)", err, err)
 	}
@@ -303,8 +356,11 @@ func TestFsCopy(t *testing.T) {
 		t.Errorf("object path: want %q got %q", file1Copy.Path, dst.Remote())
 	}
 
+	// Check the returned object has kept the modification time and hashes
+	file1Copy.Check(t, dst, remote.Precision())
+
 	// Delete copy
-	err = dst.Remove()
+	err = dst.Remove(context.Background())
 	if err != nil {
 		t.Fatal("Remove copy error", err)
 	}
@@ -316,9 +372,9 @@ func TestFsMove(t *testing.T) {
 	skipIfNotOk(t)
 
 	// Check have Move
-	_, ok := remote.(fs.Mover)
-	if !ok {
-		t.Skip("FS has no Mover interface")
+	doMove := remote.Features().Move
+	if doMove == nil {
+		t.Skip("FS has no Move feature")
 	}
 
 	var file1Move = file1
@@ -326,7 +382,7 @@ func TestFsMove(t *testing.T) {
 
 	// do the move
 	src := findObject(t, file1.Path)
-	dst, err := remote.(fs.Mover).Move(src, file1Move.Path)
+	dst, err := doMove(context.Background(), src, file1Move.Path)
 	if err != nil {
 		t.Fatalf("Move failed: %v", err)
 	}
@@ -341,7 +397,7 @@ func TestFsMove(t *testing.T) {
 
 	// move it back
 	src = findObject(t, file1Move.Path)
-	_, err = remote.(fs.Mover).Move(src, file1.Path)
+	_, err = doMove(context.Background(), src, file1.Path)
 	if err != nil {
 		t.Errorf("Move failed: %v", err)
 	}
@@ -363,13 +419,13 @@ func TestFsDirMove(t *testing.T) {
 	skipIfNotOk(t)
 
 	// Check have DirMove
-	_, ok := remote.(fs.DirMover)
-	if !ok {
-		t.Skip("FS has no DirMover interface")
+	doDirMove := remote.Features().DirMove
+	if doDirMove == nil {
+		t.Skip("FS has no DirMove feature")
 	}
 
 	// Check it can't move onto itself
-	err := remote.(fs.DirMover).DirMove(remote)
+	err := doDirMove(context.Background(), remote)
 	if err != fs.ErrorDirExists {
 		t.Errorf("Expecting fs.ErrorDirExists got: %v", err)
 	}
@@ -382,7 +438,7 @@ func TestFsDirMove(t *testing.T) {
 	defer removeNewRemote()
 
 	// try the move
-	err = newRemote.(fs.DirMover).DirMove(remote)
+	err = newRemote.Features().DirMove(context.Background(), remote)
 	if err != nil {
 		t.Errorf("Failed to DirMove: %v", err)
 	}
@@ -393,7 +449,7 @@ func TestFsDirMove(t *testing.T) {
 	fstest.CheckListing(t, newRemote, []fstest.Item{file2, file1})
 
 	// move it back
-	err = remote.(fs.DirMover).DirMove(newRemote)
+	err = doDirMove(context.Background(), newRemote)
 	if err != nil {
 		t.Errorf("Failed to DirMove: %v", err)
 	}
@@ -401,12 +457,35 @@ func TestFsDirMove(t *testing.T) {
 	// check remotes
 	fstest.CheckListing(t, remote, []fstest.Item{file2, file1})
 	fstest.CheckListing(t, newRemote, []fstest.Item{})
+
+	// new remote in a subdirectory which doesn't exist yet so
+	// the move changes the parent as well as the name
+	subRemote, removeSubRemote, err := fstest.RandomRemote(RemoteName, true)
+	if err != nil {
+		t.Fatalf("Failed to create remote: %v", err)
+	}
+	defer removeSubRemote()
+
+	err = subRemote.Features().DirMove(context.Background(), remote)
+	if err != nil {
+		t.Errorf("Failed to DirMove into subdirectory: %v", err)
+	}
+	fstest.CheckListing(t, remote, []fstest.Item{})
+	fstest.CheckListing(t, subRemote, []fstest.Item{file2, file1})
+
+	// and back out again
+	err = doDirMove(context.Background(), subRemote)
+	if err != nil {
+		t.Errorf("Failed to DirMove out of subdirectory: %v", err)
+	}
+	fstest.CheckListing(t, remote, []fstest.Item{file2, file1})
+	fstest.CheckListing(t, subRemote, []fstest.Item{})
 }
 
 // TestFsRmdirFull tests removing a non empty directory
 func TestFsRmdirFull(t *testing.T) {
 	skipIfNotOk(t)
-	err := remote.Rmdir()
+	err := remote.Rmdir(context.Background())
 	if err == nil {
 		t.Fatalf("Expecting error on RMdir on non empty remote")
 	}
@@ -447,8 +526,8 @@ func TestObjectFs(t *testing.T) {
 	equal := obj.Fs() == remote
 	if !equal {
 		// Check to see if this wraps something else
-		if unwrap, ok := remote.(fs.UnWrapper); ok {
-			equal = obj.Fs() == unwrap.UnWrap()
+		if unwrap := remote.Features().UnWrap; unwrap != nil {
+			equal = obj.Fs() == unwrap()
 		}
 	}
 	if !equal {
@@ -465,16 +544,14 @@ func TestObjectRemote(t *testing.T) {
 	}
 }
 
-// TestObjectMd5sum tests the MD5SUM of the object is correct
-func TestObjectMd5sum(t *testing.T) {
+// TestObjectHashes tests the hashes of the object are correct
+func TestObjectHashes(t *testing.T) {
 	skipIfNotOk(t)
 	obj := findObject(t, file1.Path)
-	Md5sum, err := obj.Md5sum()
-	if err != nil {
-		t.Errorf("Error in Md5sum: %v", err)
-	}
-	if !fs.Md5sumsEqual(Md5sum, file1.Md5sum) {
-		t.Errorf("Md5sum is wrong %v != %v", Md5sum, file1.Md5sum)
+	file1.CheckHashes(t, obj)
+	_, err := obj.Hash(context.Background(), fs.HashNone)
+	if err != fs.ErrHashUnsupported {
+		t.Errorf("Expecting ErrHashUnsupported for HashNone got %v", err)
 	}
 }
 
@@ -482,7 +559,7 @@ func TestObjectMd5sum(t *testing.T) {
 func TestObjectModTime(t *testing.T) {
 	skipIfNotOk(t)
 	obj := findObject(t, file1.Path)
-	file1.CheckModTime(t, obj, obj.ModTime(), remote.Precision())
+	file1.CheckModTime(t, obj, obj.ModTime(context.Background()), remote.Precision())
 }
 
 // TestObjectSetModTime tests that SetModTime works
@@ -490,9 +567,9 @@ func TestObjectSetModTime(t *testing.T) {
 	skipIfNotOk(t)
 	newModTime := fstest.Time("2011-12-13T14:15:16.999999999Z")
 	obj := findObject(t, file1.Path)
-	obj.SetModTime(newModTime)
+	obj.SetModTime(context.Background(), newModTime)
 	file1.ModTime = newModTime
-	file1.CheckModTime(t, obj, obj.ModTime(), remote.Precision())
+	file1.CheckModTime(t, obj, obj.ModTime(context.Background()), remote.Precision())
 	// And make a new object and read it from there too
 	TestObjectModTime(t)
 }
@@ -510,11 +587,11 @@ func TestObjectSize(t *testing.T) {
 func TestObjectOpen(t *testing.T) {
 	skipIfNotOk(t)
 	obj := findObject(t, file1.Path)
-	in, err := obj.Open()
+	in, err := obj.Open(context.Background())
 	if err != nil {
 		t.Fatalf("Open() return error: %v", err)
 	}
-	hash := md5.New()
+	hash := fs.NewMultiHasher()
 	n, err := io.Copy(hash, in)
 	if err != nil {
 		t.Fatalf("io.Copy() return error: %v", err)
@@ -526,26 +603,64 @@ func TestObjectOpen(t *testing.T) {
 	if err != nil {
 		t.Fatalf("in.Close() return error: %v", err)
 	}
-	Md5sum := hex.EncodeToString(hash.Sum(nil))
-	if !fs.Md5sumsEqual(Md5sum, file1.Md5sum) {
-		t.Errorf("Md5sum is wrong %v != %v", Md5sum, file1.Md5sum)
+	for hashType, sum := range hash.Sums() {
+		if !fs.HashEquals(sum, file1.Hashes[hashType]) {
+			t.Errorf("%v hash is wrong %v != %v", hashType, sum, file1.Hashes[hashType])
+		}
+	}
+}
+
+// TestObjectOpenSeek tests that Open works with a SeekOption and a
+// RangeOption
+func TestObjectOpenSeek(t *testing.T) {
+	skipIfNotOk(t)
+	obj := findObject(t, file1.Path)
+	n := len(file1Contents)
+	for _, test := range []struct {
+		option fs.OpenOption
+		want   string
+	}{
+		{&fs.SeekOption{Offset: 0}, file1Contents},
+		{&fs.SeekOption{Offset: 10}, file1Contents[10:]},
+		{&fs.RangeOption{Start: 10, End: 19}, file1Contents[10:20]},
+		{&fs.RangeOption{Start: 90, End: -1}, file1Contents[90:]},
+		{&fs.RangeOption{Start: -1, End: 20}, file1Contents[n-20:]},
+	} {
+		in, err := obj.Open(context.Background(), test.option)
+		if err != nil {
+			t.Fatalf("%v: Open() return error: %v", test.option, err)
+		}
+		got, err := ioutil.ReadAll(in)
+		if err != nil {
+			t.Fatalf("%v: ReadAll() return error: %v", test.option, err)
+		}
+		err = in.Close()
+		if err != nil {
+			t.Fatalf("%v: in.Close() return error: %v", test.option, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%v: contents wrong - want %q got %q", test.option, test.want, got)
+		}
 	}
 }
 
 // TestObjectUpdate tests that Update works
 func TestObjectUpdate(t *testing.T) {
 	skipIfNotOk(t)
-	buf := bytes.NewBufferString(fstest.RandomString(200))
-	hash := md5.New()
+	contents := fstest.RandomString(200)
+	buf := bytes.NewBufferString(contents)
+	hash := fs.NewMultiHasher()
 	in := io.TeeReader(buf, hash)
 
 	file1.Size = int64(buf.Len())
 	obj := findObject(t, file1.Path)
-	err := obj.Update(in, file1.ModTime, file1.Size)
+	err := obj.Update(context.Background(), in, file1.ModTime, file1.Size)
 	if err != nil {
 		t.Fatal("Update error", err)
 	}
-	file1.Md5sum = hex.EncodeToString(hash.Sum(nil))
+	file1Contents = contents
+	file1.Hashes = hash.Sums()
+	file1.Md5sum = file1.Hashes[fs.HashMD5]
 	file1.Check(t, obj, remote.Precision())
 	// Re-read the object and check again
 	obj = findObject(t, file1.Path)
@@ -575,8 +690,8 @@ func TestLimitedFs(t *testing.T) {
 	_, ok := fileRemote.(*fs.Limited)
 	if !ok {
 		// Check to see if this wraps a Limited FS
-		if unwrap, hasUnWrap := fileRemote.(fs.UnWrapper); hasUnWrap {
-			_, ok = unwrap.UnWrap().(*fs.Limited)
+		if unwrap := fileRemote.Features().UnWrap; unwrap != nil {
+			_, ok = unwrap().(*fs.Limited)
 		}
 		if !ok {
 			t.Errorf("%v is not a fs.Limited", fileRemote)
@@ -599,11 +714,164 @@ func TestLimitedFsNotFound(t *testing.T) {
 	}
 }
 
+// TestFsPutStream tests putting a file of unknown size
+func TestFsPutStream(t *testing.T) {
+	skipIfNotOk(t)
+	contents := fstest.RandomString(100)
+	buf := bytes.NewBufferString(contents)
+	hash := fs.NewMultiHasher()
+	in := io.TeeReader(buf, hash)
+
+	file := fstest.Item{
+		ModTime: fstest.Time("2001-02-03T04:05:06.499999999Z"),
+		Path:    "piped data.txt",
+		Size:    int64(len(contents)),
+	}
+	obj, err := remote.Put(context.Background(), in, file.Path, file.ModTime, -1)
+	if err != nil {
+		t.Fatal("Put error", err)
+	}
+	file.Hashes = hash.Sums()
+	file.Md5sum = file.Hashes[fs.HashMD5]
+	file.Check(t, obj, remote.Precision())
+	// Re-read the object and check again
+	obj = findObject(t, file.Path)
+	file.Check(t, obj, remote.Precision())
+	err = obj.Remove(context.Background())
+	if err != nil {
+		t.Fatal("Remove error", err)
+	}
+}
+
+// TestFsPutMetadata tests the content type and metadata passed in are stored
+func TestFsPutMetadata(t *testing.T) {
+	skipIfNotOk(t)
+	contents := fstest.RandomString(100)
+	file := fstest.Item{
+		ModTime: fstest.Time("2001-02-03T04:05:06.499999999Z"),
+		Path:    "metadata.txt",
+		Size:    int64(len(contents)),
+	}
+	option := &fs.MetadataOption{
+		MimeType: "text/x-rclone-test",
+		Metadata: fs.Metadata{"potato": "jersey royal"},
+	}
+	obj, err := remote.Put(context.Background(), bytes.NewBufferString(contents), file.Path, file.ModTime, file.Size, option)
+	if err != nil {
+		t.Fatal("Put error", err)
+	}
+	defer func() {
+		err := obj.Remove(context.Background())
+		if err != nil {
+			t.Fatal("Remove error", err)
+		}
+	}()
+
+	// Re-read the object and check the metadata if supported
+	obj = findObject(t, file.Path)
+	do, ok := obj.(fs.Metadataer)
+	if !ok {
+		t.Skip("Object has no Metadataer interface")
+	}
+	if mimeType := do.MimeType(context.Background()); mimeType != "text/x-rclone-test" {
+		t.Errorf("Expecting mime type %q got %q", "text/x-rclone-test", mimeType)
+	}
+	metadata, err := do.Metadata(context.Background())
+	if err != nil {
+		t.Fatal("Metadata error", err)
+	}
+	if metadata["potato"] != "jersey royal" {
+		t.Errorf("Expecting potato=%q in metadata got %v", "jersey royal", metadata)
+	}
+}
+
+// TestFsAbout tests the quota information can be read
+func TestFsAbout(t *testing.T) {
+	skipIfNotOk(t)
+	doAbout := remote.Features().About
+	if doAbout == nil {
+		t.Skip("FS has no About feature")
+	}
+	usage, err := doAbout(context.Background())
+	if err != nil {
+		t.Fatalf("About failed: %v", err)
+	}
+	if usage.Used != nil && *usage.Used < 0 {
+		t.Errorf("Expecting used >= 0 got %d", *usage.Used)
+	}
+}
+
+// TestFsPublicLink tests a link can be made to an object but not to
+// one which doesn't exist
+func TestFsPublicLink(t *testing.T) {
+	skipIfNotOk(t)
+	doPublicLink := remote.Features().PublicLink
+	if doPublicLink == nil {
+		t.Skip("FS has no PublicLink feature")
+	}
+	link, err := doPublicLink(context.Background(), file1.Path, time.Hour)
+	if err != nil {
+		t.Fatalf("PublicLink failed: %v", err)
+	}
+	if !strings.HasPrefix(link, "http") {
+		t.Errorf("Expecting an http link got %q", link)
+	}
+	_, err = doPublicLink(context.Background(), "potato/not/found", time.Hour)
+	if err == nil {
+		t.Errorf("Expecting an error making a link to an object which doesn't exist")
+	}
+}
+
+// TestFsMakeDir tests empty directories can be made, listed and removed
+func TestFsMakeDir(t *testing.T) {
+	skipIfNotOk(t)
+	features := remote.Features()
+	if features.MakeDir == nil {
+		t.Skip("FS has no MakeDir feature")
+	}
+	ctx := context.Background()
+	const dir, subDir = "empty dir", "empty dir/sub dir"
+	err := features.MakeDir(ctx, subDir)
+	if err != nil {
+		t.Fatalf("MakeDir failed: %v", err)
+	}
+	modTime := fstest.Time("2002-03-04T05:06:07.000000000Z")
+	if features.SetDirModTime != nil {
+		err = features.SetDirModTime(ctx, subDir, modTime)
+		if err != nil {
+			t.Errorf("SetDirModTime failed: %v", err)
+		}
+	}
+	if features.ListDirEntries != nil {
+		objs, dirs, err := features.ListDirEntries(ctx, dir)
+		if err != nil {
+			t.Fatalf("ListDirEntries failed: %v", err)
+		}
+		if len(objs) != 0 || len(dirs) != 1 || dirs[0].Name != subDir {
+			t.Fatalf("Expecting only %q but got %v and %v", subDir, objs, dirs)
+		}
+		if features.SetDirModTime != nil {
+			dt, ok := fstest.CheckTimeEqualWithPrecision(dirs[0].When, modTime, remote.Precision())
+			if !ok {
+				t.Errorf("%s: Modification time difference too big |%s| > %s (%s vs %s)", subDir, dt, remote.Precision(), dirs[0].When, modTime)
+			}
+		}
+	}
+	err = features.RemoveDir(ctx, subDir)
+	if err != nil {
+		t.Fatalf("RemoveDir %q failed: %v", subDir, err)
+	}
+	err = features.RemoveDir(ctx, dir)
+	if err != nil {
+		t.Fatalf("RemoveDir %q failed: %v", dir, err)
+	}
+}
+
 // TestObjectRemove tests Remove
 func TestObjectRemove(t *testing.T) {
 	skipIfNotOk(t)
 	obj := findObject(t, file1.Path)
-	err := obj.Remove()
+	err := obj.Remove(context.Background())
 	if err != nil {
 		t.Fatal("Remove error", err)
 	}
@@ -614,7 +882,7 @@ func TestObjectRemove(t *testing.T) {
 func TestObjectPurge(t *testing.T) {
 	skipIfNotOk(t)
 	fstest.TestPurge(t, remote)
-	err := fs.Purge(remote)
+	err := fs.Purge(context.Background(), remote)
 	if err == nil {
 		t.Fatal("Expecting error after on second purge")
 	}
@@ -13,14 +13,24 @@ FIXME Patch/Delete/Get isn't working with files with spaces in - giving 404 erro
 */
 
 import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 	"path"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -73,6 +83,9 @@ func init() {
 		}, {
 			Name: "project_number",
 			Help: "Project number optional - needed only for list/create/delete buckets - see your developer console.",
+		}, {
+			Name: "service_account_file",
+			Help: "Service Account Credentials JSON file path optional - needed only for making signed links with link.",
 		}, {
 			Name: "object_acl",
 			Help: "Access Control List for new objects.",
@@ -128,18 +141,22 @@ type Fs struct {
 	projectNumber string           // used for finding buckets
 	objectAcl     string           // used when creating new objects
 	bucketAcl     string           // used when creating new buckets
+	accountFile   string           // service account JSON key file for signing links
+	features      *fs.Features     // optional features
 }
 
 // Object describes a storage object
 //
 // Will definitely have info but maybe not meta
 type Object struct {
-	fs      *Fs       // what this object is part of
-	remote  string    // The remote path
-	url     string    // download path
-	md5sum  string    // The MD5Sum of the object
-	bytes   int64     // Bytes in the object
-	modTime time.Time // Modified time of the object
+	fs       *Fs               // what this object is part of
+	remote   string            // The remote path
+	url      string            // download path
+	md5sum   string            // The MD5Sum of the object
+	bytes    int64             // Bytes in the object
+	modTime  time.Time         // Modified time of the object
+	mimeType string            // Content-Type of the object
+	metadata map[string]string // The object metadata
 }
 
 // ------------------------------------------------------------
@@ -182,6 +199,7 @@ func parsePath(path string) (bucket, directory string, err error) {
 
 // NewFs contstructs an Fs from the path, bucket:path
 func NewFs(name, root string) (fs.Fs, error) {
+	ctx := context.Background()
 	oAuthClient, err := oauthutil.NewClient(name, storageConfig)
 	if err != nil {
 		log.Fatalf("Failed to configure Google Cloud Storage: %v", err)
@@ -199,7 +217,9 @@ func NewFs(name, root string) (fs.Fs, error) {
 		projectNumber: fs.ConfigFile.MustValue(name, "project_number"),
 		objectAcl:     fs.ConfigFile.MustValue(name, "object_acl"),
 		bucketAcl:     fs.ConfigFile.MustValue(name, "bucket_acl"),
+		accountFile:   fs.ConfigFile.MustValue(name, "service_account_file"),
 	}
+	f.features = (&fs.Features{}).Fill(f)
 	if f.objectAcl == "" {
 		f.objectAcl = "private"
 	}
@@ -217,7 +237,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 	if f.root != "" {
 		f.root += "/"
 		// Check to see if the object exists
-		_, err = f.svc.Objects.Get(bucket, directory).Do()
+		_, err = f.svc.Objects.Get(bucket, directory).Context(ctx).Do()
 		if err == nil {
 			remote := path.Base(directory)
 			f.root = path.Dir(directory)
@@ -226,7 +246,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 			} else {
 				f.root += "/"
 			}
-			obj := f.NewFsObject(remote)
+			obj := f.NewFsObject(ctx, remote)
 			// return a Fs Limited to this object
 			return fs.NewLimited(f, obj), nil
 		}
@@ -237,7 +257,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 // Return an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) newFsObjectWithInfo(remote string, info *storage.Object) fs.Object {
+func (f *Fs) newFsObjectWithInfo(ctx context.Context, remote string, info *storage.Object) fs.Object {
 	o := &Object{
 		fs:     f,
 		remote: remote,
@@ -245,7 +265,7 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *storage.Object) fs.Object
 	if info != nil {
 		o.setMetaData(info)
 	} else {
-		err := o.readMetaData() // reads info and meta, returning an error
+		err := o.readMetaData(ctx) // reads info and meta, returning an error
 		if err != nil {
 			// logged already FsDebug("Failed to read info: %s", err)
 			return nil
@@ -257,42 +277,69 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *storage.Object) fs.Object
 // NewFsObject returns an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) NewFsObject(remote string) fs.Object {
-	return f.newFsObjectWithInfo(remote, nil)
+func (f *Fs) NewFsObject(ctx context.Context, remote string) fs.Object {
+	return f.newFsObjectWithInfo(ctx, remote, nil)
 }
 
+// listFn is called from list to handle an object or a directory
+type listFn func(remote string, object *storage.Object, isDirectory bool) error
+
 // list the objects into the function supplied
 //
-// If directories is set it only sends directories
-func (f *Fs) list(directories bool, fn func(string, *storage.Object)) {
-	list := f.svc.Objects.List(f.bucket).Prefix(f.root).MaxResults(listChunks)
+// dir is the directory to list relative to the root, "" being the
+// root itself
+//
+// If directories is set it only sends the objects and directories
+// directly in dir, otherwise it sends all the objects below dir.
+//
+// If fn returns an error the listing stops and the error is returned
+func (f *Fs) list(ctx context.Context, dir string, directories bool, fn listFn) error {
+	prefix := f.root
+	if dir != "" {
+		prefix += dir + "/"
+	}
+	list := f.svc.Objects.List(f.bucket).Prefix(prefix).MaxResults(listChunks)
 	if directories {
 		list = list.Delimiter("/")
 	}
 	rootLength := len(f.root)
 	for {
-		objects, err := list.Do()
+		objects, err := list.Context(ctx).Do()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
-			fs.Stats.Error()
-			fs.ErrorLog(f, "Couldn't read bucket %q: %s", f.bucket, err)
-			return
+			return fmt.Errorf("Couldn't read bucket %q: %s", f.bucket, err)
 		}
-		if !directories {
-			for _, object := range objects.Items {
-				if !strings.HasPrefix(object.Name, f.root) {
-					fs.Log(f, "Odd name received %q", object.Name)
-					continue
-				}
-				remote := object.Name[rootLength:]
-				fn(remote, object)
-			}
-		} else {
+		if directories {
 			var object storage.Object
 			for _, prefix := range objects.Prefixes {
 				if !strings.HasSuffix(prefix, "/") {
 					continue
 				}
-				fn(prefix[:len(prefix)-1], &object)
+				if !strings.HasPrefix(prefix, f.root) {
+					fs.Log(f, "Odd name received %q", prefix)
+					continue
+				}
+				err = fn(prefix[rootLength:len(prefix)-1], &object, true)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		for _, object := range objects.Items {
+			if !strings.HasPrefix(object.Name, f.root) {
+				fs.Log(f, "Odd name received %q", object.Name)
+				continue
+			}
+			if directories && strings.HasSuffix(object.Name, "/") {
+				// Skip directory markers
+				continue
+			}
+			remote := object.Name[rootLength:]
+			err = fn(remote, object, false)
+			if err != nil {
+				return err
 			}
 		}
 		if objects.NextPageToken == "" {
@@ -300,32 +347,55 @@ func (f *Fs) list(directories bool, fn func(string, *storage.Object)) {
 		}
 		list.PageToken(objects.NextPageToken)
 	}
+	return nil
 }
 
-// List walks the path returning a channel of FsObjects
-func (f *Fs) List() fs.ObjectsChan {
-	out := make(fs.ObjectsChan, fs.Config.Checkers)
+// List lists the objects in the bucket into out
+func (f *Fs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
 	if f.bucket == "" {
-		// Return no objects at top level list
-		close(out)
-		fs.Stats.Error()
-		fs.ErrorLog(f, "Can't list objects at root - choose a bucket using lsd")
-	} else {
-		// List the objects
-		go func() {
-			defer close(out)
-			f.list(false, func(remote string, object *storage.Object) {
-				if fs := f.newFsObjectWithInfo(remote, object); fs != nil {
-					out <- fs
-				}
+		// Objects can only be listed within a bucket
+		out.SetError(fmt.Errorf("Can't list objects at root - choose a bucket using lsd"))
+		return
+	}
+	err := f.list(ctx, "", false, func(remote string, object *storage.Object, isDirectory bool) error {
+		if o := f.newFsObjectWithInfo(ctx, remote, object); o != nil {
+			if out.Add(o) {
+				return fs.ErrorListAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && err != fs.ErrorListAborted {
+		out.SetError(err)
+	}
+}
+
+// ListDirEntries lists the objects and directories directly in dir
+func (f *Fs) ListDirEntries(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	if f.bucket == "" {
+		return nil, nil, fmt.Errorf("Can't list objects at root - choose a bucket using lsd")
+	}
+	err = f.list(ctx, dir, true, func(remote string, object *storage.Object, isDirectory bool) error {
+		if isDirectory {
+			dirs = append(dirs, &fs.Dir{
+				Name:  remote,
+				Bytes: -1,
+				Count: -1,
 			})
-		}()
+		} else if o := f.newFsObjectWithInfo(ctx, remote, object); o != nil {
+			objects = append(objects, o)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
-	return out
+	return objects, dirs, nil
 }
 
 // ListDir lists the buckets
-func (f *Fs) ListDir() fs.DirChan {
+func (f *Fs) ListDir(ctx context.Context) fs.DirChan {
 	out := make(fs.DirChan, fs.Config.Checkers)
 	if f.bucket == "" {
 		// List the buckets
@@ -338,17 +408,24 @@ func (f *Fs) ListDir() fs.DirChan {
 			}
 			listBuckets := f.svc.Buckets.List(f.projectNumber).MaxResults(listChunks)
 			for {
-				buckets, err := listBuckets.Do()
+				buckets, err := listBuckets.Context(ctx).Do()
+				if ctx.Err() != nil {
+					return
+				}
 				if err != nil {
 					fs.Stats.Error()
 					fs.ErrorLog(f, "Couldn't list buckets: %v", err)
 					break
 				} else {
 					for _, bucket := range buckets.Items {
-						out <- &fs.Dir{
+						select {
+						case out <- &fs.Dir{
 							Name:  bucket.Name,
 							Bytes: 0,
 							Count: 0,
+						}:
+						case <-ctx.Done():
+							return
 						}
 					}
 				}
@@ -362,13 +439,25 @@ func (f *Fs) ListDir() fs.DirChan {
 		// List the directories in the path in the bucket
 		go func() {
 			defer close(out)
-			f.list(true, func(remote string, object *storage.Object) {
-				out <- &fs.Dir{
+			err := f.list(ctx, "", true, func(remote string, object *storage.Object, isDirectory bool) error {
+				if !isDirectory {
+					return nil
+				}
+				select {
+				case out <- &fs.Dir{
 					Name:  remote,
 					Bytes: int64(object.Size),
 					Count: 0,
+				}:
+				case <-ctx.Done():
+					return ctx.Err()
 				}
+				return nil
 			})
+			if err != nil && ctx.Err() == nil {
+				fs.Stats.Error()
+				fs.ErrorLog(f, "%s", err)
+			}
 		}()
 	}
 	return out
@@ -379,18 +468,18 @@ func (f *Fs) ListDir() fs.DirChan {
 // Copy the reader in to the new object which is returned
 //
 // The new object may have been created if an error is returned
-func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs.Object, error) {
+func (f *Fs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
 	// Temporary Object under construction
 	o := &Object{
 		fs:     f,
 		remote: remote,
 	}
-	return o, o.Update(in, modTime, size)
+	return o, o.Update(ctx, in, modTime, size, options...)
 }
 
 // Mkdir creates the bucket if it doesn't exist
-func (f *Fs) Mkdir() error {
-	_, err := f.svc.Buckets.Get(f.bucket).Do()
+func (f *Fs) Mkdir(ctx context.Context) error {
+	_, err := f.svc.Buckets.Get(f.bucket).Context(ctx).Do()
 	if err == nil {
 		// Bucket already exists
 		return nil
@@ -403,7 +492,7 @@ func (f *Fs) Mkdir() error {
 	bucket := storage.Bucket{
 		Name: f.bucket,
 	}
-	_, err = f.svc.Buckets.Insert(f.projectNumber, &bucket).PredefinedAcl(f.bucketAcl).Do()
+	_, err = f.svc.Buckets.Insert(f.projectNumber, &bucket).PredefinedAcl(f.bucketAcl).Context(ctx).Do()
 	return err
 }
 
@@ -411,11 +500,11 @@ func (f *Fs) Mkdir() error {
 //
 // Returns an error if it isn't empty: Error 409: The bucket you tried
 // to delete was not empty.
-func (f *Fs) Rmdir() error {
+func (f *Fs) Rmdir(ctx context.Context) error {
 	if f.root != "" {
 		return nil
 	}
-	return f.svc.Buckets.Delete(f.bucket).Do()
+	return f.svc.Buckets.Delete(f.bucket).Context(ctx).Do()
 }
 
 // Precision returns the precision
@@ -423,6 +512,16 @@ func (f *Fs) Precision() time.Duration {
 	return time.Nanosecond
 }
 
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() fs.HashSet {
+	return fs.HashSet(fs.HashMD5)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
 // Copy src to this remote using server side copy operations.
 //
 // This is stored with the remote path given
@@ -432,7 +531,7 @@ func (f *Fs) Precision() time.Duration {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantCopy
-func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debug(src, "Can't copy - not same remote type")
@@ -449,7 +548,7 @@ func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
 	srcObject := srcObj.fs.root + srcObj.remote
 	dstBucket := f.bucket
 	dstObject := f.root + remote
-	newObject, err := f.svc.Objects.Copy(srcBucket, srcObject, dstBucket, dstObject, nil).Do()
+	newObject, err := f.svc.Objects.Copy(srcBucket, srcObject, dstBucket, dstObject, nil).Context(ctx).Do()
 	if err != nil {
 		return nil, err
 	}
@@ -458,6 +557,106 @@ func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
 	return dstObj, nil
 }
 
+// Move src to this remote using server side move operations.
+//
+// This is done as a server side copy followed by deleting src.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantMove
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debug(src, "Can't move - not same remote type")
+		return nil, fs.ErrorCantMove
+	}
+	dstObj, err := f.Copy(ctx, srcObj, remote)
+	if err == fs.ErrorCantCopy {
+		return nil, fs.ErrorCantMove
+	} else if err != nil {
+		return nil, err
+	}
+	if dstObj == nil {
+		return nil, fmt.Errorf("Couldn't find %q after copying it", remote)
+	}
+	err = srcObj.Remove(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Copied but couldn't remove source: %v", err)
+	}
+	return dstObj, nil
+}
+
+// PublicLink makes a signed URL for the object at remote which is
+// valid for expire
+//
+// This needs the service_account_file to sign the URL with.
+func (f *Fs) PublicLink(ctx context.Context, remote string, expire time.Duration) (string, error) {
+	if f.accountFile == "" {
+		return "", fmt.Errorf("need service_account_file in the config to sign links")
+	}
+	if f.NewFsObject(ctx, remote) == nil {
+		return "", fs.ErrorObjectNotFound
+	}
+	data, err := ioutil.ReadFile(f.accountFile)
+	if err != nil {
+		return "", fmt.Errorf("Couldn't read service account file: %v", err)
+	}
+	conf, err := google.JWTConfigFromJSON(data)
+	if err != nil {
+		return "", fmt.Errorf("Couldn't parse service account file: %v", err)
+	}
+	key, err := parsePrivateKey(conf.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	// Sign the request as described in
+	// https://cloud.google.com/storage/docs/access-control/signed-urls-v2
+	expires := strconv.FormatInt(time.Now().Add(expire).Unix(), 10)
+	u := &url.URL{
+		Scheme: "https",
+		Host:   "storage.googleapis.com",
+		Path:   "/" + f.bucket + "/" + f.root + remote,
+	}
+	toSign := "GET\n\n\n" + expires + "\n" + u.EscapedPath()
+	sum := sha256.Sum256([]byte(toSign))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("Couldn't sign link: %v", err)
+	}
+	u.RawQuery = url.Values{
+		"GoogleAccessId": {conf.Email},
+		"Expires":        {expires},
+		"Signature":      {base64.StdEncoding.EncodeToString(signature)},
+	}.Encode()
+	return u.String(), nil
+}
+
+// parsePrivateKey parses the PEM encoded RSA key from a service
+// account file
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("Couldn't find private key in service account file")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't parse private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Private key isn't an RSA key")
+	}
+	return key, nil
+}
+
 // ------------------------------------------------------------
 
 // Fs returns the parent Fs
@@ -478,8 +677,11 @@ func (o *Object) Remote() string {
 	return o.remote
 }
 
-// Md5sum returns the Md5sum of an object returning a lowercase hex string
-func (o *Object) Md5sum() (string, error) {
+// Hash returns the Md5sum of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t fs.HashType) (string, error) {
+	if t != fs.HashMD5 {
+		return "", fs.ErrHashUnsupported
+	}
 	return o.md5sum, nil
 }
 
@@ -501,6 +703,9 @@ func (o *Object) setMetaData(info *storage.Object) {
 		o.md5sum = hex.EncodeToString(md5sumData)
 	}
 
+	o.mimeType = info.ContentType
+	o.metadata = info.Metadata
+
 	// read mtime out of metadata if available
 	mtimeString, ok := info.Metadata[metaMtime]
 	if ok {
@@ -524,11 +729,11 @@ func (o *Object) setMetaData(info *storage.Object) {
 // readMetaData gets the metadata if it hasn't already been fetched
 //
 // it also sets the info
-func (o *Object) readMetaData() (err error) {
+func (o *Object) readMetaData(ctx context.Context) (err error) {
 	if !o.modTime.IsZero() {
 		return nil
 	}
-	object, err := o.fs.svc.Objects.Get(o.fs.bucket, o.fs.root+o.remote).Do()
+	object, err := o.fs.svc.Objects.Get(o.fs.bucket, o.fs.root+o.remote).Context(ctx).Do()
 	if err != nil {
 		fs.Debug(o, "Failed to read info: %s", err)
 		return err
@@ -541,8 +746,8 @@ func (o *Object) readMetaData() (err error) {
 //
 // It attempts to read the objects mtime and if that isn't present the
 // LastModified returned in the http headers
-func (o *Object) ModTime() time.Time {
-	err := o.readMetaData()
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		// fs.Log(o, "Failed to read metadata: %s", err)
 		return time.Now()
@@ -550,6 +755,32 @@ func (o *Object) ModTime() time.Time {
 	return o.modTime
 }
 
+// MimeType returns the content type of the object
+func (o *Object) MimeType(ctx context.Context) string {
+	err := o.readMetaData(ctx)
+	if err != nil {
+		fs.Log(o, "Failed to read metadata: %s", err)
+		return ""
+	}
+	return o.mimeType
+}
+
+// Metadata returns the user metadata of the object
+func (o *Object) Metadata(ctx context.Context) (fs.Metadata, error) {
+	err := o.readMetaData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(fs.Metadata, len(o.metadata))
+	for k, v := range o.metadata {
+		if k == metaMtime {
+			continue
+		}
+		metadata[strings.ToLower(k)] = v
+	}
+	return metadata, nil
+}
+
 // Returns metadata for an object
 func metadataFromModTime(modTime time.Time) map[string]string {
 	metadata := make(map[string]string, 1)
@@ -558,14 +789,14 @@ func metadataFromModTime(modTime time.Time) map[string]string {
 }
 
 // SetModTime sets the modification time of the local fs object
-func (o *Object) SetModTime(modTime time.Time) {
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) {
 	// This only adds metadata so will perserve other metadata
 	object := storage.Object{
 		Bucket:   o.fs.bucket,
 		Name:     o.fs.root + o.remote,
 		Metadata: metadataFromModTime(modTime),
 	}
-	newObject, err := o.fs.svc.Objects.Patch(o.fs.bucket, o.fs.root+o.remote, &object).Do()
+	newObject, err := o.fs.svc.Objects.Patch(o.fs.bucket, o.fs.root+o.remote, &object).Context(ctx).Do()
 	if err != nil {
 		fs.Stats.Error()
 		fs.ErrorLog(o, "Failed to update remote mtime: %s", err)
@@ -579,7 +810,7 @@ func (o *Object) Storable() bool {
 }
 
 // Open an object for read
-func (o *Object) Open() (in io.ReadCloser, err error) {
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
 	// This is slightly complicated by Go here insisting on
 	// decoding the %2F in URLs into / which is legal in http, but
 	// unfortunately not what the storage server wants.
@@ -591,34 +822,46 @@ func (o *Object) Open() (in io.ReadCloser, err error) {
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	// SetOpaque sets Opaque such that HTTP requests to it don't
 	// alter any hex-escaped characters
 	googleapi.SetOpaque(req.URL)
 	req.Header.Set("User-Agent", fs.UserAgent)
+	fs.OpenOptionAddHTTPHeaders(req.Header, options...)
 	res, err := o.fs.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	if res.StatusCode != 200 {
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
 		_ = res.Body.Close() // ignore error
 		return nil, fmt.Errorf("Bad response: %d: %s", res.StatusCode, res.Status)
 	}
-	return res.Body, nil
+	return fs.OpenOptionsResponse(res, o.Size(), options...)
 }
 
 // Update the object with the contents of the io.Reader, modTime and size
 //
 // The new object may have been created if an error is returned
-func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
+func (o *Object) Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...fs.PutOption) error {
 	object := storage.Object{
 		Bucket:      o.fs.bucket,
 		Name:        o.fs.root + o.remote,
-		ContentType: fs.MimeType(o),
-		Size:        uint64(size),
+		ContentType: fs.MimeType(o, options...),
 		Updated:     modTime.Format(timeFormatOut), // Doesn't get set
 		Metadata:    metadataFromModTime(modTime),
 	}
-	newObject, err := o.fs.svc.Objects.Insert(o.fs.bucket, &object).Media(in).Name(object.Name).PredefinedAcl(o.fs.objectAcl).Do()
+	// Store any user metadata passed in
+	_, userMetadata := fs.PutOptionsMetadata(options...)
+	for k, v := range userMetadata {
+		if k != metaMtime {
+			object.Metadata[k] = v
+		}
+	}
+	// size is -1 if the length of the stream isn't known
+	if size >= 0 {
+		object.Size = uint64(size)
+	}
+	newObject, err := o.fs.svc.Objects.Insert(o.fs.bucket, &object).Media(in).Name(object.Name).PredefinedAcl(o.fs.objectAcl).Context(ctx).Do()
 	if err != nil {
 		return err
 	}
@@ -628,13 +871,17 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 }
 
 // Remove an object
-func (o *Object) Remove() error {
-	return o.fs.svc.Objects.Delete(o.fs.bucket, o.fs.root+o.remote).Do()
+func (o *Object) Remove(ctx context.Context) error {
+	return o.fs.svc.Objects.Delete(o.fs.bucket, o.fs.root+o.remote).Context(ctx).Do()
 }
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs     = &Fs{}
-	_ fs.Copier = &Fs{}
-	_ fs.Object = &Object{}
+	_ fs.Fs           = &Fs{}
+	_ fs.Copier       = &Fs{}
+	_ fs.Mover        = &Fs{}
+	_ fs.DirLister    = &Fs{}
+	_ fs.PublicLinker = &Fs{}
+	_ fs.Object       = &Object{}
+	_ fs.Metadataer   = &Object{}
 )
@@ -7,6 +7,7 @@ package hubic
 // to be revisted after some actual experience.
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -74,6 +75,7 @@ type credentials struct {
 // Fs represents a remote hubic
 type Fs struct {
 	fs.Fs                    // wrapped Fs
+	features    *fs.Features // optional features
 	client      *http.Client // client for oauth api
 	credentials credentials  // returned from the Hubic API
 	expires     time.Time    // time credentials expire
@@ -169,20 +171,28 @@ func NewFs(name, root string) (fs.Fs, error) {
 		return nil, err
 	}
 	f.Fs = swiftFs
+	f.features = (&fs.Features{}).Wrap(swiftFs)
 	return f, nil
 }
 
+// Features returns the optional features of this Fs
+//
+// These are the features of the wrapped swift Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
 // Purge deletes all the files and the container
 //
 // Optional interface: Only implement this if you have a way of
 // deleting all the files quicker than just running Remove() on the
 // result of List()
-func (f *Fs) Purge() error {
-	fPurge, ok := f.Fs.(fs.Purger)
-	if !ok {
+func (f *Fs) Purge(ctx context.Context) error {
+	do := f.Fs.Features().Purge
+	if do == nil {
 		return fs.ErrorCantPurge
 	}
-	return fPurge.Purge()
+	return do(ctx)
 }
 
 // Copy src to this remote using server side copy operations.
@@ -194,12 +204,12 @@ func (f *Fs) Purge() error {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantCopy
-func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
-	fCopy, ok := f.Fs.(fs.Copier)
-	if !ok {
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	do := f.Fs.Features().Copy
+	if do == nil {
 		return nil, fs.ErrorCantCopy
 	}
-	return fCopy.Copy(src, remote)
+	return do(ctx, src, remote)
 }
 
 // UnWrap returns the Fs that this Fs is wrapping
@@ -0,0 +1,32 @@
+// +build darwin freebsd linux
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/Shop2market/rclone/fs"
+)
+
+// About gets quota information for the filesystem the root is on
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	var s syscall.Statfs_t
+	err := syscall.Statfs(f.root, &s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read disk usage: %v", err)
+	}
+	bs := int64(s.Bsize)
+	usage := &fs.Usage{
+		Total: fs.NewUsageValue(bs * int64(s.Blocks)),
+		Used:  fs.NewUsageValue(bs * int64(s.Blocks-s.Bfree)),
+		Free:  fs.NewUsageValue(bs * int64(s.Bavail)),
+	}
+	return usage, nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Abouter = &Fs{}
+)
@@ -2,13 +2,12 @@
 package local
 
 import (
-	"crypto/md5"
-	"encoding/hex"
+	"context"
 	"fmt"
-	"hash"
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -35,15 +34,16 @@ type Fs struct {
 	precisionOk sync.Once           // Whether we need to read the precision
 	precision   time.Duration       // precision of local filesystem
 	warned      map[string]struct{} // whether we have warned about this string
+	features    *fs.Features        // optional features
 }
 
 // Object represents a local filesystem object
 type Object struct {
-	fs     *Fs         // The Fs this object is part of
-	remote string      // The remote path
-	path   string      // The local path
-	info   os.FileInfo // Interface for file info (always present)
-	md5sum string      // the md5sum of the object or "" if not calculated
+	fs     *Fs                    // The Fs this object is part of
+	remote string                 // The remote path
+	path   string                 // The local path
+	info   os.FileInfo            // Interface for file info (always present)
+	hashes map[fs.HashType]string // Hashes or nil if not calculated
 }
 
 // ------------------------------------------------------------
@@ -56,6 +56,10 @@ func NewFs(name, root string) (fs.Fs, error) {
 		name:   name,
 		warned: make(map[string]struct{}),
 	}
+	f.features = (&fs.Features{
+		CaseInsensitive:         runtime.GOOS == "windows" || runtime.GOOS == "darwin",
+		CanHaveEmptyDirectories: true,
+	}).Fill(f)
 	f.root = filterPath(f.cleanUtf8(root))
 
 	// Check to see if this points to a file
@@ -64,7 +68,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 		// It is a file, so use the parent as the root
 		var remote string
 		f.root, remote = getDirFile(f.root)
-		obj := f.NewFsObject(remote)
+		obj := f.NewFsObject(context.Background(), remote)
 		// return a Fs Limited to this object
 		return fs.NewLimited(f, obj), nil
 	}
@@ -116,47 +120,76 @@ func (f *Fs) newFsObjectWithInfo(remote string, info os.FileInfo) fs.Object {
 
 // NewFsObject returns an FsObject from a path
 //
-// May return nil if an error occurred
-func (f *Fs) NewFsObject(remote string) fs.Object {
-	return f.newFsObjectWithInfo(remote, nil)
+// May return nil if an error occurred or remote isn't a file
+func (f *Fs) NewFsObject(ctx context.Context, remote string) fs.Object {
+	o := f.newFsObjectWithInfo(remote, nil)
+	if o == nil || !o.Storable() {
+		return nil
+	}
+	return o
 }
 
-// List the path returning a channel of FsObjects
+// List the path into out
 //
 // Ignores everything which isn't Storable, eg links etc
-func (f *Fs) List() fs.ObjectsChan {
-	out := make(fs.ObjectsChan, fs.Config.Checkers)
-	go func() {
-		err := filepath.Walk(f.root, func(path string, fi os.FileInfo, err error) error {
-			if err != nil {
-				fs.Stats.Error()
-				fs.ErrorLog(f, "Failed to open directory: %s: %s", path, err)
-			} else {
-				remote, err := filepath.Rel(f.root, path)
-				if err != nil {
-					fs.Stats.Error()
-					fs.ErrorLog(f, "Failed to get relative path %s: %s", path, err)
-					return nil
-				}
-				if remote == "." {
-					return nil
-					// remote = ""
-				}
-				if fs := f.newFsObjectWithInfo(remote, fi); fs != nil {
-					if fs.Storable() {
-						out <- fs
-					}
-				}
-			}
-			return nil
-		})
+func (f *Fs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
+	err := filepath.Walk(f.root, func(path string, fi os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
-			fs.Stats.Error()
-			fs.ErrorLog(f, "Failed to open directory: %s: %s", f.root, err)
+			return fmt.Errorf("Failed to open directory: %s: %s", path, err)
 		}
-		close(out)
-	}()
-	return out
+		remote, err := filepath.Rel(f.root, path)
+		if err != nil {
+			return fmt.Errorf("Failed to get relative path %s: %s", path, err)
+		}
+		if remote == "." {
+			return nil
+			// remote = ""
+		}
+		if o := f.newFsObjectWithInfo(remote, fi); o != nil {
+			if o.Storable() && out.Add(o) {
+				return fs.ErrorListAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && err != fs.ErrorListAborted {
+		out.SetError(err)
+	}
+}
+
+// ListDirEntries lists the objects and directories directly in dir
+//
+// Ignores everything which isn't Storable, eg links etc
+func (f *Fs) ListDirEntries(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	dirPath := f.dirPath(dir)
+	items, err := ioutil.ReadDir(dirPath)
+	if os.IsNotExist(err) {
+		return nil, nil, fs.ErrorDirNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to open directory: %s: %s", dirPath, err)
+	}
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		remote := path.Join(dir, item.Name())
+		if item.IsDir() {
+			dirs = append(dirs, &fs.Dir{
+				Name:  remote,
+				When:  item.ModTime(),
+				Bytes: -1,
+				Count: -1,
+			})
+		} else if o := f.newFsObjectWithInfo(remote, item); o != nil && o.Storable() {
+			objects = append(objects, o)
+		}
+	}
+	return objects, dirs, nil
 }
 
 // CleanUtf8 makes string a valid UTF-8 string
@@ -177,7 +210,7 @@ func (f *Fs) cleanUtf8(name string) string {
 }
 
 // ListDir walks the path returning a channel of FsObjects
-func (f *Fs) ListDir() fs.DirChan {
+func (f *Fs) ListDir(ctx context.Context) fs.DirChan {
 	out := make(fs.DirChan, fs.Config.Checkers)
 	go func() {
 		defer close(out)
@@ -197,6 +230,9 @@ func (f *Fs) ListDir() fs.DirChan {
 					// Go down the tree to count the files and directories
 					dirpath := filterPath(filepath.Join(f.root, item.Name()))
 					err := filepath.Walk(dirpath, func(path string, fi os.FileInfo, err error) error {
+						if ctx.Err() != nil {
+							return ctx.Err()
+						}
 						if err != nil {
 							fs.Stats.Error()
 							fs.ErrorLog(f, "Failed to open directory: %s: %s", path, err)
@@ -206,11 +242,18 @@ func (f *Fs) ListDir() fs.DirChan {
 						}
 						return nil
 					})
+					if ctx.Err() != nil {
+						return
+					}
 					if err != nil {
 						fs.Stats.Error()
 						fs.ErrorLog(f, "Failed to open directory: %s: %s", dirpath, err)
 					}
-					out <- dir
+					select {
+					case out <- dir:
+					case <-ctx.Done():
+						return
+					}
 				}
 			}
 		}
@@ -220,10 +263,10 @@ func (f *Fs) ListDir() fs.DirChan {
 }
 
 // Put the FsObject to the local filesystem
-func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs.Object, error) {
+func (f *Fs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
 	// Temporary FsObject under construction - info filled in by Update()
 	o := f.newFsObject(remote)
-	err := o.Update(in, modTime, size)
+	err := o.Update(ctx, in, modTime, size, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -231,7 +274,7 @@ func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs
 }
 
 // Mkdir creates the directory if it doesn't exist
-func (f *Fs) Mkdir() error {
+func (f *Fs) Mkdir(ctx context.Context) error {
 	// FIXME: https://github.com/syncthing/syncthing/blob/master/lib/osutil/mkdirall_windows.go
 	return os.MkdirAll(f.root, 0777)
 }
@@ -239,10 +282,36 @@ func (f *Fs) Mkdir() error {
 // Rmdir removes the directory
 //
 // If it isn't empty it will return an error
-func (f *Fs) Rmdir() error {
+func (f *Fs) Rmdir(ctx context.Context) error {
 	return os.Remove(f.root)
 }
 
+// dirPath returns the local path of the directory dir
+func (f *Fs) dirPath(dir string) string {
+	return filterPath(filepath.Join(f.root, f.cleanUtf8(dir)))
+}
+
+// MakeDir makes the directory dir and any parents it needs
+func (f *Fs) MakeDir(ctx context.Context, dir string) error {
+	return os.MkdirAll(f.dirPath(dir), 0777)
+}
+
+// RemoveDir removes the directory dir
+//
+// If it isn't empty it will return an error
+func (f *Fs) RemoveDir(ctx context.Context, dir string) error {
+	return os.Remove(f.dirPath(dir))
+}
+
+// SetDirModTime sets the modification time of the directory dir
+func (f *Fs) SetDirModTime(ctx context.Context, dir string, modTime time.Time) error {
+	err := os.Chtimes(f.dirPath(dir), modTime, modTime)
+	if os.IsNotExist(err) {
+		return fs.ErrorDirNotFound
+	}
+	return err
+}
+
 // Precision of the file system
 func (f *Fs) Precision() (precision time.Duration) {
 	f.precisionOk.Do(func() {
@@ -251,6 +320,16 @@ func (f *Fs) Precision() (precision time.Duration) {
 	return f.precision
 }
 
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() fs.HashSet {
+	return fs.SupportedHashes
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
 // Read the precision
 func (f *Fs) readPrecision() (precision time.Duration) {
 	// Default precision of 1s
@@ -308,7 +387,7 @@ func (f *Fs) readPrecision() (precision time.Duration) {
 // Optional interface: Only implement this if you have a way of
 // deleting all the files quicker than just running Remove() on the
 // result of List()
-func (f *Fs) Purge() error {
+func (f *Fs) Purge(ctx context.Context) error {
 	fi, err := os.Lstat(f.root)
 	if err != nil {
 		return err
@@ -328,7 +407,7 @@ func (f *Fs) Purge() error {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantMove
-func (f *Fs) Move(src fs.Object, remote string) (fs.Object, error) {
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debug(src, "Can't move - not same remote type")
@@ -378,7 +457,7 @@ func (f *Fs) Move(src fs.Object, remote string) (fs.Object, error) {
 // If it isn't possible then return fs.ErrorCantDirMove
 //
 // If destination exists then return fs.ErrorDirExists
-func (f *Fs) DirMove(src fs.Fs) error {
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs) error {
 	srcFs, ok := src.(*Fs)
 	if !ok {
 		fs.Debug(srcFs, "Can't move directory - not same remote type")
@@ -400,6 +479,12 @@ func (f *Fs) DirMove(src fs.Fs) error {
 		return fs.ErrorDirExists
 	}
 
+	// Make the parent directories of the destination
+	err = os.MkdirAll(filepath.Dir(f.root), 0777)
+	if err != nil {
+		return err
+	}
+
 	// Do the move
 	return os.Rename(srcFs.root, f.root)
 }
@@ -424,32 +509,36 @@ func (o *Object) Remote() string {
 	return o.fs.cleanUtf8(o.remote)
 }
 
-// Md5sum calculates the Md5sum of a file returning a lowercase hex string
-func (o *Object) Md5sum() (string, error) {
-	if o.md5sum != "" {
-		return o.md5sum, nil
-	}
-	in, err := os.Open(o.path)
-	if err != nil {
-		fs.Stats.Error()
-		fs.ErrorLog(o, "Failed to open: %s", err)
-		return "", err
-	}
-	hash := md5.New()
-	_, err = io.Copy(hash, in)
-	closeErr := in.Close()
-	if err != nil {
-		fs.Stats.Error()
-		fs.ErrorLog(o, "Failed to read: %s", err)
-		return "", err
-	}
-	if closeErr != nil {
-		fs.Stats.Error()
-		fs.ErrorLog(o, "Failed to close: %s", closeErr)
-		return "", closeErr
+// Hash returns the requested hash of a file as a lowercase hex string
+//
+// All the supported hashes are calculated on the first call and
+// cached for subsequent ones.
+func (o *Object) Hash(ctx context.Context, r fs.HashType) (string, error) {
+	if !fs.SupportedHashes.Contains(r) {
+		return "", fs.ErrHashUnsupported
+	}
+	if o.hashes == nil {
+		in, err := os.Open(o.path)
+		if err != nil {
+			fs.Stats.Error()
+			fs.ErrorLog(o, "Failed to open: %s", err)
+			return "", err
+		}
+		hashes, err := fs.HashStream(fs.NewContextReader(ctx, in))
+		closeErr := in.Close()
+		if err != nil {
+			fs.Stats.Error()
+			fs.ErrorLog(o, "Failed to read: %s", err)
+			return "", err
+		}
+		if closeErr != nil {
+			fs.Stats.Error()
+			fs.ErrorLog(o, "Failed to close: %s", closeErr)
+			return "", closeErr
+		}
+		o.hashes = hashes
 	}
-	o.md5sum = hex.EncodeToString(hash.Sum(nil))
-	return o.md5sum, nil
+	return o.hashes[r], nil
 }
 
 // Size returns the size of an object in bytes
@@ -458,12 +547,12 @@ func (o *Object) Size() int64 {
 }
 
 // ModTime returns the modification time of the object
-func (o *Object) ModTime() time.Time {
+func (o *Object) ModTime(ctx context.Context) time.Time {
 	return o.info.ModTime()
 }
 
 // SetModTime sets the modification time of the local fs object
-func (o *Object) SetModTime(modTime time.Time) {
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) {
 	err := os.Chtimes(o.path, modTime, modTime)
 	if err != nil {
 		fs.Debug(o, "Failed to set mtime on file: %s", err)
@@ -490,16 +579,20 @@ func (o *Object) Storable() bool {
 	return true
 }
 
-// localOpenFile wraps an io.ReadCloser and updates the md5sum of the
+// localOpenFile wraps an io.ReadCloser and updates the hashes of the
 // object that is read
 type localOpenFile struct {
-	o    *Object       // object that is open
-	in   io.ReadCloser // handle we are wrapping
-	hash hash.Hash     // currently accumulating MD5
+	ctx  context.Context // cancels the read
+	o    *Object         // object that is open
+	in   io.ReadCloser   // handle we are wrapping
+	hash *fs.MultiHasher // currently accumulating hashes
 }
 
 // Read bytes from the object - see io.Reader
 func (file *localOpenFile) Read(p []byte) (n int, err error) {
+	if err = file.ctx.Err(); err != nil {
+		return 0, err
+	}
 	n, err = file.in.Read(p)
 	if n > 0 {
 		// Hash routines never return an error
@@ -508,28 +601,45 @@ func (file *localOpenFile) Read(p []byte) (n int, err error) {
 	return
 }
 
-// Close the object and update the md5sum
+// Close the object and update the hashes
 func (file *localOpenFile) Close() (err error) {
 	err = file.in.Close()
 	if err == nil {
-		file.o.md5sum = hex.EncodeToString(file.hash.Sum(nil))
+		file.o.hashes = file.hash.Sums()
 	} else {
-		file.o.md5sum = ""
+		file.o.hashes = nil
 	}
 	return err
 }
 
 // Open an object for read
-func (o *Object) Open() (in io.ReadCloser, err error) {
-	in, err = os.Open(o.path)
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	offset, limit, err := fs.OpenOptionsDecode(o.Size(), options...)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := os.Open(o.path)
 	if err != nil {
 		return
 	}
-	// Update the md5sum as we go along
+	if offset != 0 || limit >= 0 {
+		// Only read the part of the file requested - the hashes
+		// can't be updated from a partial read
+		if offset != 0 {
+			_, err = fd.Seek(offset, io.SeekStart)
+			if err != nil {
+				_ = fd.Close() // ignore error
+				return nil, err
+			}
+		}
+		return fs.NewLimitedReadCloser(fs.NewContextReadCloser(ctx, fd), limit), nil
+	}
+	// Update the hashes as we go along
 	in = &localOpenFile{
+		ctx:  ctx,
 		o:    o,
-		in:   in,
-		hash: md5.New(),
+		in:   fd,
+		hash: fs.NewMultiHasher(),
 	}
 	return
 }
@@ -541,7 +651,7 @@ func (o *Object) mkdirAll() error {
 }
 
 // Update the object from in with modTime and size
-func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
+func (o *Object) Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...fs.PutOption) error {
 	err := o.mkdirAll()
 	if err != nil {
 		return err
@@ -552,9 +662,9 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 		return err
 	}
 
-	// Calculate the md5sum of the object we are reading as we go along
-	hash := md5.New()
-	in = io.TeeReader(in, hash)
+	// Calculate the hashes of the object we are reading as we go along
+	hash := fs.NewMultiHasher()
+	in = io.TeeReader(fs.NewContextReader(ctx, in), hash)
 
 	_, err = io.Copy(out, in)
 	outErr := out.Close()
@@ -565,11 +675,11 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 		return outErr
 	}
 
-	// All successful so update the md5sum
-	o.md5sum = hex.EncodeToString(hash.Sum(nil))
+	// All successful so update the hashes
+	o.hashes = hash.Sums()
 
 	// Set the mtime
-	o.SetModTime(modTime)
+	o.SetModTime(ctx, modTime)
 
 	// ReRead info now that we have finished
 	return o.lstat()
@@ -583,7 +693,7 @@ func (o *Object) lstat() error {
 }
 
 // Remove an object
-func (o *Object) Remove() error {
+func (o *Object) Remove(ctx context.Context) error {
 	return os.Remove(o.path)
 }
 
@@ -684,9 +794,12 @@ func cleanWindowsName(f *Fs, name string) string {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs       = &Fs{}
-	_ fs.Purger   = &Fs{}
-	_ fs.Mover    = &Fs{}
-	_ fs.DirMover = &Fs{}
-	_ fs.Object   = &Object{}
+	_ fs.Fs               = &Fs{}
+	_ fs.Purger           = &Fs{}
+	_ fs.Mover            = &Fs{}
+	_ fs.DirMover         = &Fs{}
+	_ fs.DirLister        = &Fs{}
+	_ fs.DirMaker         = &Fs{}
+	_ fs.DirModTimeSetter = &Fs{}
+	_ fs.Object           = &Object{}
 )
@@ -50,10 +50,10 @@ type IdentitySet struct {
 
 // Quota groups storage space quota-related information on OneDrive into a single structure.
 type Quota struct {
-	Total     int    `json:"total"`
-	Used      int    `json:"used"`
-	Remaining int    `json:"remaining"`
-	Deleted   int    `json:"deleted"`
+	Total     int64  `json:"total"`
+	Used      int64  `json:"used"`
+	Remaining int64  `json:"remaining"`
+	Deleted   int64  `json:"deleted"`
 	State     string `json:"state"` // normal | nearing | critical | exceeded
 }
 
@@ -100,7 +100,7 @@ type FolderFacet struct {
 
 // HashesType groups different types of hashes into a single structure, for an item on OneDrive.
 type HashesType struct {
-	Sha1Hash  string `json:"sha1Hash"`  // base64 encoded SHA1 hash for the contents of the file (if available)
+	Sha1Hash  string `json:"sha1Hash"`  // hex encoded SHA1 hash for the contents of the file (if available)
 	Crc32Hash string `json:"crc32Hash"` // base64 encoded CRC32 value of the file (if available)
 }
 
@@ -200,6 +200,12 @@ type CopyItemRequest struct {
 	Name            *string       `json:"name"`            // Optional The new name for the copy. If this isn't provided, the same name will be used as the original.
 }
 
+// MoveItemRequest is the request to move or rename an item
+type MoveItemRequest struct {
+	ParentReference *ItemReference `json:"parentReference,omitempty"` // Reference to the new parent item, if moving it.
+	Name            string         `json:"name,omitempty"`            // The new name for the item, if renaming it.
+}
+
 // AsyncOperationStatus provides information on the status of a asynchronous job progress.
 //
 // The following API calls return AsyncOperationStatus resources:
@@ -211,3 +217,21 @@ type AsyncOperationStatus struct {
 	PercentageComplete float64 `json:"percentageComplete"` // An float value between 0 and 100 that indicates the percentage complete.
 	Status             string  `json:"status"`             // A string value that maps to an enumeration of possible values about the status of the job. "notStarted | inProgress | completed | updating | failed | deletePending | deleteFailed | waiting"
 }
+
+// CreateShareLinkRequest is the request to create a sharing link
+type CreateShareLinkRequest struct {
+	Type string `json:"type"` // The type of sharing link to create. Either "view", "edit" or "embed".
+}
+
+// SharingLink is the link facet of a Permission
+type SharingLink struct {
+	Type   string `json:"type"`   // The type of the link created.
+	WebURL string `json:"webUrl"` // A URL that opens the item in the browser on the OneDrive website.
+}
+
+// Permission is the response to creating a sharing link
+type Permission struct {
+	ID    string      `json:"id"`    // The unique identifier of the permission among all permissions on the item.
+	Roles []string    `json:"roles"` // The type of permission, eg "read".
+	Link  SharingLink `json:"link"`  // Provides the link details of the current permission, if it is a link type permissions.
+}
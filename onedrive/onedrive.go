@@ -4,6 +4,7 @@ package onedrive
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -83,6 +84,7 @@ type Fs struct {
 	root     string             // the path we are working on
 	dirCache *dircache.DirCache // Map of directory path to directory id
 	pacer    *pacer.Pacer       // pacer for API calls
+	features *fs.Features       // optional features
 }
 
 // Object describes a one drive object
@@ -95,6 +97,7 @@ type Object struct {
 	size        int64     // size of the object
 	modTime     time.Time // modification time of the object
 	id          string    // ID of the object
+	sha1        string    // SHA-1 of the object content
 }
 
 // ------------------------------------------------------------
@@ -140,13 +143,13 @@ func shouldRetry(resp *http.Response, err error) (bool, error) {
 }
 
 // readMetaDataForPath reads the metadata from the path
-func (f *Fs) readMetaDataForPath(path string) (info *api.Item, resp *http.Response, err error) {
+func (f *Fs) readMetaDataForPath(ctx context.Context, path string) (info *api.Item, resp *http.Response, err error) {
 	opts := rest.Opts{
 		Method: "GET",
 		Path:   "/drive/root:/" + replaceReservedChars(path),
 	}
-	err = f.pacer.Call(func() (bool, error) {
-		resp, err = f.srv.CallJSON(&opts, nil, &info)
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &info)
 		return shouldRetry(resp, err)
 	})
 	return info, resp, err
@@ -168,6 +171,7 @@ func errorHandler(resp *http.Response) error {
 
 // NewFs constructs an Fs from the path, container:path
 func NewFs(name, root string) (fs.Fs, error) {
+	ctx := context.Background()
 	root = parsePath(root)
 	oAuthClient, err := oauthutil.NewClient(name, oauthConfig)
 	if err != nil {
@@ -180,10 +184,14 @@ func NewFs(name, root string) (fs.Fs, error) {
 		srv:   rest.NewClient(oAuthClient).SetRoot(rootURL),
 		pacer: pacer.New().SetMinSleep(minSleep).SetMaxSleep(maxSleep).SetDecayConstant(decayConstant),
 	}
+	f.features = (&fs.Features{
+		CaseInsensitive:         true,
+		CanHaveEmptyDirectories: true,
+	}).Fill(f)
 	f.srv.SetErrorHandler(errorHandler)
 
 	// Get rootID
-	rootInfo, _, err := f.readMetaDataForPath("")
+	rootInfo, _, err := f.readMetaDataForPath(ctx, "")
 	if err != nil || rootInfo.ID == "" {
 		return nil, fmt.Errorf("Failed to get root: %v", err)
 	}
@@ -191,20 +199,22 @@ func NewFs(name, root string) (fs.Fs, error) {
 	f.dirCache = dircache.New(root, rootInfo.ID, f)
 
 	// Find the current root
-	err = f.dirCache.FindRoot(false)
+	err = f.dirCache.FindRoot(ctx, false)
 	if err != nil {
 		// Assume it is a file
 		newRoot, remote := dircache.SplitPath(root)
 		newF := *f
+		features := *f.features
+		newF.features = features.Fill(&newF)
 		newF.dirCache = dircache.New(newRoot, rootInfo.ID, &newF)
 		newF.root = newRoot
 		// Make new Fs which is the parent
-		err = newF.dirCache.FindRoot(false)
+		err = newF.dirCache.FindRoot(ctx, false)
 		if err != nil {
 			// No root so return old f
 			return f, nil
 		}
-		obj := newF.newObjectWithInfo(remote, nil)
+		obj := newF.newObjectWithInfo(ctx, remote, nil)
 		if obj == nil {
 			// File doesn't exist so return old f
 			return f, nil
@@ -226,7 +236,7 @@ func (f *Fs) rootSlash() string {
 // Return an Object from a path
 //
 // May return nil if an error occurred
-func (f *Fs) newObjectWithInfo(remote string, info *api.Item) fs.Object {
+func (f *Fs) newObjectWithInfo(ctx context.Context, remote string, info *api.Item) fs.Object {
 	o := &Object{
 		fs:     f,
 		remote: remote,
@@ -235,7 +245,7 @@ func (f *Fs) newObjectWithInfo(remote string, info *api.Item) fs.Object {
 		// Set info
 		o.setMetaData(info)
 	} else {
-		err := o.readMetaData() // reads info and meta, returning an error
+		err := o.readMetaData(ctx) // reads info and meta, returning an error
 		if err != nil {
 			// logged already FsDebug("Failed to read info: %s", err)
 			return nil
@@ -247,12 +257,12 @@ func (f *Fs) newObjectWithInfo(remote string, info *api.Item) fs.Object {
 // NewFsObject returns an Object from a path
 //
 // May return nil if an error occurred
-func (f *Fs) NewFsObject(remote string) fs.Object {
-	return f.newObjectWithInfo(remote, nil)
+func (f *Fs) NewFsObject(ctx context.Context, remote string) fs.Object {
+	return f.newObjectWithInfo(ctx, remote, nil)
 }
 
 // FindLeaf finds a directory of name leaf in the folder with ID pathID
-func (f *Fs) FindLeaf(pathID, leaf string) (pathIDOut string, found bool, err error) {
+func (f *Fs) FindLeaf(ctx context.Context, pathID, leaf string) (pathIDOut string, found bool, err error) {
 	// fs.Debug(f, "FindLeaf(%q, %q)", pathID, leaf)
 	parent, ok := f.dirCache.GetInv(pathID)
 	if !ok {
@@ -265,7 +275,7 @@ func (f *Fs) FindLeaf(pathID, leaf string) (pathIDOut string, found bool, err er
 	if f.dirCache.FoundRoot() {
 		path = f.rootSlash() + path
 	}
-	info, resp, err := f.readMetaDataForPath(path)
+	info, resp, err := f.readMetaDataForPath(ctx, path)
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			return "", false, nil
@@ -279,7 +289,7 @@ func (f *Fs) FindLeaf(pathID, leaf string) (pathIDOut string, found bool, err er
 }
 
 // CreateDir makes a directory with pathID as parent and name leaf
-func (f *Fs) CreateDir(pathID, leaf string) (newID string, err error) {
+func (f *Fs) CreateDir(ctx context.Context, pathID, leaf string) (newID string, err error) {
 	// fs.Debug(f, "CreateDir(%q, %q)\n", pathID, leaf)
 	var resp *http.Response
 	var info *api.Item
@@ -291,8 +301,8 @@ func (f *Fs) CreateDir(pathID, leaf string) (newID string, err error) {
 		Name:             replaceReservedChars(leaf),
 		ConflictBehavior: "fail",
 	}
-	err = f.pacer.Call(func() (bool, error) {
-		resp, err = f.srv.CallJSON(&opts, &mkdir, &info)
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, &mkdir, &info)
 		return shouldRetry(resp, err)
 	})
 	if err != nil {
@@ -314,7 +324,7 @@ type listAllFn func(*api.Item) bool
 // Lists the directory required calling the user function on each item found
 //
 // If the user fn ever returns true then it early exits with found = true
-func (f *Fs) listAll(dirID string, directoriesOnly bool, filesOnly bool, fn listAllFn) (found bool, err error) {
+func (f *Fs) listAll(ctx context.Context, dirID string, directoriesOnly bool, filesOnly bool, fn listAllFn) (found bool, err error) {
 	// Top parameter asks for bigger pages of data
 	// https://dev.onedrive.com/odata/optional-query-parameters.htm
 	opts := rest.Opts{
@@ -325,13 +335,11 @@ OUTER:
 	for {
 		var result api.ListChildrenResponse
 		var resp *http.Response
-		err = f.pacer.Call(func() (bool, error) {
-			resp, err = f.srv.CallJSON(&opts, nil, &result)
+		err = f.pacer.Call(ctx, func() (bool, error) {
+			resp, err = f.srv.CallJSON(ctx, &opts, nil, &result)
 			return shouldRetry(resp, err)
 		})
 		if err != nil {
-			fs.Stats.Error()
-			fs.ErrorLog(f, "Couldn't list files: %v", err)
 			break
 		}
 		if len(result.Value) == 0 {
@@ -373,11 +381,15 @@ OUTER:
 //
 // This fetches the minimum amount of stuff but does more API calls
 // which makes it slow
-func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) error {
-	var subError error
+func (f *Fs) listDirRecursive(ctx context.Context, dirID string, path string, out fs.ListOpts) error {
+	var (
+		subErrorMu sync.Mutex
+		subError   error
+		aborted    bool
+	)
 	// Make the API request
 	var wg sync.WaitGroup
-	_, err := f.listAll(dirID, false, false, func(info *api.Item) bool {
+	_, err := f.listAll(ctx, dirID, false, false, func(info *api.Item) bool {
 		// Recurse on directories
 		if info.Folder != nil {
 			wg.Add(1)
@@ -385,21 +397,31 @@ func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) err
 			fs.Debug(f, "Reading %s", folder)
 			go func() {
 				defer wg.Done()
-				err := f.listDirRecursive(info.ID, folder, out)
+				err := f.listDirRecursive(ctx, info.ID, folder, out)
 				if err != nil {
+					subErrorMu.Lock()
 					subError = err
-					fs.ErrorLog(f, "Error reading %s:%s", folder, err)
+					subErrorMu.Unlock()
 				}
 			}()
 		} else {
-			if fs := f.newObjectWithInfo(path+info.Name, info); fs != nil {
-				out <- fs
+			if o := f.newObjectWithInfo(ctx, path+info.Name, info); o != nil {
+				if out.Add(o) {
+					aborted = true
+					return true
+				}
 			}
 		}
 		return false
 	})
 	wg.Wait()
 	fs.Debug(f, "Finished reading %s", path)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if aborted {
+		return fs.ErrorListAborted
+	}
 	if err != nil {
 		return err
 	}
@@ -409,37 +431,31 @@ func (f *Fs) listDirRecursive(dirID string, path string, out fs.ObjectsChan) err
 	return nil
 }
 
-// List walks the path returning a channel of Objects
-func (f *Fs) List() fs.ObjectsChan {
-	out := make(fs.ObjectsChan, fs.Config.Checkers)
-	go func() {
-		defer close(out)
-		err := f.dirCache.FindRoot(false)
-		if err != nil {
-			fs.Stats.Error()
-			fs.ErrorLog(f, "Couldn't find root: %s", err)
-		} else {
-			err = f.listDirRecursive(f.dirCache.RootID(), "", out)
-			if err != nil {
-				fs.Stats.Error()
-				fs.ErrorLog(f, "List failed: %s", err)
-			}
-		}
-	}()
-	return out
+// List walks the path into out
+func (f *Fs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
+	err := f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		out.SetError(err)
+		return
+	}
+	err = f.listDirRecursive(ctx, f.dirCache.RootID(), "", out)
+	if err != nil && err != fs.ErrorListAborted {
+		out.SetError(err)
+	}
 }
 
 // ListDir lists the directories
-func (f *Fs) ListDir() fs.DirChan {
+func (f *Fs) ListDir(ctx context.Context) fs.DirChan {
 	out := make(fs.DirChan, fs.Config.Checkers)
 	go func() {
 		defer close(out)
-		err := f.dirCache.FindRoot(false)
+		err := f.dirCache.FindRoot(ctx, false)
 		if err != nil {
 			fs.Stats.Error()
 			fs.ErrorLog(f, "Couldn't find root: %s", err)
 		} else {
-			_, err := f.listAll(f.dirCache.RootID(), true, false, func(item *api.Item) bool {
+			_, err := f.listAll(ctx, f.dirCache.RootID(), true, false, func(item *api.Item) bool {
 				dir := &fs.Dir{
 					Name:  item.Name,
 					Bytes: -1,
@@ -449,10 +465,14 @@ func (f *Fs) ListDir() fs.DirChan {
 				if item.Folder != nil {
 					dir.Count = item.Folder.ChildCount
 				}
-				out <- dir
+				select {
+				case out <- dir:
+				case <-ctx.Done():
+					return true
+				}
 				return false
 			})
-			if err != nil {
+			if err != nil && ctx.Err() == nil {
 				fs.Stats.Error()
 				fs.ErrorLog(f, "ListDir failed: %s", err)
 			}
@@ -461,15 +481,58 @@ func (f *Fs) ListDir() fs.DirChan {
 	return out
 }
 
+// ListDirEntries lists the objects and directories directly in dir
+func (f *Fs) ListDirEntries(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	err = f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	_, err = f.listAll(ctx, directoryID, false, false, func(info *api.Item) bool {
+		remote := prefix + info.Name
+		if info.Folder != nil {
+			// Cache the directory for when it is listed
+			f.dirCache.Put(remote, info.ID)
+			d := &fs.Dir{
+				Name:  remote,
+				When:  time.Time(info.LastModifiedDateTime),
+				Bytes: -1,
+				Count: info.Folder.ChildCount,
+			}
+			if info.FileSystemInfo != nil {
+				d.When = time.Time(info.FileSystemInfo.LastModifiedDateTime)
+			}
+			dirs = append(dirs, d)
+		} else if o := f.newObjectWithInfo(ctx, remote, info); o != nil {
+			objects = append(objects, o)
+		}
+		return ctx.Err() != nil
+	})
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return objects, dirs, nil
+}
+
 // Creates from the parameters passed in a half finished Object which
 // must have setMetaData called on it
 //
 // Returns the object, leaf, directoryID and error
 //
 // Used to create new objects
-func (f *Fs) createObject(remote string, modTime time.Time, size int64) (o *Object, leaf string, directoryID string, err error) {
+func (f *Fs) createObject(ctx context.Context, remote string, modTime time.Time, size int64) (o *Object, leaf string, directoryID string, err error) {
 	// Create the directory for the object if it doesn't exist
-	leaf, directoryID, err = f.dirCache.FindPath(remote, true)
+	leaf, directoryID, err = f.dirCache.FindPath(ctx, remote, true)
 	if err != nil {
 		return nil, leaf, directoryID, err
 	}
@@ -486,45 +549,45 @@ func (f *Fs) createObject(remote string, modTime time.Time, size int64) (o *Obje
 // Copy the reader in to the new object which is returned
 //
 // The new object may have been created if an error is returned
-func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs.Object, error) {
-	o, _, _, err := f.createObject(remote, modTime, size)
+func (f *Fs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
+	o, _, _, err := f.createObject(ctx, remote, modTime, size)
 	if err != nil {
 		return nil, err
 	}
-	return o, o.Update(in, modTime, size)
+	return o, o.Update(ctx, in, modTime, size, options...)
 }
 
 // Mkdir creates the container if it doesn't exist
-func (f *Fs) Mkdir() error {
-	return f.dirCache.FindRoot(true)
+func (f *Fs) Mkdir(ctx context.Context) error {
+	return f.dirCache.FindRoot(ctx, true)
 }
 
 // deleteObject removes an object by ID
-func (f *Fs) deleteObject(id string) error {
+func (f *Fs) deleteObject(ctx context.Context, id string) error {
 	opts := rest.Opts{
 		Method:     "DELETE",
 		Path:       "/drive/items/" + id,
 		NoResponse: true,
 	}
-	return f.pacer.Call(func() (bool, error) {
-		resp, err := f.srv.Call(&opts)
+	return f.pacer.Call(ctx, func() (bool, error) {
+		resp, err := f.srv.Call(ctx, &opts)
 		return shouldRetry(resp, err)
 	})
 }
 
 // purgeCheck removes the root directory, if check is set then it
 // refuses to do so if it has anything in
-func (f *Fs) purgeCheck(check bool) error {
+func (f *Fs) purgeCheck(ctx context.Context, check bool) error {
 	if f.root == "" {
 		return fmt.Errorf("Can't purge root directory")
 	}
 	dc := f.dirCache
-	err := dc.FindRoot(false)
+	err := dc.FindRoot(ctx, false)
 	if err != nil {
 		return err
 	}
 	rootID := dc.RootID()
-	item, _, err := f.readMetaDataForPath(f.root)
+	item, _, err := f.readMetaDataForPath(ctx, f.root)
 	if err != nil {
 		return err
 	}
@@ -534,7 +597,7 @@ func (f *Fs) purgeCheck(check bool) error {
 	if check && item.Folder.ChildCount != 0 {
 		return fmt.Errorf("Folder not empty")
 	}
-	err = f.deleteObject(rootID)
+	err = f.deleteObject(ctx, rootID)
 	if err != nil {
 		return err
 	}
@@ -548,8 +611,83 @@ func (f *Fs) purgeCheck(check bool) error {
 // Rmdir deletes the root folder
 //
 // Returns an error if it isn't empty
-func (f *Fs) Rmdir() error {
-	return f.purgeCheck(true)
+func (f *Fs) Rmdir(ctx context.Context) error {
+	return f.purgeCheck(ctx, true)
+}
+
+// MakeDir makes the directory dir and any parents it needs
+func (f *Fs) MakeDir(ctx context.Context, dir string) error {
+	err := f.dirCache.FindRoot(ctx, true)
+	if err != nil {
+		return err
+	}
+	_, err = f.dirCache.FindDir(ctx, dir, true)
+	return err
+}
+
+// RemoveDir removes the directory dir
+//
+// Returns an error if it isn't empty
+func (f *Fs) RemoveDir(ctx context.Context, dir string) error {
+	err := f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return err
+	}
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return err
+	}
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/drive/items/" + directoryID,
+	}
+	var item *api.Item
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, nil, &item)
+		return shouldRetry(resp, err)
+	})
+	if err != nil {
+		return err
+	}
+	if item.Folder == nil {
+		return fmt.Errorf("Not a folder")
+	}
+	if item.Folder.ChildCount != 0 {
+		return fmt.Errorf("Folder not empty")
+	}
+	err = f.deleteObject(ctx, directoryID)
+	if err != nil {
+		return err
+	}
+	f.dirCache.FlushDir(dir)
+	return nil
+}
+
+// SetDirModTime sets the modification time of the directory dir
+func (f *Fs) SetDirModTime(ctx context.Context, dir string, modTime time.Time) error {
+	err := f.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return err
+	}
+	directoryID, err := f.dirCache.FindDir(ctx, dir, false)
+	if err != nil {
+		return err
+	}
+	opts := rest.Opts{
+		Method: "PATCH",
+		Path:   "/drive/items/" + directoryID,
+	}
+	update := api.SetFileSystemInfo{
+		FileSystemInfo: api.FileSystemInfoFacet{
+			CreatedDateTime:      api.Timestamp(modTime),
+			LastModifiedDateTime: api.Timestamp(modTime),
+		},
+	}
+	var info *api.Item
+	return f.pacer.Call(ctx, func() (bool, error) {
+		resp, err := f.srv.CallJSON(ctx, &opts, &update, &info)
+		return shouldRetry(resp, err)
+	})
 }
 
 // Precision return the precision of this Fs
@@ -557,8 +695,72 @@ func (f *Fs) Precision() time.Duration {
 	return time.Second
 }
 
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() fs.HashSet {
+	return fs.HashSet(fs.HashSHA1)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// About gets quota information
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	var drive api.Drive
+	opts := rest.Opts{
+		Method: "GET",
+		Path:   "/drive",
+	}
+	var resp *http.Response
+	var err error
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, nil, &drive)
+		return shouldRetry(resp, err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("about failed: %v", err)
+	}
+	q := drive.Quota
+	usage := &fs.Usage{
+		Total:   fs.NewUsageValue(q.Total),
+		Used:    fs.NewUsageValue(q.Used),
+		Trashed: fs.NewUsageValue(q.Deleted),
+		Free:    fs.NewUsageValue(q.Remaining),
+	}
+	return usage, nil
+}
+
+// PublicLink creates a view only sharing link to the file or
+// directory at remote which anyone can use
+//
+// One drive links don't expire so expire is ignored
+func (f *Fs) PublicLink(ctx context.Context, remote string, expire time.Duration) (string, error) {
+	info, _, err := f.readMetaDataForPath(ctx, f.rootSlash()+remote)
+	if err != nil {
+		return "", err
+	}
+	opts := rest.Opts{
+		Method: "POST",
+		Path:   "/drive/items/" + info.ID + "/action.createLink",
+	}
+	request := api.CreateShareLinkRequest{
+		Type: "view",
+	}
+	var resp *http.Response
+	var result api.Permission
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, &request, &result)
+		return shouldRetry(resp, err)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create link: %v", err)
+	}
+	return result.Link.WebURL, nil
+}
+
 // waitForJob waits for the job with status in url to complete
-func (f *Fs) waitForJob(location string, o *Object) error {
+func (f *Fs) waitForJob(ctx context.Context, location string, o *Object) error {
 	deadline := time.Now().Add(fs.Config.Timeout)
 	for time.Now().Before(deadline) {
 		opts := rest.Opts{
@@ -568,8 +770,8 @@ func (f *Fs) waitForJob(location string, o *Object) error {
 		}
 		var resp *http.Response
 		var err error
-		err = f.pacer.Call(func() (bool, error) {
-			resp, err = f.srv.Call(&opts)
+		err = f.pacer.Call(ctx, func() (bool, error) {
+			resp, err = f.srv.Call(ctx, &opts)
 			return shouldRetry(resp, err)
 		})
 		if err != nil {
@@ -593,7 +795,11 @@ func (f *Fs) waitForJob(location string, o *Object) error {
 			o.setMetaData(&info)
 			return nil
 		}
-		time.Sleep(1 * time.Second)
+		select {
+		case <-time.After(1 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 	return fmt.Errorf("Async operation didn't complete after %v", fs.Config.Timeout)
 }
@@ -607,19 +813,19 @@ func (f *Fs) waitForJob(location string, o *Object) error {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantCopy
-func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debug(src, "Can't copy - not same remote type")
 		return nil, fs.ErrorCantCopy
 	}
-	err := srcObj.readMetaData()
+	err := srcObj.readMetaData(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create temporary object
-	dstObj, leaf, directoryID, err := f.createObject(remote, srcObj.modTime, srcObj.size)
+	dstObj, leaf, directoryID, err := f.createObject(ctx, remote, srcObj.modTime, srcObj.size)
 	if err != nil {
 		return nil, err
 	}
@@ -639,8 +845,8 @@ func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
 		},
 	}
 	var resp *http.Response
-	err = f.pacer.Call(func() (bool, error) {
-		resp, err = f.srv.CallJSON(&opts, &copy, nil)
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, &copy, nil)
 		return shouldRetry(resp, err)
 	})
 	if err != nil {
@@ -654,20 +860,92 @@ func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
 	}
 
 	// Wait for job to finish
-	err = f.waitForJob(location, dstObj)
+	err = f.waitForJob(ctx, location, dstObj)
 	if err != nil {
 		return nil, err
 	}
 	return dstObj, nil
 }
 
+// DirMove moves src directory to this remote using server side move
+// operations.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantDirMove
+//
+// If destination exists then return fs.ErrorDirExists
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs) error {
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		fs.Debug(srcFs, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+	if srcFs.root == "" {
+		fs.Debug(srcFs, "Can't move root directory")
+		return fs.ErrorCantDirMove
+	}
+
+	// Find the source directory
+	err := srcFs.dirCache.FindRoot(ctx, false)
+	if err != nil {
+		return err
+	}
+	srcID := srcFs.dirCache.RootID()
+
+	// Check if destination exists
+	f.dirCache.ResetRoot()
+	err = f.dirCache.FindRoot(ctx, false)
+	if err == nil {
+		return fs.ErrorDirExists
+	} else if err != fs.ErrorDirNotFound {
+		return err
+	}
+
+	// Find ID of parent
+	leaf, directoryID, err := f.dirCache.FindPath(ctx, f.root, true)
+	if err != nil {
+		return err
+	}
+
+	// Do the move
+	opts := rest.Opts{
+		Method: "PATCH",
+		Path:   "/drive/items/" + srcID,
+	}
+	move := api.MoveItemRequest{
+		Name: replaceReservedChars(leaf),
+		ParentReference: &api.ItemReference{
+			ID: directoryID,
+		},
+	}
+	var resp *http.Response
+	var info api.Item
+	err = f.pacer.Call(ctx, func() (bool, error) {
+		resp, err = f.srv.CallJSON(ctx, &opts, &move, &info)
+		return shouldRetry(resp, err)
+	})
+	if err != nil {
+		return err
+	}
+	srcFs.dirCache.ResetRoot()
+	f.dirCache.ResetRoot()
+	return nil
+}
+
+// DirCacheFlush resets the directory cache - used when another Fs on
+// the same remote has changed the directories, eg with DirMove
+func (f *Fs) DirCacheFlush() {
+	f.dirCache.ResetRoot()
+}
+
 // Purge deletes all the files and the container
 //
 // Optional interface: Only implement this if you have a way of
 // deleting all the files quicker than just running Remove() on the
 // result of List()
-func (f *Fs) Purge() error {
-	return f.purgeCheck(false)
+func (f *Fs) Purge(ctx context.Context) error {
+	return f.purgeCheck(ctx, false)
 }
 
 // ------------------------------------------------------------
@@ -695,14 +973,21 @@ func (o *Object) srvPath() string {
 	return replaceReservedChars(o.fs.rootSlash() + o.remote)
 }
 
-// Md5sum returns the Md5sum of an object returning a lowercase hex string
-func (o *Object) Md5sum() (string, error) {
-	return "", nil // not supported by one drive
+// Hash returns the SHA-1 of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t fs.HashType) (string, error) {
+	if t != fs.HashSHA1 {
+		return "", fs.ErrHashUnsupported
+	}
+	err := o.readMetaData(ctx)
+	if err != nil {
+		return "", err
+	}
+	return o.sha1, nil
 }
 
 // Size returns the size of an object in bytes
 func (o *Object) Size() int64 {
-	err := o.readMetaData()
+	err := o.readMetaData(context.Background())
 	if err != nil {
 		fs.Log(o, "Failed to read metadata: %s", err)
 		return 0
@@ -720,20 +1005,23 @@ func (o *Object) setMetaData(info *api.Item) {
 		o.modTime = time.Time(info.LastModifiedDateTime)
 	}
 	o.id = info.ID
+	if info.File != nil {
+		o.sha1 = strings.ToLower(info.File.Hashes.Sha1Hash)
+	}
 }
 
 // readMetaData gets the metadata if it hasn't already been fetched
 //
 // it also sets the info
-func (o *Object) readMetaData() (err error) {
+func (o *Object) readMetaData(ctx context.Context) (err error) {
 	if o.hasMetaData {
 		return nil
 	}
-	// leaf, directoryID, err := o.fs.dirCache.FindPath(o.remote, false)
+	// leaf, directoryID, err := o.fs.dirCache.FindPath(ctx, o.remote, false)
 	// if err != nil {
 	// 	return err
 	// }
-	info, _, err := o.fs.readMetaDataForPath(o.srvPath())
+	info, _, err := o.fs.readMetaDataForPath(ctx, o.srvPath())
 	if err != nil {
 		fs.Debug(o, "Failed to read info: %s", err)
 		return err
@@ -747,8 +1035,8 @@ func (o *Object) readMetaData() (err error) {
 //
 // It attempts to read the objects mtime and if that isn't present the
 // LastModified returned in the http headers
-func (o *Object) ModTime() time.Time {
-	err := o.readMetaData()
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Log(o, "Failed to read metadata: %s", err)
 		return time.Now()
@@ -757,7 +1045,7 @@ func (o *Object) ModTime() time.Time {
 }
 
 // setModTime sets the modification time of the local fs object
-func (o *Object) setModTime(modTime time.Time) (*api.Item, error) {
+func (o *Object) setModTime(ctx context.Context, modTime time.Time) (*api.Item, error) {
 	opts := rest.Opts{
 		Method: "PATCH",
 		Path:   "/drive/root:/" + o.srvPath(),
@@ -769,16 +1057,16 @@ func (o *Object) setModTime(modTime time.Time) (*api.Item, error) {
 		},
 	}
 	var info *api.Item
-	err := o.fs.pacer.Call(func() (bool, error) {
-		resp, err := o.fs.srv.CallJSON(&opts, &update, &info)
+	err := o.fs.pacer.Call(ctx, func() (bool, error) {
+		resp, err := o.fs.srv.CallJSON(ctx, &opts, &update, &info)
 		return shouldRetry(resp, err)
 	})
 	return info, err
 }
 
 // SetModTime sets the modification time of the local fs object
-func (o *Object) SetModTime(modTime time.Time) {
-	info, err := o.setModTime(modTime)
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) {
+	info, err := o.setModTime(ctx, modTime)
 	if err != nil {
 		fs.Stats.Error()
 		fs.ErrorLog(o, "Failed to update remote mtime: %v", err)
@@ -792,41 +1080,42 @@ func (o *Object) Storable() bool {
 }
 
 // Open an object for read
-func (o *Object) Open() (in io.ReadCloser, err error) {
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
 	if o.id == "" {
 		return nil, fmt.Errorf("Can't download no id")
 	}
 	var resp *http.Response
 	opts := rest.Opts{
-		Method: "GET",
-		Path:   "/drive/items/" + o.id + "/content",
+		Method:       "GET",
+		Path:         "/drive/items/" + o.id + "/content",
+		ExtraHeaders: fs.OpenOptionHeaders(nil, options...),
 	}
-	err = o.fs.pacer.Call(func() (bool, error) {
-		resp, err = o.fs.srv.Call(&opts)
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
 		return shouldRetry(resp, err)
 	})
 	if err != nil {
 		return nil, err
 	}
-	return resp.Body, err
+	return fs.OpenOptionsResponse(resp, o.Size(), options...)
 }
 
 // createUploadSession creates an upload session for the object
-func (o *Object) createUploadSession() (response *api.CreateUploadResponse, err error) {
+func (o *Object) createUploadSession(ctx context.Context) (response *api.CreateUploadResponse, err error) {
 	opts := rest.Opts{
 		Method: "POST",
 		Path:   "/drive/root:/" + o.srvPath() + ":/upload.createSession",
 	}
 	var resp *http.Response
-	err = o.fs.pacer.Call(func() (bool, error) {
-		resp, err = o.fs.srv.CallJSON(&opts, nil, &response)
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
+		resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, &response)
 		return shouldRetry(resp, err)
 	})
 	return
 }
 
 // uploadFragment uploads a part
-func (o *Object) uploadFragment(url string, start int64, totalSize int64, buf []byte) (err error) {
+func (o *Object) uploadFragment(ctx context.Context, url string, start int64, totalSize int64, buf []byte) (err error) {
 	bufSize := int64(len(buf))
 	opts := rest.Opts{
 		Method:        "PUT",
@@ -838,15 +1127,15 @@ func (o *Object) uploadFragment(url string, start int64, totalSize int64, buf []
 	}
 	var response api.UploadFragmentResponse
 	var resp *http.Response
-	err = o.fs.pacer.Call(func() (bool, error) {
-		resp, err = o.fs.srv.CallJSON(&opts, nil, &response)
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
+		resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, &response)
 		return shouldRetry(resp, err)
 	})
 	return err
 }
 
 // cancelUploadSession cancels an upload session
-func (o *Object) cancelUploadSession(url string) (err error) {
+func (o *Object) cancelUploadSession(ctx context.Context, url string) (err error) {
 	opts := rest.Opts{
 		Method:     "DELETE",
 		Path:       url,
@@ -854,22 +1143,22 @@ func (o *Object) cancelUploadSession(url string) (err error) {
 		NoResponse: true,
 	}
 	var resp *http.Response
-	err = o.fs.pacer.Call(func() (bool, error) {
-		resp, err = o.fs.srv.Call(&opts)
+	err = o.fs.pacer.Call(ctx, func() (bool, error) {
+		resp, err = o.fs.srv.Call(ctx, &opts)
 		return shouldRetry(resp, err)
 	})
 	return
 }
 
 // uploadMultipart uploads a file using multipart upload
-func (o *Object) uploadMultipart(in io.Reader, size int64) (err error) {
+func (o *Object) uploadMultipart(ctx context.Context, in io.Reader, size int64) (err error) {
 	if chunkSize%(320*1024) != 0 {
 		return fmt.Errorf("Chunk size %d is not a multiple of 320k", chunkSize)
 	}
 
 	// Create upload session
 	fs.Debug(o, "Starting multipart upload")
-	session, err := o.createUploadSession()
+	session, err := o.createUploadSession(ctx)
 	if err != nil {
 		return err
 	}
@@ -879,7 +1168,8 @@ func (o *Object) uploadMultipart(in io.Reader, size int64) (err error) {
 	defer func() {
 		if err != nil {
 			fs.Debug(o, "Cancelling multipart upload")
-			cancelErr := o.cancelUploadSession(uploadURL)
+			// Use a fresh context so the session is cancelled even if ctx was
+			cancelErr := o.cancelUploadSession(context.Background(), uploadURL)
 			if cancelErr != nil {
 				fs.Log(o, "Failed to cancel multipart upload: %v", err)
 			}
@@ -901,7 +1191,7 @@ func (o *Object) uploadMultipart(in io.Reader, size int64) (err error) {
 			return err
 		}
 		fs.Debug(o, "Uploading segment %d/%d size %d", position, size, n)
-		err = o.uploadFragment(uploadURL, position, size, buf)
+		err = o.uploadFragment(ctx, uploadURL, position, size, buf)
 		if err != nil {
 			return err
 		}
@@ -915,7 +1205,18 @@ func (o *Object) uploadMultipart(in io.Reader, size int64) (err error) {
 // Update the object with the contents of the io.Reader, modTime and size
 //
 // The new object may have been created if an error is returned
-func (o *Object) Update(in io.Reader, modTime time.Time, size int64) (err error) {
+func (o *Object) Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...fs.PutOption) (err error) {
+	// The length must be known in advance to choose the upload
+	// method and for the upload session so spool streams of
+	// unknown length to disk
+	if size < 0 {
+		fd, n, cleanup, err := fs.SpoolToTempFile(fs.NewContextReader(ctx, in), "rclone-onedrive-")
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		in, size = fd, n
+	}
 	var info *api.Item
 	if size <= int64(uploadCutoff) {
 		// This is for less than 100 MB of content
@@ -925,8 +1226,8 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) (err error)
 			Path:   "/drive/root:/" + o.srvPath() + ":/content",
 			Body:   in,
 		}
-		err = o.fs.pacer.CallNoRetry(func() (bool, error) {
-			resp, err = o.fs.srv.CallJSON(&opts, nil, &info)
+		err = o.fs.pacer.CallNoRetry(ctx, func() (bool, error) {
+			resp, err = o.fs.srv.CallJSON(ctx, &opts, nil, &info)
 			return shouldRetry(resp, err)
 		})
 		if err != nil {
@@ -934,13 +1235,13 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) (err error)
 		}
 		o.setMetaData(info)
 	} else {
-		err = o.uploadMultipart(in, size)
+		err = o.uploadMultipart(ctx, in, size)
 		if err != nil {
 			return err
 		}
 	}
 	// Set the mod time now and read metadata
-	info, err = o.setModTime(modTime)
+	info, err = o.setModTime(ctx, modTime)
 	if err != nil {
 		return err
 	}
@@ -949,16 +1250,22 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) (err error)
 }
 
 // Remove an object
-func (o *Object) Remove() error {
-	return o.fs.deleteObject(o.id)
+func (o *Object) Remove(ctx context.Context) error {
+	return o.fs.deleteObject(ctx, o.id)
 }
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs     = (*Fs)(nil)
-	_ fs.Purger = (*Fs)(nil)
-	_ fs.Copier = (*Fs)(nil)
+	_ fs.Fs               = (*Fs)(nil)
+	_ fs.Purger           = (*Fs)(nil)
+	_ fs.Copier           = (*Fs)(nil)
+	_ fs.Abouter          = (*Fs)(nil)
+	_ fs.PublicLinker     = (*Fs)(nil)
+	_ fs.DirMover         = (*Fs)(nil)
+	_ fs.DirCacheFlusher  = (*Fs)(nil)
+	_ fs.DirLister        = (*Fs)(nil)
+	_ fs.DirMaker         = (*Fs)(nil)
+	_ fs.DirModTimeSetter = (*Fs)(nil)
 	// _ fs.Mover    = (*Fs)(nil)
-	// _ fs.DirMover = (*Fs)(nil)
 	_ fs.Object = (*Object)(nil)
 )
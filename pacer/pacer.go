@@ -2,6 +2,7 @@
 package pacer
 
 import (
+	"context"
 	"math/rand"
 	"sync"
 	"time"
@@ -152,15 +153,26 @@ func (p *Pacer) SetPacer(t Type) *Pacer {
 //
 // This must be called as a pair with endCall
 //
-// This waits for the pacer token
-func (p *Pacer) beginCall() {
+// This waits for the pacer token.  If ctx is cancelled while waiting
+// then it returns ctx.Err() and endCall must not be called.
+func (p *Pacer) beginCall(ctx context.Context) error {
 	// pacer starts with a token in and whenever we take one out
 	// XXX ms later we put another in.  We could do this with a
 	// Ticker more accurately, but then we'd have to work out how
 	// not to run it when it wasn't needed
-	<-p.pacer
+	select {
+	case <-p.pacer:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 	if p.maxConnections > 0 {
-		<-p.connTokens
+		select {
+		case <-p.connTokens:
+		case <-ctx.Done():
+			// Put the pacing token back
+			p.pacer <- struct{}{}
+			return ctx.Err()
+		}
 	}
 
 	p.mu.Lock()
@@ -171,6 +183,7 @@ func (p *Pacer) beginCall() {
 		p.pacer <- struct{}{}
 	}(p.sleepTime)
 	p.mu.Unlock()
+	return nil
 }
 
 // exponentialImplementation implements a exponentialImplementation up
@@ -254,15 +267,21 @@ func (p *Pacer) endCall(retry bool) {
 }
 
 // call implements Call but with settable retries
-func (p *Pacer) call(fn Paced, retries int) (err error) {
+func (p *Pacer) call(ctx context.Context, fn Paced, retries int) (err error) {
 	var retry bool
 	for i := 0; i < retries; i++ {
-		p.beginCall()
+		if err := p.beginCall(ctx); err != nil {
+			return err
+		}
 		retry, err = fn()
 		p.endCall(retry)
 		if !retry {
 			break
 		}
+		if ctx.Err() != nil {
+			// Don't retry if cancelled - return the last error
+			return err
+		}
 	}
 	if retry {
 		err = fs.RetryError(err)
@@ -276,11 +295,14 @@ func (p *Pacer) call(fn Paced, retries int) (err error) {
 // This calls fn, expecting it to return a retry flag and an
 // error. This error may be returned wrapped in a RetryError if the
 // number of retries is exceeded.
-func (p *Pacer) Call(fn Paced) (err error) {
+//
+// If ctx is cancelled then no further attempts are made and either
+// ctx.Err() or the last error from fn is returned.
+func (p *Pacer) Call(ctx context.Context, fn Paced) (err error) {
 	p.mu.Lock()
 	retries := p.retries
 	p.mu.Unlock()
-	return p.call(fn, retries)
+	return p.call(ctx, fn, retries)
 }
 
 // CallNoRetry paces the remote operations to not exceed the limits
@@ -288,6 +310,6 @@ func (p *Pacer) Call(fn Paced) (err error) {
 //
 // This calls fn and wraps the output in a RetryError if it would like
 // it to be retried
-func (p *Pacer) CallNoRetry(fn Paced) error {
-	return p.call(fn, 1)
+func (p *Pacer) CallNoRetry(ctx context.Context, fn Paced) error {
+	return p.call(ctx, fn, 1)
 }
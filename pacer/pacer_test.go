@@ -1,6 +1,7 @@
 package pacer
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -125,7 +126,7 @@ func waitForPace(p *Pacer, duration time.Duration) (when time.Time) {
 func TestBeginCall(t *testing.T) {
 	p := New().SetMaxConnections(10).SetMinSleep(1 * time.Millisecond)
 	emptyTokens(p)
-	go p.beginCall()
+	go func() { _ = p.beginCall(context.Background()) }()
 	if !waitForPace(p, 10*time.Millisecond).IsZero() {
 		t.Errorf("beginSleep fired too early #1")
 	}
@@ -148,7 +149,7 @@ func TestBeginCall(t *testing.T) {
 func TestBeginCallZeroConnections(t *testing.T) {
 	p := New().SetMaxConnections(0).SetMinSleep(1 * time.Millisecond)
 	emptyTokens(p)
-	go p.beginCall()
+	go func() { _ = p.beginCall(context.Background()) }()
 	if !waitForPace(p, 10*time.Millisecond).IsZero() {
 		t.Errorf("beginSleep fired too early #1")
 	}
@@ -163,6 +164,23 @@ func TestBeginCallZeroConnections(t *testing.T) {
 	}
 }
 
+func TestBeginCallCancelled(t *testing.T) {
+	p := New().SetMaxConnections(10).SetMinSleep(1 * time.Millisecond)
+	emptyTokens(p)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.beginCall(ctx) }()
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("err want %v got %v", context.Canceled, err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("beginCall didn't return after cancel")
+	}
+}
+
 func TestDefaultPacer(t *testing.T) {
 	p := New().SetMinSleep(time.Millisecond).SetPacer(DefaultPacer).SetMaxSleep(time.Second).SetDecayConstant(2)
 	for _, test := range []struct {
@@ -269,7 +287,7 @@ func Test_callNoRetry(t *testing.T) {
 	p := New().SetMinSleep(time.Millisecond).SetMaxSleep(2 * time.Millisecond)
 
 	dp := &dummyPaced{retry: false}
-	err := p.call(dp.fn, 10)
+	err := p.call(context.Background(), dp.fn, 10)
 	if dp.called != 1 {
 		t.Errorf("called want %d got %d", 1, dp.called)
 	}
@@ -282,7 +300,7 @@ func Test_callRetry(t *testing.T) {
 	p := New().SetMinSleep(time.Millisecond).SetMaxSleep(2 * time.Millisecond)
 
 	dp := &dummyPaced{retry: true}
-	err := p.call(dp.fn, 10)
+	err := p.call(context.Background(), dp.fn, 10)
 	if dp.called != 10 {
 		t.Errorf("called want %d got %d", 10, dp.called)
 	}
@@ -299,7 +317,7 @@ func TestCall(t *testing.T) {
 	p := New().SetMinSleep(time.Millisecond).SetMaxSleep(2 * time.Millisecond).SetRetries(20)
 
 	dp := &dummyPaced{retry: true}
-	err := p.Call(dp.fn)
+	err := p.Call(context.Background(), dp.fn)
 	if dp.called != 20 {
 		t.Errorf("called want %d got %d", 20, dp.called)
 	}
@@ -313,7 +331,7 @@ func TestCallNoRetry(t *testing.T) {
 	p := New().SetMinSleep(time.Millisecond).SetMaxSleep(2 * time.Millisecond).SetRetries(20)
 
 	dp := &dummyPaced{retry: true}
-	err := p.CallNoRetry(dp.fn)
+	err := p.CallNoRetry(context.Background(), dp.fn)
 	if dp.called != 1 {
 		t.Errorf("called want %d got %d", 1, dp.called)
 	}
@@ -322,3 +340,20 @@ func TestCallNoRetry(t *testing.T) {
 		t.Errorf("didn't return a retry error")
 	}
 }
+
+func TestCallCancelled(t *testing.T) {
+	p := New().SetMinSleep(time.Millisecond).SetMaxSleep(2 * time.Millisecond).SetRetries(20)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dp := &dummyPaced{retry: true}
+	err := p.Call(ctx, func() (bool, error) {
+		cancel()
+		return dp.fn()
+	})
+	if dp.called != 1 {
+		t.Errorf("called want %d got %d", 1, dp.called)
+	}
+	if err != errFoo {
+		t.Errorf("err want %v got %v", errFoo, err)
+	}
+}
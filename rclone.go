@@ -4,17 +4,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"runtime"
 	"runtime/pprof"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/pflag"
 
 	"github.com/Shop2market/rclone/fs"
+	"github.com/Shop2market/rclone/serve"
 	// Active file systems
 	_ "github.com/Shop2market/rclone/amazonclouddrive"
 	_ "github.com/Shop2market/rclone/b2"
@@ -36,14 +41,40 @@ var (
 	version       = pflag.BoolP("version", "V", false, "Print the version number")
 	logFile       = pflag.StringP("log-file", "", "", "Log everything to this file")
 	retries       = pflag.IntP("retries", "", 3, "Retry operations this many times if they fail")
+	jsonOutput    = pflag.BoolP("json", "", false, "Format output as JSON (about)")
+	linkExpire    = pflag.DurationP("expire", "", 7*24*time.Hour, "Time before links made by link expire if the remote supports it")
+	catHead       = pflag.Int64P("head", "", 0, "Only print the first N bytes of each file (cat)")
+	catTail       = pflag.Int64P("tail", "", 0, "Only print the last N bytes of each file (cat)")
+	recursive     = pflag.BoolP("recursive", "R", false, "List all the sub directories too (lsjson)")
+	showHash      = pflag.BoolP("hash", "", false, "Include the hashes of the objects - may read every file on some remotes (lsjson)")
+	dedupeMode    = fs.DeduplicateInteractive
+	checkOneWay   = pflag.BoolP("one-way", "", false, "Only check the files in the source are in the destination (check, checksum)")
+	checkDownload = pflag.BoolP("download", "", false, "Download files to compare or hash them if there is no hash available (check, checksum, hashsum)")
+	matchFile     = pflag.StringP("match", "", "", "Write the paths of files which match to this file (check, checksum)")
+	differFile    = pflag.StringP("differ", "", "", "Write the paths of files which differ to this file (check, checksum)")
+	missingOnSrc  = pflag.StringP("missing-on-src", "", "", "Write the paths of files only in the destination to this file (check, checksum)")
+	missingOnDst  = pflag.StringP("missing-on-dst", "", "", "Write the paths of files only in the source to this file (check, checksum)")
+	errorFile     = pflag.StringP("error", "", "", "Write the paths of files which couldn't be checked to this file (check, checksum)")
+	maxDepth      = pflag.IntP("max-depth", "", -1, "Only show this many levels of directories, -1 for all (tree, du)")
+	sortBySize    = pflag.BoolP("sort-size", "", false, "Sort by size largest first rather than by name (tree, du)")
+	serveAddr     = pflag.StringP("addr", "", serve.DefaultAddr, "IPaddress:Port to serve on (serve)")
+	serveUser     = pflag.StringP("user", "", "", "User name for basic authentication, none if blank (serve)")
+	servePass     = pflag.StringP("pass", "", "", "Password for basic authentication (serve)")
+	serveCert     = pflag.StringP("cert", "", "", "TLS PEM certificate file to serve HTTPS with (serve)")
+	serveKey      = pflag.StringP("key", "", "", "TLS PEM private key file to serve HTTPS with (serve)")
 )
 
+func init() {
+	pflag.VarP(&dedupeMode, "dedupe-mode", "", "Dedupe mode interactive|skip|first|newest|oldest|rename (dedupe)")
+}
+
 // Command holds info about the current running command
 type Command struct {
 	Name     string
 	Help     string
 	ArgsHelp string
-	Run      func(fdst, fsrc fs.Fs) error
+	Run      func(ctx context.Context, fdst, fsrc fs.Fs) error
+	RunArgs  func(ctx context.Context, args []string) error // used instead of Run if the command parses its own args
 	MinArgs  int
 	MaxArgs  int
 	NoStats  bool
@@ -63,6 +94,15 @@ func (cmd *Command) checkArgs(args []string) {
 	}
 }
 
+// run runs the command, making the Fs from the args unless the
+// command parses them itself
+func (cmd *Command) run(ctx context.Context, fdst, fsrc fs.Fs, args []string) error {
+	if cmd.RunArgs != nil {
+		return cmd.RunArgs(ctx, args)
+	}
+	return cmd.Run(ctx, fdst, fsrc)
+}
+
 // Commands is a slice of possible Command~s
 var Commands = []Command{
 	{
@@ -71,9 +111,9 @@ var Commands = []Command{
 		Help: `
         Copy the source to the destination.  Doesn't transfer
         unchanged files, testing by size and modification time or
-        MD5SUM.  Doesn't delete files from the destination.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.CopyDir(fdst, fsrc)
+        hash.  Doesn't delete files from the destination.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.CopyDir(ctx, fdst, fsrc)
 		},
 		MinArgs: 2,
 		MaxArgs: 2,
@@ -85,11 +125,11 @@ var Commands = []Command{
 		Help: `
         Sync the source to the destination, changing the destination
         only.  Doesn't transfer unchanged files, testing by size and
-        modification time or MD5SUM.  Destination is updated to match
+        modification time or hash.  Destination is updated to match
         source, including deleting files if necessary.  Since this can
         cause data loss, test first with the --dry-run flag.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.Sync(fdst, fsrc)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.Sync(ctx, fdst, fsrc)
 		},
 		MinArgs: 2,
 		MaxArgs: 2,
@@ -103,8 +143,46 @@ var Commands = []Command{
         copy followed by a purge, but may use server side operations
         to speed it up. Since this can cause data loss, test first
         with the --dry-run flag.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.MoveDir(fdst, fsrc)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.MoveDir(ctx, fdst, fsrc)
+		},
+		MinArgs: 2,
+		MaxArgs: 2,
+		Retry:   true,
+	},
+	{
+		Name:     "copyto",
+		ArgsHelp: "source:path/to/file dest:path/to/file",
+		Help: `
+        Copies a single file from the source to the destination which
+        may have a different name, eg
+            rclone copyto src:a/file.txt dst:b/other-name.txt
+        Doesn't transfer the file if it is unchanged, testing by size
+        and modification time or hash.`,
+		RunArgs: func(ctx context.Context, args []string) error {
+			fsrc, srcFileName := NewFsFile(args[0])
+			fdst, dstFileName := NewFsFile(args[1])
+			fs.CalculateModifyWindow(fdst, fsrc)
+			return fs.CopyFile(ctx, fdst, fsrc, dstFileName, srcFileName)
+		},
+		MinArgs: 2,
+		MaxArgs: 2,
+		Retry:   true,
+	},
+	{
+		Name:     "moveto",
+		ArgsHelp: "source:path/to/file dest:path/to/file",
+		Help: `
+        Moves a single file from the source to the destination which
+        may have a different name.  This uses a server side move if
+        possible, otherwise it copies the file then deletes the
+        source.  Since this can cause data loss, test first with the
+        --dry-run flag.`,
+		RunArgs: func(ctx context.Context, args []string) error {
+			fsrc, srcFileName := NewFsFile(args[0])
+			fdst, dstFileName := NewFsFile(args[1])
+			fs.CalculateModifyWindow(fdst, fsrc)
+			return fs.MoveFile(ctx, fdst, fsrc, dstFileName, srcFileName)
 		},
 		MinArgs: 2,
 		MaxArgs: 2,
@@ -115,8 +193,8 @@ var Commands = []Command{
 		ArgsHelp: "remote:path",
 		Help: `
         List all the objects in the the path with size and path.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.List(fdst, os.Stdout)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.List(ctx, fdst, os.Stdout)
 		},
 		MinArgs: 1,
 		MaxArgs: 1,
@@ -126,8 +204,8 @@ var Commands = []Command{
 		ArgsHelp: "remote:path",
 		Help: `
         List all directories/containers/buckets in the the path.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.ListDir(fdst, os.Stdout)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.ListDir(ctx, fdst, os.Stdout)
 		},
 		MinArgs: 1,
 		MaxArgs: 1,
@@ -138,11 +216,26 @@ var Commands = []Command{
 		Help: `
         List all the objects in the the path with modification time,
         size and path.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.ListLong(fdst, os.Stdout)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.ListLong(ctx, fdst, os.Stdout)
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+	},
+	{
+		Name:     "lsjson",
+		ArgsHelp: "remote:path",
+		Help: `
+        List the objects and directories in the path as a JSON array
+        with the path, name, size, modification time, MIME type and
+        whether it is a directory of each.  Use -R to list all the sub
+        directories too and --hash to include the hashes.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.ListJSON(ctx, fdst, os.Stdout, *recursive, *showHash)
 		},
 		MinArgs: 1,
 		MaxArgs: 1,
+		NoStats: true,
 	},
 	{
 		Name:     "md5sum",
@@ -150,8 +243,48 @@ var Commands = []Command{
 		Help: `
         Produces an md5sum file for all the objects in the path.  This
         is in the same format as the standard md5sum tool produces.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.Md5sum(fdst, os.Stdout)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.Md5sum(ctx, fdst, os.Stdout)
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+	},
+	{
+		Name:     "hashsum",
+		ArgsHelp: "hash remote:path",
+		Help: `
+        Produces a sum file of type hash (MD5 or SHA-1) for all the
+        objects in the path in the same format as md5sum and sha1sum,
+        eg
+            rclone hashsum SHA1 remote:path
+        With --download sums the remote doesn't store are calculated
+        by downloading the files.`,
+		RunArgs: func(ctx context.Context, args []string) error {
+			var ht fs.HashType
+			err := ht.Set(args[0])
+			if err != nil {
+				return err
+			}
+			fdst := NewFs(args[1])
+			return fs.HashLister(ctx, ht, *checkDownload, fdst, os.Stdout)
+		},
+		MinArgs: 2,
+		MaxArgs: 2,
+	},
+	{
+		Name:     "cat",
+		ArgsHelp: "remote:path",
+		Help: `
+        Sends the contents of the files in the path which match the
+        filters to standard output, eg
+            rclone cat remote:logs/today.log.gz | zcat
+        Use --head N or --tail N to print only the first or last N
+        bytes of each file.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			if *catHead < 0 || *catTail < 0 {
+				return fmt.Errorf("--head and --tail can't be negative")
+			}
+			return fs.Cat(ctx, fdst, os.Stdout, -*catTail, *catHead)
 		},
 		MinArgs: 1,
 		MaxArgs: 1,
@@ -162,8 +295,8 @@ var Commands = []Command{
 		Help: `
         Returns the total size of objects in remote:path and the number
         of objects.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			objects, size, err := fs.Count(fdst)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			objects, size, err := fs.Count(ctx, fdst)
 			if err != nil {
 				return err
 			}
@@ -174,13 +307,56 @@ var Commands = []Command{
 		MinArgs: 1,
 		MaxArgs: 1,
 	},
+	{
+		Name:     "tree",
+		ArgsHelp: "remote:path",
+		Help: `
+        Shows the directories and files in the path as a tree, with
+        the total size and number of files beneath each directory.
+        Use --max-depth N to show only N levels and --sort-size to
+        show the largest first.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.Tree(ctx, fdst, os.Stdout, *maxDepth, *sortBySize)
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+	},
+	{
+		Name:     "du",
+		ArgsHelp: "remote:path",
+		Help: `
+        Shows the total size in bytes and the number of files beneath
+        each directory in the path, sub directories first and the
+        whole path last.  Use --max-depth N to show only N levels and
+        --sort-size to show the largest first.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.Du(ctx, fdst, os.Stdout, *maxDepth, *sortBySize)
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+	},
+	{
+		Name:     "about",
+		ArgsHelp: "remote:",
+		Help: `
+        Prints the quota information for the remote - the total size,
+        the space used, the space used by the trash and the space
+        free.  Not all remotes support this and some only report
+        some of the values.  Use --json for machine readable output.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.About(ctx, fdst, os.Stdout, *jsonOutput)
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+		NoStats: true,
+	},
 	{
 		Name:     "mkdir",
 		ArgsHelp: "remote:path",
 		Help: `
         Make the path if it doesn't already exist`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.Mkdir(fdst)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.Mkdir(ctx, fdst)
 		},
 		MinArgs: 1,
 		MaxArgs: 1,
@@ -192,8 +368,8 @@ var Commands = []Command{
 		Help: `
         Remove the path.  Note that you can't remove a path with
         objects in it, use purge for that.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.Rmdir(fdst)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.Rmdir(ctx, fdst)
 		},
 		MinArgs: 1,
 		MaxArgs: 1,
@@ -204,31 +380,215 @@ var Commands = []Command{
 		ArgsHelp: "remote:path",
 		Help: `
         Remove the path and all of its contents.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.Purge(fdst)
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.Purge(ctx, fdst)
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+		Retry:   true,
+	},
+	{
+		Name:     "delete",
+		ArgsHelp: "remote:path",
+		Help: `
+        Remove the files in the path which match the filters, eg
+            rclone --min-age 30d --include '*.log' delete remote:logs
+        Unlike purge it obeys include/exclude filters and leaves the
+        directories in place.  Test first with the --dry-run flag.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.Delete(ctx, fdst)
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+		Retry:   true,
+	},
+	{
+		Name:     "cleanup",
+		ArgsHelp: "remote:path",
+		Help: `
+        Clean up the remote if possible.  Empty the trash or delete
+        old file versions.  Not supported by all remotes.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.CleanUp(ctx, fdst)
 		},
 		MinArgs: 1,
 		MaxArgs: 1,
 		Retry:   true,
 	},
+	{
+		Name:     "dedupe",
+		ArgsHelp: "remote:path",
+		Help: `
+        Finds files with duplicate names in the path, which remotes
+        such as Drive allow, and deals with them according to
+        --dedupe-mode.  Duplicates with the same MD5 sum are always
+        deleted leaving one copy.  The modes are interactive (the
+        default), skip, first, newest, oldest and rename (to
+        file-1.jpg, file-2.jpg etc).  Test first with the --dry-run
+        flag.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
+			return fs.Deduplicate(ctx, fdst, dedupeMode)
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+	},
 	{
 		Name:     "check",
 		ArgsHelp: "source:path dest:path",
 		Help: `
         Checks the files in the source and destination match.  It
-        compares sizes and MD5SUMs and prints a report of files which
-        don't match.  It doesn't alter the source or destination.`,
-		Run: func(fdst, fsrc fs.Fs) error {
-			return fs.Check(fdst, fsrc)
+        compares sizes and hashes and prints a report of files which
+        don't match.  It doesn't alter the source or destination.
+        With --one-way files only in the destination are ignored.
+        With --download files with no hash in common are downloaded
+        and compared.  Use --match, --differ, --missing-on-src,
+        --missing-on-dst and --error to write the paths of the files
+        in each category to files.`,
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) (err error) {
+			opt, closeFiles, err := newCheckOpt()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				closeErr := closeFiles()
+				if err == nil {
+					err = closeErr
+				}
+			}()
+			return fs.Check(ctx, fdst, fsrc, opt)
+		},
+		MinArgs: 2,
+		MaxArgs: 2,
+	},
+	{
+		Name:     "checksum",
+		ArgsHelp: "hash sumfile remote:path",
+		Help: `
+        Checks the files in the path against a file of sums of type
+        hash (MD5 or SHA-1) in the format produced by md5sum and
+        sha1sum, eg
+            rclone checksum SHA1 SHA1SUMS remote:path
+        It reports in the same way as check, with the sum file as the
+        source.  With --download sums the remote doesn't store are
+        calculated by downloading the files.`,
+		RunArgs: func(ctx context.Context, args []string) (err error) {
+			var ht fs.HashType
+			err = ht.Set(args[0])
+			if err != nil {
+				return err
+			}
+			in, err := os.Open(args[1])
+			if err != nil {
+				return fmt.Errorf("failed to open sum file: %v", err)
+			}
+			defer func() {
+				_ = in.Close() // ignore error
+			}()
+			fdst := NewFs(args[2])
+			opt, closeFiles, err := newCheckOpt()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				closeErr := closeFiles()
+				if err == nil {
+					err = closeErr
+				}
+			}()
+			return fs.CheckSum(ctx, fdst, ht, in, opt)
+		},
+		MinArgs: 3,
+		MaxArgs: 3,
+	},
+	{
+		Name:     "serve",
+		ArgsHelp: "http|webdav remote:path",
+		Help: `
+        Serves the objects in the path over a network protocol.  http
+        serves them read only with an index page for each directory
+        and webdav lets them be read and changed with WebDAV clients,
+        eg
+            rclone serve http --addr :8080 remote:bucket
+        Use --user and --pass to require basic authentication and
+        --cert and --key to serve HTTPS.`,
+		RunArgs: func(ctx context.Context, args []string) error {
+			opt := &serve.Options{
+				Addr:     *serveAddr,
+				User:     *serveUser,
+				Pass:     *servePass,
+				CertFile: *serveCert,
+				KeyFile:  *serveKey,
+			}
+			switch args[0] {
+			case "http":
+				return serve.HTTP(NewFs(args[1]), opt)
+			case "webdav":
+				return serve.WebDAV(NewFs(args[1]), opt)
+			}
+			return fmt.Errorf("unknown protocol %q to serve - use http or webdav", args[0])
 		},
 		MinArgs: 2,
 		MaxArgs: 2,
 	},
+	{
+		Name:     "rcat",
+		ArgsHelp: "remote:path/to/file",
+		Help: `
+        Reads from standard input and copies it to a single remote
+        file, eg
+            echo "hello world" | rclone rcat remote:path/to/file
+            pg_dump db | gzip | rclone rcat remote:backups/db.gz
+        The length of the input doesn't need to be known in advance.
+        If the remote file already exists it will be overwritten.`,
+		RunArgs: func(ctx context.Context, args []string) error {
+			stat, err := os.Stdin.Stat()
+			if err != nil {
+				return fmt.Errorf("can't read standard input: %v", err)
+			}
+			if (stat.Mode() & os.ModeCharDevice) != 0 {
+				return fmt.Errorf("nothing to read from standard input")
+			}
+			fdst, leaf := NewFsFile(args[0])
+			_, err = fs.Rcat(ctx, fdst, leaf, os.Stdin, time.Now())
+			return err
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+	},
+	{
+		Name:     "link",
+		ArgsHelp: "remote:path",
+		Help: `
+        Makes a public link to the file or directory at remote:path
+        which can be read without any credentials and prints it.
+        Where the remote makes links which expire they are valid for
+        the time set with --expire.`,
+		RunArgs: func(ctx context.Context, args []string) error {
+			// Link directories, with or without a trailing /,
+			// by name from their parent like files
+			var f fs.Fs
+			remote := strings.TrimRight(args[0], "/")
+			if _, leaf := fs.SplitRemote(remote); leaf == "" {
+				f, remote = NewFs(args[0]), ""
+			} else {
+				f, remote = NewFsFile(remote)
+			}
+			link, err := fs.PublicLink(ctx, f, remote, *linkExpire)
+			if err != nil {
+				return err
+			}
+			fmt.Println(link)
+			return nil
+		},
+		MinArgs: 1,
+		MaxArgs: 1,
+		NoStats: true,
+	},
 	{
 		Name: "config",
 		Help: `
         Enter an interactive configuration session.`,
-		Run: func(fdst, fsrc fs.Fs) error {
+		Run: func(ctx context.Context, fdst, fsrc fs.Fs) error {
 			fs.EditConfig()
 			return nil
 		},
@@ -334,7 +694,7 @@ func ParseCommand() (*Command, []string) {
 			log.Fatalf("Not unique - matches multiple commands: %s", strings.Join(names, ", "))
 		}
 	}
-	if command.Run == nil {
+	if command.Run == nil && command.RunArgs == nil {
 		syntaxError()
 	}
 	command.checkArgs(args)
@@ -351,6 +711,62 @@ func NewFs(remote string) fs.Fs {
 	return f
 }
 
+// NewFsFile creates a Fs for the directory containing the file at
+// remote and returns it along with the name of the file
+func NewFsFile(remote string) (fs.Fs, string) {
+	parent, leaf := fs.SplitRemote(remote)
+	if leaf == "" {
+		fs.Stats.Error()
+		log.Fatalf("%q should be a file not a directory", remote)
+	}
+	if parent == "" {
+		parent = "."
+	}
+	return NewFs(parent), leaf
+}
+
+// newCheckOpt makes the options for check and checksum from the
+// flags, creating any files the paths are to be written to.  It
+// returns a function to close those files.
+func newCheckOpt() (opt *fs.CheckOpt, closeFiles func() error, err error) {
+	opt = &fs.CheckOpt{
+		OneWay:   *checkOneWay,
+		Download: *checkDownload,
+	}
+	var files []*os.File
+	closeFiles = func() (err error) {
+		for _, out := range files {
+			closeErr := out.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}
+	for _, output := range []struct {
+		path string
+		w    *io.Writer
+	}{
+		{*matchFile, &opt.Match},
+		{*differFile, &opt.Differ},
+		{*missingOnSrc, &opt.MissingOnSrc},
+		{*missingOnDst, &opt.MissingOnDst},
+		{*errorFile, &opt.Error},
+	} {
+		if output.path == "" {
+			continue
+		}
+		out, err := os.Create(output.path)
+		if err != nil {
+			_ = closeFiles() // ignore error
+			return nil, nil, fmt.Errorf("failed to create output file: %v", err)
+		}
+		files = append(files, out)
+		*output.w = out
+	}
+	return opt, closeFiles, nil
+}
+
 // StartStats prints the stats every statsInterval
 func StartStats() {
 	if *statsInterval <= 0 {
@@ -365,6 +781,23 @@ func StartStats() {
 	}()
 }
 
+// cancelOnSignal returns a context which is cancelled when rclone is
+// interrupted or terminated so the transfers in progress are aborted.
+//
+// A second signal kills rclone straight away.
+func cancelOnSignal() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		signal.Stop(sigs)
+		fs.Log(nil, "Received %v - cancelling", sig)
+		cancel()
+	}()
+	return ctx
+}
+
 func main() {
 	ParseFlags()
 	if *version {
@@ -387,28 +820,30 @@ func main() {
 		redirectStderr(f)
 	}
 
-	// Make source and destination fs
+	// Make source and destination fs unless the command does it
 	var fdst, fsrc fs.Fs
-	if len(args) >= 1 {
-		fdst = NewFs(args[0])
-	}
-	if len(args) >= 2 {
-		fsrc = fdst
-		fdst = NewFs(args[1])
+	if command.RunArgs == nil {
+		if len(args) >= 1 {
+			fdst = NewFs(args[0])
+		}
+		if len(args) >= 2 {
+			fsrc = fdst
+			fdst = NewFs(args[1])
+		}
+		fs.CalculateModifyWindow(fdst, fsrc)
 	}
 
-	fs.CalculateModifyWindow(fdst, fsrc)
-
 	if !command.NoStats {
 		StartStats()
 	}
 
 	// Run the actual command
-	if command.Run != nil {
+	if command.Run != nil || command.RunArgs != nil {
+		ctx := cancelOnSignal()
 		var err error
 		for try := 1; try <= *retries; try++ {
-			err = command.Run(fdst, fsrc)
-			if !command.Retry || (err == nil && !fs.Stats.Errored()) {
+			err = command.run(ctx, fdst, fsrc, args)
+			if !command.Retry || (err == nil && !fs.Stats.Errored()) || ctx.Err() != nil {
 				break
 			}
 			if err != nil {
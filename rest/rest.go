@@ -3,6 +3,7 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -88,7 +89,9 @@ func DecodeJSON(resp *http.Response, result interface{}) (err error) {
 // if err != nil then resp.Body will need to be closed
 //
 // it will return resp if at all possible, even if err is set
-func (api *Client) Call(opts *Opts) (resp *http.Response, err error) {
+//
+// The request is cancelled if ctx is cancelled
+func (api *Client) Call(ctx context.Context, opts *Opts) (resp *http.Response, err error) {
 	if opts == nil {
 		return nil, fmt.Errorf("call() called with nil opts")
 	}
@@ -105,6 +108,7 @@ func (api *Client) Call(opts *Opts) (resp *http.Response, err error) {
 	if err != nil {
 		return
 	}
+	req = req.WithContext(ctx)
 	headers := make(map[string]string)
 	// Set default headers
 	for k, v := range api.headers {
@@ -150,7 +154,7 @@ func (api *Client) Call(opts *Opts) (resp *http.Response, err error) {
 // If request is not nil then it will be JSON encoded as the body of the request
 //
 // It will return resp if at all possible, even if err is set
-func (api *Client) CallJSON(opts *Opts, request interface{}, response interface{}) (resp *http.Response, err error) {
+func (api *Client) CallJSON(ctx context.Context, opts *Opts, request interface{}, response interface{}) (resp *http.Response, err error) {
 	// Set the body up as a JSON object if required
 	if opts.Body == nil && request != nil {
 		body, err := json.Marshal(request)
@@ -162,7 +166,7 @@ func (api *Client) CallJSON(opts *Opts, request interface{}, response interface{
 		newOpts.ContentType = "application/json"
 		opts = &newOpts
 	}
-	resp, err = api.Call(opts)
+	resp, err = api.Call(ctx, opts)
 	if err != nil {
 		return resp, err
 	}
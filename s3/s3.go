@@ -14,6 +14,7 @@ What happens if you CTRL-C a multipart upload
 */
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -123,9 +124,10 @@ func init() {
 
 // Constants
 const (
-	metaMtime     = "Mtime" // the meta key to store mtime in - eg X-Amz-Meta-Mtime
-	listChunkSize = 1024    // number of items to read at once
-	maxRetries    = 10      // number of retries to make of operations
+	metaMtime     = "Mtime"            // the meta key to store mtime in - eg X-Amz-Meta-Mtime
+	listChunkSize = 1024               // number of items to read at once
+	maxRetries    = 10                 // number of retries to make of operations
+	maxLinkExpire = 7 * 24 * time.Hour // longest a presigned URL can last
 )
 
 // Fs represents a remote s3 server
@@ -137,11 +139,13 @@ type Fs struct {
 	perm               string           // permissions for new buckets / objects
 	root               string           // root of the bucket - ignore all objects above this
 	locationConstraint string           // location constraint of new buckets
+	features           *fs.Features     // optional features
 }
 
 // Object describes a s3 object
 type Object struct {
-	// Will definitely have everything but meta which may be nil
+	// Will definitely have everything but meta and mimeType
+	// which may be nil and ""
 	//
 	// List will read everything but meta - to fill that in need to call
 	// readMetaData
@@ -151,6 +155,7 @@ type Object struct {
 	bytes        int64              // size of the object
 	lastModified time.Time          // Last modified
 	meta         map[string]*string // The object metadata if known - may be nil
+	mimeType     string             // Content-Type of the object if known
 }
 
 // ------------------------------------------------------------
@@ -266,6 +271,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 		root:               directory,
 		locationConstraint: fs.ConfigFile.MustValue(name, "location_constraint"),
 	}
+	f.features = (&fs.Features{}).Fill(f)
 	if f.root != "" {
 		f.root += "/"
 		// Check to see if the object exists
@@ -273,7 +279,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 			Bucket: &f.bucket,
 			Key:    &directory,
 		}
-		_, err = f.c.HeadObject(&req)
+		_, err = f.c.HeadObjectWithContext(context.Background(), &req)
 		if err == nil {
 			remote := path.Base(directory)
 			f.root = path.Dir(directory)
@@ -282,7 +288,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 			} else {
 				f.root += "/"
 			}
-			obj := f.NewFsObject(remote)
+			obj := f.NewFsObject(context.Background(), remote)
 			// return a Fs Limited to this object
 			return fs.NewLimited(f, obj), nil
 		}
@@ -294,7 +300,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 // Return an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) newFsObjectWithInfo(remote string, info *s3.Object) fs.Object {
+func (f *Fs) newFsObjectWithInfo(ctx context.Context, remote string, info *s3.Object) fs.Object {
 	o := &Object{
 		fs:     f,
 		remote: remote,
@@ -310,7 +316,7 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *s3.Object) fs.Object {
 		o.etag = aws.StringValue(info.ETag)
 		o.bytes = aws.Int64Value(info.Size)
 	} else {
-		err := o.readMetaData() // reads info and meta, returning an error
+		err := o.readMetaData(ctx) // reads info and meta, returning an error
 		if err != nil {
 			// logged already FsDebug("Failed to read info: %s", err)
 			return nil
@@ -322,34 +328,48 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *s3.Object) fs.Object {
 // NewFsObject returns an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) NewFsObject(remote string) fs.Object {
-	return f.newFsObjectWithInfo(remote, nil)
+func (f *Fs) NewFsObject(ctx context.Context, remote string) fs.Object {
+	return f.newFsObjectWithInfo(ctx, remote, nil)
 }
 
+// listFn is called from list to handle an object or a directory
+type listFn func(remote string, object *s3.Object, isDirectory bool) error
+
 // list the objects into the function supplied
 //
-// If directories is set it only sends directories
-func (f *Fs) list(directories bool, fn func(string, *s3.Object)) {
+// dir is the directory to list relative to the root, "" being the
+// root itself
+//
+// If directories is set it only sends the objects and directories
+// directly in dir, otherwise it sends all the objects below dir.
+//
+// If fn returns an error the listing stops and the error is returned
+func (f *Fs) list(ctx context.Context, dir string, directories bool, fn listFn) error {
 	maxKeys := int64(listChunkSize)
 	delimiter := ""
 	if directories {
 		delimiter = "/"
 	}
+	prefix := f.root
+	if dir != "" {
+		prefix += dir + "/"
+	}
 	var marker *string
 	for {
 		// FIXME need to implement ALL loop
 		req := s3.ListObjectsInput{
 			Bucket:    &f.bucket,
 			Delimiter: &delimiter,
-			Prefix:    &f.root,
+			Prefix:    &prefix,
 			MaxKeys:   &maxKeys,
 			Marker:    marker,
 		}
-		resp, err := f.c.ListObjects(&req)
+		resp, err := f.c.ListObjectsWithContext(ctx, &req)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
-			fs.Stats.Error()
-			fs.ErrorLog(f, "Couldn't read bucket %q: %s", f.bucket, err)
-			break
+			return fmt.Errorf("Couldn't read bucket %q: %s", f.bucket, err)
 		} else {
 			rootLength := len(f.root)
 			if directories {
@@ -367,17 +387,26 @@ func (f *Fs) list(directories bool, fn func(string, *s3.Object)) {
 					if strings.HasSuffix(remote, "/") {
 						remote = remote[:len(remote)-1]
 					}
-					fn(remote, &s3.Object{Key: &remote})
-				}
-			} else {
-				for _, object := range resp.Contents {
-					key := aws.StringValue(object.Key)
-					if !strings.HasPrefix(key, f.root) {
-						fs.Log(f, "Odd name received %q", key)
-						continue
+					err = fn(remote, &s3.Object{Key: &remote}, true)
+					if err != nil {
+						return err
 					}
-					remote := key[rootLength:]
-					fn(remote, object)
+				}
+			}
+			for _, object := range resp.Contents {
+				key := aws.StringValue(object.Key)
+				if !strings.HasPrefix(key, f.root) {
+					fs.Log(f, "Odd name received %q", key)
+					continue
+				}
+				if directories && strings.HasSuffix(key, "/") {
+					// Skip directory markers
+					continue
+				}
+				remote := key[rootLength:]
+				err = fn(remote, object, false)
+				if err != nil {
+					return err
 				}
 			}
 			if !aws.BoolValue(resp.IsTruncated) {
@@ -391,48 +420,78 @@ func (f *Fs) list(directories bool, fn func(string, *s3.Object)) {
 			}
 		}
 	}
+	return nil
 }
 
-// List walks the path returning a channel of FsObjects
-func (f *Fs) List() fs.ObjectsChan {
-	out := make(fs.ObjectsChan, fs.Config.Checkers)
+// List lists the objects in the bucket into out
+func (f *Fs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
 	if f.bucket == "" {
-		// Return no objects at top level list
-		close(out)
-		fs.Stats.Error()
-		fs.ErrorLog(f, "Can't list objects at root - choose a bucket using lsd")
-	} else {
-		go func() {
-			defer close(out)
-			f.list(false, func(remote string, object *s3.Object) {
-				if fs := f.newFsObjectWithInfo(remote, object); fs != nil {
-					out <- fs
-				}
+		// Objects can only be listed within a bucket
+		out.SetError(fmt.Errorf("Can't list objects at root - choose a bucket using lsd"))
+		return
+	}
+	err := f.list(ctx, "", false, func(remote string, object *s3.Object, isDirectory bool) error {
+		if o := f.newFsObjectWithInfo(ctx, remote, object); o != nil {
+			if out.Add(o) {
+				return fs.ErrorListAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && err != fs.ErrorListAborted {
+		out.SetError(err)
+	}
+}
+
+// ListDirEntries lists the objects and directories directly in dir
+func (f *Fs) ListDirEntries(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	if f.bucket == "" {
+		return nil, nil, fmt.Errorf("Can't list objects at root - choose a bucket using lsd")
+	}
+	err = f.list(ctx, dir, true, func(remote string, object *s3.Object, isDirectory bool) error {
+		if isDirectory {
+			dirs = append(dirs, &fs.Dir{
+				Name:  remote,
+				Bytes: -1,
+				Count: -1,
 			})
-		}()
+		} else if o := f.newFsObjectWithInfo(ctx, remote, object); o != nil {
+			objects = append(objects, o)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
-	return out
+	return objects, dirs, nil
 }
 
 // ListDir lists the buckets
-func (f *Fs) ListDir() fs.DirChan {
+func (f *Fs) ListDir(ctx context.Context) fs.DirChan {
 	out := make(fs.DirChan, fs.Config.Checkers)
 	if f.bucket == "" {
 		// List the buckets
 		go func() {
 			defer close(out)
 			req := s3.ListBucketsInput{}
-			resp, err := f.c.ListBuckets(&req)
+			resp, err := f.c.ListBucketsWithContext(ctx, &req)
 			if err != nil {
-				fs.Stats.Error()
-				fs.ErrorLog(f, "Couldn't list buckets: %s", err)
+				if ctx.Err() == nil {
+					fs.Stats.Error()
+					fs.ErrorLog(f, "Couldn't list buckets: %s", err)
+				}
 			} else {
 				for _, bucket := range resp.Buckets {
-					out <- &fs.Dir{
+					select {
+					case out <- &fs.Dir{
 						Name:  aws.StringValue(bucket.Name),
 						When:  aws.TimeValue(bucket.CreationDate),
 						Bytes: -1,
 						Count: -1,
+					}:
+					case <-ctx.Done():
+						return
 					}
 				}
 			}
@@ -441,34 +500,46 @@ func (f *Fs) ListDir() fs.DirChan {
 		// List the directories in the path in the bucket
 		go func() {
 			defer close(out)
-			f.list(true, func(remote string, object *s3.Object) {
+			err := f.list(ctx, "", true, func(remote string, object *s3.Object, isDirectory bool) error {
+				if !isDirectory {
+					return nil
+				}
 				size := int64(0)
 				if object.Size != nil {
 					size = *object.Size
 				}
-				out <- &fs.Dir{
+				select {
+				case out <- &fs.Dir{
 					Name:  remote,
 					Bytes: size,
 					Count: 0,
+				}:
+				case <-ctx.Done():
+					return ctx.Err()
 				}
+				return nil
 			})
+			if err != nil && ctx.Err() == nil {
+				fs.Stats.Error()
+				fs.ErrorLog(f, "Couldn't list directories: %s", err)
+			}
 		}()
 	}
 	return out
 }
 
 // Put the FsObject into the bucket
-func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs.Object, error) {
+func (f *Fs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
 	// Temporary Object under construction
 	fs := &Object{
 		fs:     f,
 		remote: remote,
 	}
-	return fs, fs.Update(in, modTime, size)
+	return fs, fs.Update(ctx, in, modTime, size, options...)
 }
 
 // Mkdir creates the bucket if it doesn't exist
-func (f *Fs) Mkdir() error {
+func (f *Fs) Mkdir(ctx context.Context) error {
 	req := s3.CreateBucketInput{
 		Bucket: &f.bucket,
 		ACL:    &f.perm,
@@ -478,7 +549,7 @@ func (f *Fs) Mkdir() error {
 			LocationConstraint: &f.locationConstraint,
 		}
 	}
-	_, err := f.c.CreateBucket(&req)
+	_, err := f.c.CreateBucketWithContext(ctx, &req)
 	if err, ok := err.(awserr.Error); ok {
 		if err.Code() == "BucketAlreadyOwnedByYou" {
 			return nil
@@ -490,14 +561,14 @@ func (f *Fs) Mkdir() error {
 // Rmdir deletes the bucket if the fs is at the root
 //
 // Returns an error if it isn't empty
-func (f *Fs) Rmdir() error {
+func (f *Fs) Rmdir(ctx context.Context) error {
 	if f.root != "" {
 		return nil
 	}
 	req := s3.DeleteBucketInput{
 		Bucket: &f.bucket,
 	}
-	_, err := f.c.DeleteBucket(&req)
+	_, err := f.c.DeleteBucketWithContext(ctx, &req)
 	return err
 }
 
@@ -506,6 +577,16 @@ func (f *Fs) Precision() time.Duration {
 	return time.Nanosecond
 }
 
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() fs.HashSet {
+	return fs.HashSet(fs.HashMD5)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
 // Copy src to this remote using server side copy operations.
 //
 // This is stored with the remote path given
@@ -515,7 +596,7 @@ func (f *Fs) Precision() time.Duration {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantCopy
-func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debug(src, "Can't copy - not same remote type")
@@ -530,11 +611,65 @@ func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
 		CopySource:        &source,
 		MetadataDirective: aws.String(s3.MetadataDirectiveCopy),
 	}
-	_, err := f.c.CopyObject(&req)
+	_, err := f.c.CopyObjectWithContext(ctx, &req)
 	if err != nil {
 		return nil, err
 	}
-	return f.NewFsObject(remote), err
+	return f.NewFsObject(ctx, remote), err
+}
+
+// Move src to this remote using server side move operations.
+//
+// This is done as a server side copy followed by deleting src.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantMove
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debug(src, "Can't move - not same remote type")
+		return nil, fs.ErrorCantMove
+	}
+	dstObj, err := f.Copy(ctx, srcObj, remote)
+	if err == fs.ErrorCantCopy {
+		return nil, fs.ErrorCantMove
+	} else if err != nil {
+		return nil, err
+	}
+	if dstObj == nil {
+		return nil, fmt.Errorf("Couldn't find %q after copying it", remote)
+	}
+	err = srcObj.Remove(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Copied but couldn't remove source: %v", err)
+	}
+	return dstObj, nil
+}
+
+// PublicLink makes a presigned URL for the object at remote which
+// is valid for expire
+//
+// S3 limits this to a week at most so longer times are cut down to
+// that.
+func (f *Fs) PublicLink(ctx context.Context, remote string, expire time.Duration) (string, error) {
+	if f.NewFsObject(ctx, remote) == nil {
+		return "", fs.ErrorObjectNotFound
+	}
+	if expire > maxLinkExpire {
+		fs.Debug(f, "Link expiry %v is longer than S3 allows - using %v", expire, maxLinkExpire)
+		expire = maxLinkExpire
+	}
+	key := f.root + remote
+	req, _ := f.c.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: &f.bucket,
+		Key:    &key,
+	})
+	return req.Presign(expire)
 }
 
 // ------------------------------------------------------------
@@ -559,8 +694,11 @@ func (o *Object) Remote() string {
 
 var matchMd5 = regexp.MustCompile(`^[0-9a-f]{32}$`)
 
-// Md5sum returns the Md5sum of an object returning a lowercase hex string
-func (o *Object) Md5sum() (string, error) {
+// Hash returns the Md5sum of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t fs.HashType) (string, error) {
+	if t != fs.HashMD5 {
+		return "", fs.ErrHashUnsupported
+	}
 	etag := strings.Trim(strings.ToLower(o.etag), `"`)
 	// Check the etag is a valid md5sum
 	if !matchMd5.MatchString(etag) {
@@ -578,7 +716,7 @@ func (o *Object) Size() int64 {
 // readMetaData gets the metadata if it hasn't already been fetched
 //
 // it also sets the info
-func (o *Object) readMetaData() (err error) {
+func (o *Object) readMetaData(ctx context.Context) (err error) {
 	if o.meta != nil {
 		return nil
 	}
@@ -587,7 +725,7 @@ func (o *Object) readMetaData() (err error) {
 		Bucket: &o.fs.bucket,
 		Key:    &key,
 	}
-	resp, err := o.fs.c.HeadObject(&req)
+	resp, err := o.fs.c.HeadObjectWithContext(ctx, &req)
 	if err != nil {
 		fs.Debug(o, "Failed to read info: %s", err)
 		return err
@@ -601,6 +739,7 @@ func (o *Object) readMetaData() (err error) {
 	o.etag = aws.StringValue(resp.ETag)
 	o.bytes = size
 	o.meta = resp.Metadata
+	o.mimeType = aws.StringValue(resp.ContentType)
 	if resp.LastModified == nil {
 		fs.Log(o, "Failed to read last modified from HEAD: %s", err)
 		o.lastModified = time.Now()
@@ -614,8 +753,8 @@ func (o *Object) readMetaData() (err error) {
 //
 // It attempts to read the objects mtime and if that isn't present the
 // LastModified returned in the http headers
-func (o *Object) ModTime() time.Time {
-	err := o.readMetaData()
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Log(o, "Failed to read metadata: %s", err)
 		return time.Now()
@@ -635,8 +774,8 @@ func (o *Object) ModTime() time.Time {
 }
 
 // SetModTime sets the modification time of the local fs object
-func (o *Object) SetModTime(modTime time.Time) {
-	err := o.readMetaData()
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Stats.Error()
 		fs.ErrorLog(o, "Failed to read metadata: %s", err)
@@ -644,8 +783,11 @@ func (o *Object) SetModTime(modTime time.Time) {
 	}
 	o.meta[metaMtime] = aws.String(swift.TimeToFloatString(modTime))
 
-	// Guess the content type
-	contentType := fs.MimeType(o)
+	// Keep the content type if known, otherwise guess it
+	contentType := o.mimeType
+	if contentType == "" {
+		contentType = fs.MimeType(o)
+	}
 
 	// Copy the object to itself to update the metadata
 	key := o.fs.root + o.remote
@@ -660,26 +802,63 @@ func (o *Object) SetModTime(modTime time.Time) {
 		Metadata:          o.meta,
 		MetadataDirective: &directive,
 	}
-	_, err = o.fs.c.CopyObject(&req)
+	_, err = o.fs.c.CopyObjectWithContext(ctx, &req)
 	if err != nil {
 		fs.Stats.Error()
 		fs.ErrorLog(o, "Failed to update remote mtime: %s", err)
 	}
 }
 
+// MimeType returns the content type of the object
+func (o *Object) MimeType(ctx context.Context) string {
+	err := o.readMetaData(ctx)
+	if err != nil {
+		fs.Log(o, "Failed to read metadata: %s", err)
+		return ""
+	}
+	return o.mimeType
+}
+
+// Metadata returns the user metadata of the object
+func (o *Object) Metadata(ctx context.Context) (fs.Metadata, error) {
+	err := o.readMetaData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(fs.Metadata, len(o.meta))
+	for k, v := range o.meta {
+		if v == nil || strings.EqualFold(k, metaMtime) {
+			continue
+		}
+		metadata[strings.ToLower(k)] = *v
+	}
+	return metadata, nil
+}
+
 // Storable raturns a boolean indicating if this object is storable
 func (o *Object) Storable() bool {
 	return true
 }
 
 // Open an object for read
-func (o *Object) Open() (in io.ReadCloser, err error) {
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
 	key := o.fs.root + o.remote
 	req := s3.GetObjectInput{
 		Bucket: &o.fs.bucket,
 		Key:    &key,
 	}
-	resp, err := o.fs.c.GetObject(&req)
+	for _, option := range options {
+		switch option.(type) {
+		case *fs.RangeOption, *fs.SeekOption:
+			_, value := option.Header()
+			req.Range = &value
+		default:
+			if option.Mandatory() {
+				return nil, fmt.Errorf("Unsupported mandatory option: %v", option)
+			}
+		}
+	}
+	resp, err := o.fs.c.GetObjectWithContext(ctx, &req)
 	if err != nil {
 		return nil, err
 	}
@@ -687,20 +866,25 @@ func (o *Object) Open() (in io.ReadCloser, err error) {
 }
 
 // Update the Object from in with modTime and size
-func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
+func (o *Object) Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...fs.PutOption) error {
 	uploader := s3manager.NewUploader(o.fs.ses, func(u *s3manager.Uploader) {
 		u.Concurrency = 2
 		u.LeavePartsOnError = false
 		u.S3 = o.fs.c
 	})
 
-	// Set the mtime in the meta data
-	metadata := map[string]*string{
-		metaMtime: aws.String(swift.TimeToFloatString(modTime)),
+	// Set the user metadata and the mtime in the meta data
+	_, userMetadata := fs.PutOptionsMetadata(options...)
+	metadata := make(map[string]*string, len(userMetadata)+1)
+	for k, v := range userMetadata {
+		if !strings.EqualFold(k, metaMtime) {
+			metadata[k] = aws.String(v)
+		}
 	}
+	metadata[metaMtime] = aws.String(swift.TimeToFloatString(modTime))
 
-	// Guess the content type
-	contentType := fs.MimeType(o)
+	// Use the content type passed in or guess it
+	contentType := fs.MimeType(o, options...)
 
 	key := o.fs.root + o.remote
 	req := s3manager.UploadInput{
@@ -712,31 +896,35 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 		Metadata:    metadata,
 		//ContentLength: &size,
 	}
-	_, err := uploader.Upload(&req)
+	_, err := uploader.UploadWithContext(ctx, &req)
 	if err != nil {
 		return err
 	}
 
 	// Read the metadata from the newly created object
 	o.meta = nil // wipe old metadata
-	err = o.readMetaData()
+	err = o.readMetaData(ctx)
 	return err
 }
 
 // Remove an object
-func (o *Object) Remove() error {
+func (o *Object) Remove(ctx context.Context) error {
 	key := o.fs.root + o.remote
 	req := s3.DeleteObjectInput{
 		Bucket: &o.fs.bucket,
 		Key:    &key,
 	}
-	_, err := o.fs.c.DeleteObject(&req)
+	_, err := o.fs.c.DeleteObjectWithContext(ctx, &req)
 	return err
 }
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs     = &Fs{}
-	_ fs.Copier = &Fs{}
-	_ fs.Object = &Object{}
+	_ fs.Fs           = &Fs{}
+	_ fs.Copier       = &Fs{}
+	_ fs.Mover        = &Fs{}
+	_ fs.DirLister    = &Fs{}
+	_ fs.PublicLinker = &Fs{}
+	_ fs.Object       = &Object{}
+	_ fs.Metadataer   = &Object{}
 )
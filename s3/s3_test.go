@@ -34,6 +34,7 @@ func TestFsListRoot(t *testing.T)            { fstests.TestFsListRoot(t) }
 func TestFsListFile1(t *testing.T)           { fstests.TestFsListFile1(t) }
 func TestFsNewFsObject(t *testing.T)         { fstests.TestFsNewFsObject(t) }
 func TestFsListFile1and2(t *testing.T)       { fstests.TestFsListFile1and2(t) }
+func TestFsListDirEntries(t *testing.T)      { fstests.TestFsListDirEntries(t) }
 func TestFsCopy(t *testing.T)                { fstests.TestFsCopy(t) }
 func TestFsMove(t *testing.T)                { fstests.TestFsMove(t) }
 func TestFsDirMove(t *testing.T)             { fstests.TestFsDirMove(t) }
@@ -42,15 +43,21 @@ func TestFsPrecision(t *testing.T)           { fstests.TestFsPrecision(t) }
 func TestObjectString(t *testing.T)          { fstests.TestObjectString(t) }
 func TestObjectFs(t *testing.T)              { fstests.TestObjectFs(t) }
 func TestObjectRemote(t *testing.T)          { fstests.TestObjectRemote(t) }
-func TestObjectMd5sum(t *testing.T)          { fstests.TestObjectMd5sum(t) }
+func TestObjectHashes(t *testing.T)          { fstests.TestObjectHashes(t) }
 func TestObjectModTime(t *testing.T)         { fstests.TestObjectModTime(t) }
 func TestObjectSetModTime(t *testing.T)      { fstests.TestObjectSetModTime(t) }
 func TestObjectSize(t *testing.T)            { fstests.TestObjectSize(t) }
 func TestObjectOpen(t *testing.T)            { fstests.TestObjectOpen(t) }
+func TestObjectOpenSeek(t *testing.T)        { fstests.TestObjectOpenSeek(t) }
 func TestObjectUpdate(t *testing.T)          { fstests.TestObjectUpdate(t) }
 func TestObjectStorable(t *testing.T)        { fstests.TestObjectStorable(t) }
 func TestLimitedFs(t *testing.T)             { fstests.TestLimitedFs(t) }
 func TestLimitedFsNotFound(t *testing.T)     { fstests.TestLimitedFsNotFound(t) }
+func TestFsPutStream(t *testing.T)           { fstests.TestFsPutStream(t) }
+func TestFsPutMetadata(t *testing.T)         { fstests.TestFsPutMetadata(t) }
+func TestFsAbout(t *testing.T)               { fstests.TestFsAbout(t) }
+func TestFsPublicLink(t *testing.T)          { fstests.TestFsPublicLink(t) }
+func TestFsMakeDir(t *testing.T)             { fstests.TestFsMakeDir(t) }
 func TestObjectRemove(t *testing.T)          { fstests.TestObjectRemove(t) }
 func TestObjectPurge(t *testing.T)           { fstests.TestObjectPurge(t) }
 func TestFinalise(t *testing.T)              { fstests.TestFinalise(t) }
@@ -0,0 +1,288 @@
+// Serve an Fs read only over HTTP
+
+package serve
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shop2market/rclone/fs"
+)
+
+// HTTP serves f read only over HTTP using opt
+//
+// It only returns if the server fails.
+func HTTP(f fs.Fs, opt *Options) error {
+	return opt.ListenAndServe(NewHTTPHandler(f))
+}
+
+// httpHandler serves the objects in an Fs read only with directory
+// indexes
+type httpHandler struct {
+	f fs.Fs
+
+	// Set when serving directories which f can't store - list
+	// lists a directory including them and made reports whether
+	// one of them is dir
+	list func(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error)
+	made func(dir string) bool
+}
+
+// NewHTTPHandler returns an http.Handler which serves the objects in
+// f read only - obeys includes and excludes
+//
+// Directories are served as HTML indexes of their contents.
+func NewHTTPHandler(f fs.Fs) http.Handler {
+	return &httpHandler{f: f}
+}
+
+// ServeHTTP serves a single request
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	urlPath := r.URL.Path
+	isDir := strings.HasSuffix(urlPath, "/")
+	remote := remotePath(urlPath)
+
+	if !isDir {
+		o := h.f.NewFsObject(ctx, remote)
+		if o != nil && fs.Config.Filter.IncludeObject(ctx, o) {
+			h.serveObject(w, r, o)
+			return
+		}
+	}
+
+	var objects fs.Objects
+	var dirs []*fs.Dir
+	var err error
+	if h.list != nil {
+		objects, dirs, err = h.list(ctx, remote)
+	} else {
+		objects, dirs, err = fs.ListDirSorted(ctx, h.f, remote)
+	}
+	if err != nil {
+		fs.Stats.Error()
+		fs.ErrorLog(remote, "Failed to list: %v", err)
+		http.Error(w, "Failed to list directory", http.StatusInternalServerError)
+		return
+	}
+	index := &dirIndex{
+		Title:  "/" + remote,
+		Parent: remote != "",
+	}
+	for _, d := range dirs {
+		index.add(path.Base(d.Name)+"/", d.Bytes, d.When)
+	}
+	for _, o := range objects {
+		if fs.Config.Filter.IncludeObject(ctx, o) {
+			index.add(path.Base(o.Remote()), o.Size(), o.ModTime(ctx))
+		}
+	}
+	if remote != "" && len(index.Entries) == 0 && (h.made == nil || !h.made(remote)) {
+		http.NotFound(w, r)
+		return
+	}
+	if !isDir {
+		// Make relative links in the index work
+		location := &url.URL{Path: "./" + path.Base(urlPath) + "/"}
+		http.Redirect(w, r, location.String(), http.StatusMovedPermanently)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = dirIndexTemplate.Execute(w, index)
+	if err != nil {
+		fs.ErrorLog(remote, "Failed to send index: %v", err)
+	}
+}
+
+// serveObject sends o, or the ranges of it asked for, in reply to r
+func (h *httpHandler) serveObject(w http.ResponseWriter, r *http.Request, o fs.Object) {
+	ctx := r.Context()
+	mimeType := fs.MimeType(o)
+	if do, ok := o.(fs.Metadataer); ok {
+		if stored := do.MimeType(ctx); stored != "" {
+			mimeType = stored
+		}
+	}
+	w.Header().Set("Content-Type", mimeType)
+	in := newObjectReader(ctx, o, rangeEnd(r))
+	fs.Stats.Transferring(o)
+	defer fs.Stats.DoneTransferring(o)
+	http.ServeContent(w, r, path.Base(o.Remote()), o.ModTime(ctx), in)
+	err := in.Close()
+	if err != nil {
+		fs.ErrorLog(o, "Failed to close: %v", err)
+	}
+}
+
+// rangeEnd returns the last byte asked for by r if it has a single
+// "bytes=start-end" Range header, or -1 if it doesn't
+func rangeEnd(r *http.Request) int64 {
+	header := r.Header.Get("Range")
+	ranges := strings.TrimPrefix(header, "bytes=")
+	if ranges == header || strings.Contains(ranges, ",") {
+		return -1
+	}
+	i := strings.Index(ranges, "-")
+	if i <= 0 {
+		return -1
+	}
+	end, err := strconv.ParseInt(strings.TrimSpace(ranges[i+1:]), 10, 64)
+	if err != nil || end < 0 {
+		return -1
+	}
+	return end
+}
+
+// objectReader reads an Object as an io.ReadSeeker
+//
+// The object is opened at the current offset on the first Read after
+// a Seek so only the bytes wanted are fetched.
+type objectReader struct {
+	ctx    context.Context
+	o      fs.Object
+	offset int64
+	end    int64         // last byte expected to be read, -1 for the end
+	in     io.ReadCloser // nil if not open
+}
+
+// newObjectReader makes an objectReader for o
+//
+// If end >= 0 then only the bytes up to end are fetched when o is
+// opened, though reading past end still works.
+func newObjectReader(ctx context.Context, o fs.Object, end int64) *objectReader {
+	return &objectReader{ctx: ctx, o: o, end: end}
+}
+
+// Read reads from the object, opening it if necessary
+func (r *objectReader) Read(p []byte) (n int, err error) {
+	if r.in == nil {
+		var options []fs.OpenOption
+		if r.end >= r.offset {
+			options = append(options, &fs.RangeOption{Start: r.offset, End: r.end})
+		} else if r.offset > 0 {
+			options = append(options, &fs.SeekOption{Offset: r.offset})
+		}
+		in, err := r.o.Open(r.ctx, options...)
+		if err != nil {
+			fs.Stats.Error()
+			fs.ErrorLog(r.o, "Failed to open: %v", err)
+			return 0, err
+		}
+		r.in = fs.NewAccount(in, r.o) // account the transfer
+	}
+	n, err = r.in.Read(p)
+	r.offset += int64(n)
+	if err == io.EOF && r.end >= 0 && r.offset > r.end && r.offset < r.o.Size() {
+		// Read past the end of the range so fetch the rest
+		r.end = -1
+		err = r.Close()
+		if err == nil && n == 0 {
+			return r.Read(p)
+		}
+	}
+	return n, err
+}
+
+// Seek sets the offset for the next Read
+func (r *objectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.o.Size()
+	default:
+		return r.offset, fmt.Errorf("bad whence %d", whence)
+	}
+	if offset < 0 {
+		return r.offset, fmt.Errorf("negative offset %d", offset)
+	}
+	if offset != r.offset {
+		err := r.Close()
+		if err != nil {
+			return r.offset, err
+		}
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+// Close closes the object if it is open
+func (r *objectReader) Close() error {
+	if r.in == nil {
+		return nil
+	}
+	err := r.in.Close()
+	r.in = nil
+	return err
+}
+
+// Check it satisfies the interfaces
+var _ io.ReadSeeker = (*objectReader)(nil)
+
+// dirIndex is the data for dirIndexTemplate
+type dirIndex struct {
+	Title   string
+	Parent  bool // set if there is a parent directory
+	Entries []dirIndexEntry
+}
+
+// dirIndexEntry is a directory or object in a dirIndex
+type dirIndexEntry struct {
+	URL     string // link relative to the directory
+	Name    string
+	Size    string
+	ModTime string
+}
+
+// add an entry to the index - size < 0 and a zero modTime are unknown
+func (index *dirIndex) add(name string, size int64, modTime time.Time) {
+	entry := dirIndexEntry{
+		// "./" stops a name with a ":" looking like a scheme
+		URL:  (&url.URL{Path: "./" + name}).String(),
+		Name: name,
+		Size: "-",
+	}
+	if size >= 0 {
+		entry.Size = fmt.Sprint(size)
+	}
+	if !modTime.IsZero() {
+		entry.ModTime = modTime.Format("2006-01-02 15:04:05")
+	}
+	index.Entries = append(index.Entries, entry)
+}
+
+// dirIndexTemplate renders a dirIndex as HTML
+var dirIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Index of {{.Title}}</title>
+</head>
+<body>
+<h1>Index of {{.Title}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th></tr>
+{{- if .Parent}}
+<tr><td><a href="../">../</a></td><td></td><td></td></tr>
+{{- end}}
+{{- range .Entries}}
+<tr><td><a href="{{.URL}}">{{.Name}}</a></td><td>{{.Size}}</td><td>{{.ModTime}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
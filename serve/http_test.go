@@ -0,0 +1,267 @@
+package serve
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Shop2market/rclone/fs"
+	_ "github.com/Shop2market/rclone/local"
+)
+
+// newTestFs makes a local Fs in a temporary directory containing
+// files, returning it and a function to remove it
+func newTestFs(t *testing.T, files map[string]string) (fs.Fs, func()) {
+	fs.LoadConfig()
+	dir, err := ioutil.TempDir("", "rclone-serve")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	for name, contents := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(name), 0700)
+		if err != nil {
+			t.Fatalf("Failed to make dir: %v", err)
+		}
+		err = ioutil.WriteFile(name, []byte(contents), 0600)
+		if err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	f, err := fs.NewFs(dir)
+	if err != nil {
+		t.Fatalf("Failed to make %q: %v", dir, err)
+	}
+	return f, func() {
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// get fetches url from the server with the headers given, returning
+// the response and its body
+func get(t *testing.T, method, url string, headers ...string) (*http.Response, string) {
+	return send(t, method, url, nil, headers...)
+}
+
+// send sends body to url with the headers given, returning the
+// response and its body
+func send(t *testing.T, method, url string, body io.Reader, headers ...string) (*http.Response, string) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body of %s %s: %v", method, url, err)
+	}
+	return resp, string(respBody)
+}
+
+func TestHTTP(t *testing.T) {
+	f, cleanup := newTestFs(t, map[string]string{
+		"file.txt":         "0123456789",
+		"dir/one two.html": "<p>hello</p>",
+		"odd?dir: #/x.txt": "x",
+	})
+	defer cleanup()
+	server := httptest.NewServer(NewHTTPHandler(f))
+	defer server.Close()
+
+	resp, body := get(t, "GET", server.URL+"/")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("index: want status 200 got %d", resp.StatusCode)
+	}
+	for _, want := range []string{`<a href="./dir/">dir/</a>`, `<a href="./file.txt">file.txt</a></td><td>10</td>`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index: %q missing from %q", want, body)
+		}
+	}
+	if strings.Contains(body, `href="../"`) {
+		t.Errorf("index: root shouldn't link to parent: %q", body)
+	}
+
+	// Directories without a trailing / are redirected
+	resp, body = get(t, "GET", server.URL+"/dir")
+	if resp.StatusCode != http.StatusOK || resp.Request.URL.Path != "/dir/" {
+		t.Fatalf("dir: want status 200 at /dir/ got %d at %q", resp.StatusCode, resp.Request.URL.Path)
+	}
+	for _, want := range []string{`href="../"`, `<a href="./one%20two.html">one two.html</a>`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("dir: %q missing from %q", want, body)
+		}
+	}
+
+	// The redirect must escape the directory name
+	resp, body = get(t, "GET", server.URL+"/odd%3Fdir:%20%23")
+	if resp.StatusCode != http.StatusOK || resp.Request.URL.Path != "/odd?dir: #/" || !strings.Contains(body, `href="./x.txt"`) {
+		t.Errorf("odd dir: want status 200 at %q got %d at %q: %q", "/odd?dir: #/", resp.StatusCode, resp.Request.URL.Path, body)
+	}
+
+	resp, body = get(t, "GET", server.URL+"/dir/one%20two.html")
+	if resp.StatusCode != http.StatusOK || body != "<p>hello</p>" {
+		t.Errorf("object: want 200 %q got %d %q", "<p>hello</p>", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("object: want html Content-Type got %q", got)
+	}
+
+	for _, test := range []struct {
+		rangeHeader  string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"", http.StatusOK, "0123456789", ""},
+		{"bytes=2-5", http.StatusPartialContent, "2345", "bytes 2-5/10"},
+		{"bytes=7-", http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"bytes=-3", http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"bytes=20-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+	} {
+		var headers []string
+		if test.rangeHeader != "" {
+			headers = []string{"Range", test.rangeHeader}
+		}
+		resp, body = get(t, "GET", server.URL+"/file.txt", headers...)
+		if resp.StatusCode != test.status {
+			t.Errorf("range %q: want status %d got %d", test.rangeHeader, test.status, resp.StatusCode)
+		}
+		if test.status != http.StatusRequestedRangeNotSatisfiable && body != test.body {
+			t.Errorf("range %q: want %q got %q", test.rangeHeader, test.body, body)
+		}
+		if got := resp.Header.Get("Content-Range"); got != test.contentRange {
+			t.Errorf("range %q: want Content-Range %q got %q", test.rangeHeader, test.contentRange, got)
+		}
+	}
+
+	resp, body = get(t, "HEAD", server.URL+"/file.txt")
+	if resp.StatusCode != http.StatusOK || body != "" || resp.ContentLength != 10 {
+		t.Errorf("HEAD: want 200 with no body and length 10 got %d %q %d", resp.StatusCode, body, resp.ContentLength)
+	}
+
+	resp, _ = get(t, "GET", server.URL+"/potato")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing: want status 404 got %d", resp.StatusCode)
+	}
+
+	resp, _ = get(t, "PUT", server.URL+"/file.txt")
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("PUT: want status 405 got %d", resp.StatusCode)
+	}
+}
+
+func TestHandlerAuth(t *testing.T) {
+	opt := &Options{User: "user", Pass: "pass"}
+	server := httptest.NewServer(opt.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})))
+	defer server.Close()
+
+	for _, test := range []struct {
+		user, pass string
+		status     int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"user", "wrong", http.StatusUnauthorized},
+		{"wrong", "pass", http.StatusUnauthorized},
+		{"user", "pass", http.StatusOK},
+	} {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		if test.user != "" {
+			req.SetBasicAuth(test.user, test.pass)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("%q/%q: want status %d got %d", test.user, test.pass, test.status, resp.StatusCode)
+		}
+		if test.status == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("%q/%q: missing WWW-Authenticate header", test.user, test.pass)
+		}
+	}
+}
+
+func TestRangeEnd(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want int64
+	}{
+		{"", -1},
+		{"bytes=2-5", 5},
+		{"bytes=7-", -1},
+		{"bytes=-3", -1},
+		{"bytes=0-1,4-5", -1},
+		{"items=2-5", -1},
+		{"bytes=2-x", -1},
+	} {
+		r := &http.Request{Header: http.Header{}}
+		if test.in != "" {
+			r.Header.Set("Range", test.in)
+		}
+		if got := rangeEnd(r); got != test.want {
+			t.Errorf("%q: want %d got %d", test.in, test.want, got)
+		}
+	}
+}
+
+// openRecorder records the options each Open of the Object is given
+type openRecorder struct {
+	fs.Object
+	opens [][]fs.OpenOption
+}
+
+func (o *openRecorder) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	o.opens = append(o.opens, options)
+	return o.Object.Open(ctx, options...)
+}
+
+func TestObjectReaderRange(t *testing.T) {
+	f, cleanup := newTestFs(t, map[string]string{"file.txt": "0123456789"})
+	defer cleanup()
+	o := &openRecorder{Object: f.NewFsObject(context.Background(), "file.txt")}
+	in := newObjectReader(context.Background(), o, 4)
+	_, err := in.Seek(2, io.SeekStart)
+	if err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	buf := make([]byte, 3)
+	_, err = io.ReadFull(in, buf)
+	if err != nil || string(buf) != "234" {
+		t.Fatalf("want %q got %q: %v", "234", buf, err)
+	}
+	if len(o.opens) != 1 || len(o.opens[0]) != 1 || o.opens[0][0].String() != "RangeOption(2,4)" {
+		t.Errorf("want opened with RangeOption(2,4) got %v", o.opens)
+	}
+
+	// Reading past the range fetches the rest
+	rest, err := ioutil.ReadAll(in)
+	if err != nil || string(rest) != "56789" {
+		t.Errorf("want %q got %q: %v", "56789", rest, err)
+	}
+	if len(o.opens) != 2 || len(o.opens[1]) != 1 || o.opens[1][0].String() != "SeekOption(5)" {
+		t.Errorf("want reopened with SeekOption(5) got %v", o.opens)
+	}
+	err = in.Close()
+	if err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package serve serves the objects in an Fs over network protocols
+package serve
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/Shop2market/rclone/fs"
+)
+
+// Options for the servers
+type Options struct {
+	Addr     string // host:port to listen on
+	User     string // if set basic authentication with this user is required
+	Pass     string // password for User
+	CertFile string // if set serve HTTPS using this TLS certificate
+	KeyFile  string // and this TLS key
+}
+
+// DefaultAddr is the address the servers listen on if Options.Addr
+// isn't set
+const DefaultAddr = "localhost:8080"
+
+// Handler returns handler wrapped so that it requires basic
+// authentication if opt.User is set
+func (opt *Options) Handler(handler http.Handler) http.Handler {
+	if opt.User == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(opt.User)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(opt.Pass)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="rclone"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServe serves handler on opt.Addr with the authentication
+// set in opt, using HTTPS if a certificate is set
+//
+// It only returns if the server fails.
+func (opt *Options) ListenAndServe(handler http.Handler) error {
+	addr := opt.Addr
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	handler = opt.Handler(handler)
+	if opt.CertFile != "" || opt.KeyFile != "" {
+		if opt.CertFile == "" || opt.KeyFile == "" {
+			return fmt.Errorf("need both a TLS certificate and key to serve HTTPS")
+		}
+		fs.Log(nil, "Serving on https://%s/", addr)
+		return http.ListenAndServeTLS(addr, opt.CertFile, opt.KeyFile, handler)
+	}
+	fs.Log(nil, "Serving on http://%s/", addr)
+	return http.ListenAndServe(addr, handler)
+}
+
+// remotePath returns the path relative to the root of the Fs for the
+// URL path urlPath, "" being the root
+func remotePath(urlPath string) string {
+	return strings.Trim(path.Clean("/"+urlPath), "/")
+}
+
+// parentDir returns the directory remote is in, "" being the root
+func parentDir(remote string) string {
+	dir := path.Dir(remote)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
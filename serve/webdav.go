@@ -0,0 +1,562 @@
+// Serve an Fs over WebDAV
+
+package serve
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shop2market/rclone/fs"
+)
+
+// WebDAV serves f over WebDAV using opt
+//
+// It only returns if the server fails.
+func WebDAV(f fs.Fs, opt *Options) error {
+	return opt.ListenAndServe(NewWebDAVHandler(f))
+}
+
+// webdavHandler serves the objects in an Fs over WebDAV
+type webdavHandler struct {
+	f    fs.Fs
+	http *httpHandler // serves GET and HEAD
+
+	mu   sync.Mutex
+	made map[string]bool // directories made which f can't store
+}
+
+// NewWebDAVHandler returns an http.Handler which serves f over WebDAV
+// class 1 - obeys includes and excludes
+//
+// Directories made on remotes which can't store empty directories
+// are remembered by the handler so they can be listed and used.
+func NewWebDAVHandler(f fs.Fs) http.Handler {
+	h := &webdavHandler{
+		f:    f,
+		made: make(map[string]bool),
+	}
+	h.http = &httpHandler{f: f, list: h.list, made: h.isMade}
+	return h
+}
+
+// webdavMethods are the methods webdavHandler supports
+const webdavMethods = "OPTIONS, GET, HEAD, PROPFIND, PUT, DELETE, MKCOL, COPY, MOVE"
+
+// ServeHTTP serves a single request
+func (h *webdavHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	remote := remotePath(r.URL.Path)
+	var status int
+	var err error
+	switch r.Method {
+	case "OPTIONS":
+		w.Header().Set("DAV", "1")
+		w.Header().Set("MS-Author-Via", "DAV")
+		w.Header().Set("Allow", webdavMethods)
+		status = http.StatusOK
+	case "GET", "HEAD":
+		h.http.ServeHTTP(w, r)
+		return
+	case "PROPFIND":
+		status, err = h.propfind(w, r, remote)
+	case "PUT":
+		status, err = h.put(r, remote)
+	case "DELETE":
+		status, err = h.delete(r.Context(), remote)
+	case "MKCOL":
+		status, err = h.mkcol(r, remote)
+	case "COPY", "MOVE":
+		status, err = h.copyMove(r, remote, r.Method == "MOVE")
+	default:
+		w.Header().Set("Allow", webdavMethods)
+		status = http.StatusMethodNotAllowed
+	}
+	if err != nil {
+		fs.Stats.Error()
+		fs.ErrorLog(remote, "%s failed: %v", r.Method, err)
+	}
+	switch {
+	case status == 0:
+		// reply already sent
+	case status >= 400:
+		http.Error(w, http.StatusText(status), status)
+	default:
+		w.WriteHeader(status)
+	}
+}
+
+// list returns the objects and directories directly in dir including
+// any directories made which f can't store - obeys includes and
+// excludes
+func (h *webdavHandler) list(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	all, dirs, err := fs.ListDirSorted(ctx, h.f, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, o := range all {
+		if fs.Config.Filter.IncludeObject(ctx, o) {
+			objects = append(objects, o)
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+outer:
+	for made := range h.made {
+		if parentDir(made) != dir {
+			continue
+		}
+		for _, d := range dirs {
+			if d.Name == made {
+				continue outer
+			}
+		}
+		dirs = append(dirs, &fs.Dir{Name: made, Bytes: -1, Count: -1})
+	}
+	return objects, dirs, nil
+}
+
+// isMade reports whether dir is a directory made which f can't store
+func (h *webdavHandler) isMade(dir string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.made[dir]
+}
+
+// stat finds what is at remote, returning the object if it is one
+// and whether it is a directory.  If neither then it doesn't exist.
+func (h *webdavHandler) stat(ctx context.Context, remote string) (o fs.Object, isDir bool, err error) {
+	if remote == "" {
+		return nil, true, nil
+	}
+	o = h.f.NewFsObject(ctx, remote)
+	if o != nil && fs.Config.Filter.IncludeObject(ctx, o) {
+		return o, false, nil
+	}
+	_, dirs, err := h.list(ctx, parentDir(remote))
+	if err != nil {
+		return nil, false, err
+	}
+	for _, d := range dirs {
+		if d.Name == remote {
+			return nil, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// multistatus is the reply to PROPFIND
+type multistatus struct {
+	XMLName   xml.Name       `xml:"D:multistatus"`
+	XMLNS     string         `xml:"xmlns:D,attr"`
+	Responses []propResponse `xml:"D:response"`
+}
+
+// propResponse holds the properties of a directory or object
+type propResponse struct {
+	Href   string  `xml:"D:href"`
+	Prop   davProp `xml:"D:propstat>D:prop"`
+	Status string  `xml:"D:propstat>D:status"`
+}
+
+// davProp are the properties of a directory or object
+type davProp struct {
+	DisplayName   string          `xml:"D:displayname"`
+	ResourceType  davResourceType `xml:"D:resourcetype"`
+	ContentLength string          `xml:"D:getcontentlength,omitempty"`
+	ContentType   string          `xml:"D:getcontenttype,omitempty"`
+	LastModified  string          `xml:"D:getlastmodified,omitempty"`
+}
+
+// davResourceType marks directories as collections
+type davResourceType struct {
+	Collection *struct{} `xml:"D:collection"`
+}
+
+// add the properties of remote to the reply - size < 0 and a zero
+// modTime are unknown
+func (ms *multistatus) add(remote string, isDir bool, size int64, mimeType string, modTime time.Time) {
+	href := "/" + remote
+	resp := propResponse{
+		Prop: davProp{
+			DisplayName: path.Base(remote),
+			ContentType: mimeType,
+		},
+		Status: "HTTP/1.1 200 OK",
+	}
+	if remote == "" {
+		resp.Prop.DisplayName = ""
+	}
+	if isDir {
+		if remote != "" {
+			href += "/"
+		}
+		resp.Prop.ResourceType.Collection = &struct{}{}
+	} else if size >= 0 {
+		resp.Prop.ContentLength = fmt.Sprint(size)
+	}
+	if !modTime.IsZero() {
+		resp.Prop.LastModified = modTime.UTC().Format(http.TimeFormat)
+	}
+	resp.Href = (&url.URL{Path: href}).EscapedPath()
+	ms.Responses = append(ms.Responses, resp)
+}
+
+// propfind replies with the properties of remote and, unless the
+// Depth is 0, those of its contents if it is a directory
+//
+// The properties asked for are ignored and the same ones are always
+// sent.  A Depth of infinity is treated as 1 as listing a whole
+// remote can take a very long time.
+func (h *webdavHandler) propfind(w http.ResponseWriter, r *http.Request, remote string) (int, error) {
+	ctx := r.Context()
+	o, isDir, err := h.stat(ctx, remote)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if o == nil && !isDir {
+		return http.StatusNotFound, nil
+	}
+	ms := &multistatus{XMLNS: "DAV:"}
+	if o != nil {
+		ms.add(remote, false, o.Size(), fs.MimeType(o), o.ModTime(ctx))
+	} else {
+		ms.add(remote, true, -1, "", time.Time{})
+		if r.Header.Get("Depth") != "0" {
+			objects, dirs, err := h.list(ctx, remote)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+			for _, d := range dirs {
+				ms.add(d.Name, true, -1, "", d.When)
+			}
+			for _, o := range objects {
+				ms.add(o.Remote(), false, o.Size(), fs.MimeType(o), o.ModTime(ctx))
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	_, err = io.WriteString(w, xml.Header)
+	if err == nil {
+		err = xml.NewEncoder(w).Encode(ms)
+	}
+	if err != nil {
+		fs.ErrorLog(remote, "Failed to send PROPFIND reply: %v", err)
+	}
+	return 0, nil
+}
+
+// put uploads the body of r to remote
+//
+// If the size of the body isn't known it is spooled to a temporary
+// file first so it can be uploaded to any remote.  The directory it
+// goes in must exist already.
+func (h *webdavHandler) put(r *http.Request, remote string) (int, error) {
+	if remote == "" || strings.HasSuffix(r.URL.Path, "/") {
+		return http.StatusMethodNotAllowed, nil
+	}
+	ctx := r.Context()
+	_, parentIsDir, err := h.stat(ctx, parentDir(remote))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !parentIsDir {
+		return http.StatusConflict, nil
+	}
+	var in io.Reader = r.Body
+	size := r.ContentLength
+	if size < 0 {
+		spool, n, cleanup, err := fs.SpoolToTempFile(r.Body, "rclone-webdav")
+		if err != nil {
+			return http.StatusBadRequest, fmt.Errorf("failed to read body: %v", err)
+		}
+		defer cleanup()
+		in, size = spool, n
+	}
+	metadata := &fs.MetadataOption{MimeType: r.Header.Get("Content-Type")}
+	acc := fs.NewAccountSizeName(ioutil.NopCloser(in), size, remote) // account the transfer
+	defer func() {
+		_ = acc.Close() // ignore error
+	}()
+	modTime := time.Now()
+	if o := h.f.NewFsObject(ctx, remote); o != nil {
+		err := o.Update(ctx, acc, modTime, size, metadata)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to update: %v", err)
+		}
+		return http.StatusNoContent, nil
+	}
+	_, err = h.f.Put(ctx, acc, remote, modTime, size, metadata)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to upload: %v", err)
+	}
+	return http.StatusCreated, nil
+}
+
+// delete removes the object or the directory and everything in it
+// at remote
+func (h *webdavHandler) delete(ctx context.Context, remote string) (int, error) {
+	if remote == "" {
+		return http.StatusForbidden, nil
+	}
+	o, isDir, err := h.stat(ctx, remote)
+	switch {
+	case err != nil:
+		return http.StatusInternalServerError, err
+	case o != nil:
+		err = o.Remove(ctx)
+	case isDir:
+		err = h.removeDir(ctx, remote)
+	default:
+		return http.StatusNotFound, nil
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusNoContent, nil
+}
+
+// removeDir removes dir and everything in it
+func (h *webdavHandler) removeDir(ctx context.Context, dir string) error {
+	objects, dirs, err := h.list(ctx, dir)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		err = h.removeDir(ctx, d.Name)
+		if err != nil {
+			return err
+		}
+	}
+	for _, o := range objects {
+		err = o.Remove(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to remove %q: %v", o.Remote(), err)
+		}
+	}
+	h.mu.Lock()
+	delete(h.made, dir)
+	h.mu.Unlock()
+	if removeDir := h.f.Features().RemoveDir; removeDir != nil {
+		err = removeDir(ctx, dir)
+		if err != nil {
+			return fmt.Errorf("failed to remove directory %q: %v", dir, err)
+		}
+	}
+	return nil
+}
+
+// makeDir makes dir, or remembers it if f can't store empty
+// directories
+func (h *webdavHandler) makeDir(ctx context.Context, dir string) error {
+	if makeDir := h.f.Features().MakeDir; makeDir != nil {
+		return makeDir(ctx, dir)
+	}
+	h.mu.Lock()
+	h.made[dir] = true
+	h.mu.Unlock()
+	return nil
+}
+
+// mkcol makes the directory remote
+func (h *webdavHandler) mkcol(r *http.Request, remote string) (int, error) {
+	ctx := r.Context()
+	if r.ContentLength > 0 {
+		return http.StatusUnsupportedMediaType, nil
+	}
+	o, isDir, err := h.stat(ctx, remote)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if o != nil || isDir {
+		return http.StatusMethodNotAllowed, nil
+	}
+	_, parentIsDir, err := h.stat(ctx, parentDir(remote))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !parentIsDir {
+		return http.StatusConflict, nil
+	}
+	err = h.makeDir(ctx, remote)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusCreated, nil
+}
+
+// copyMove copies or moves remote to the Destination of r
+//
+// Objects are copied and moved with server side operations if f
+// supports them, as are directories if f can move them.
+func (h *webdavHandler) copyMove(r *http.Request, remote string, move bool) (int, error) {
+	ctx := r.Context()
+	destination := r.Header.Get("Destination")
+	if destination == "" {
+		return http.StatusBadRequest, nil
+	}
+	dest, err := url.Parse(destination)
+	if err != nil {
+		return http.StatusBadRequest, nil
+	}
+	dstRemote := remotePath(dest.Path)
+	if remote == "" || dstRemote == "" || dstRemote == remote || strings.HasPrefix(dstRemote, remote+"/") {
+		return http.StatusForbidden, nil
+	}
+	o, isDir, err := h.stat(ctx, remote)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if o == nil && !isDir {
+		return http.StatusNotFound, nil
+	}
+	_, parentIsDir, err := h.stat(ctx, parentDir(dstRemote))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !parentIsDir {
+		return http.StatusConflict, nil
+	}
+	dst, dstIsDir, err := h.stat(ctx, dstRemote)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	status := http.StatusCreated
+	if dst != nil || dstIsDir {
+		if r.Header.Get("Overwrite") == "F" {
+			return http.StatusPreconditionFailed, nil
+		}
+		status = http.StatusNoContent
+		// An object replacing an object is updated in place
+		if o == nil || dstIsDir {
+			if dst != nil {
+				err = dst.Remove(ctx)
+			} else {
+				err = h.removeDir(ctx, dstRemote)
+			}
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+			dst = nil
+		}
+	}
+	if o != nil {
+		err = h.copyMoveObject(ctx, dst, dstRemote, o, move)
+	} else {
+		err = h.copyMoveDir(ctx, remote, dstRemote, move)
+	}
+	if err != nil {
+		// already counted and logged
+		return http.StatusInternalServerError, nil
+	}
+	return status, nil
+}
+
+// copyMoveObject copies or moves src to dst, or to remote if dst is
+// nil - any error returned has already been counted and logged
+func (h *webdavHandler) copyMoveObject(ctx context.Context, dst fs.Object, remote string, src fs.Object, move bool) error {
+	if move {
+		return fs.Move(ctx, h.f, dst, remote, src)
+	}
+	return fs.Copy(ctx, h.f, dst, remote, src)
+}
+
+// copyMoveDir copies or moves the directory src and everything in it
+// to dst which mustn't exist - any error returned has already been
+// counted and logged
+func (h *webdavHandler) copyMoveDir(ctx context.Context, src, dst string, move bool) error {
+	if move && h.dirMove(ctx, src, dst) {
+		return nil
+	}
+	err := h.makeDir(ctx, dst)
+	if err != nil {
+		fs.Stats.Error()
+		fs.ErrorLog(dst, "Failed to make directory: %v", err)
+		return err
+	}
+	objects, dirs, err := h.list(ctx, src)
+	if err != nil {
+		fs.Stats.Error()
+		fs.ErrorLog(src, "Failed to list: %v", err)
+		return err
+	}
+	for _, d := range dirs {
+		err = h.copyMoveDir(ctx, d.Name, path.Join(dst, path.Base(d.Name)), move)
+		if err != nil {
+			return err
+		}
+	}
+	for _, o := range objects {
+		err = h.copyMoveObject(ctx, nil, path.Join(dst, path.Base(o.Remote())), o, move)
+		if err != nil {
+			return err
+		}
+	}
+	if move {
+		err = h.removeDir(ctx, src)
+		if err != nil {
+			fs.Stats.Error()
+			fs.ErrorLog(src, "Failed to remove: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// dirMove moves the directory src to dst with a server side
+// directory move if f can do one, returning whether it did
+func (h *webdavHandler) dirMove(ctx context.Context, src, dst string) bool {
+	if h.f.Features().DirMove == nil {
+		return false
+	}
+	fsrc, err := newSubFs(h.f, src)
+	if err != nil {
+		fs.Debug(h.f, "Can't make Fs for server side directory move: %v", err)
+		return false
+	}
+	fdst, err := newSubFs(h.f, dst)
+	if err != nil {
+		fs.Debug(h.f, "Can't make Fs for server side directory move: %v", err)
+		return false
+	}
+	doDirMove := fdst.Features().DirMove
+	if doDirMove == nil {
+		return false
+	}
+	err = doDirMove(ctx, fsrc)
+	if err != nil {
+		fs.Debug(fdst, "Server side directory move failed - fallback to copy/delete: %v", err)
+		return false
+	}
+	// h.f may have cached the IDs of the directories moved
+	if do := h.f.Features().DirCacheFlush; do != nil {
+		do()
+	}
+	h.mu.Lock()
+	delete(h.made, src)
+	h.mu.Unlock()
+	return true
+}
+
+// newSubFs makes an Fs for dir in f from the same remote
+func newSubFs(f fs.Fs, dir string) (fs.Fs, error) {
+	fsName := f.Name()
+	if fs.ConfigFile != nil {
+		if configType, err := fs.ConfigFile.GetValue(f.Name(), "type"); err == nil {
+			fsName = configType
+		}
+	}
+	regInfo, err := fs.Find(fsName)
+	if err != nil {
+		return nil, err
+	}
+	return regInfo.NewFs(f.Name(), path.Join(f.Root(), dir))
+}
@@ -0,0 +1,414 @@
+package serve
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shop2market/rclone/dircache"
+	"github.com/Shop2market/rclone/fs"
+	"github.com/Shop2market/rclone/local"
+)
+
+// testMultistatus is the part of a PROPFIND reply the tests check
+type testMultistatus struct {
+	Responses []struct {
+		Href          string    `xml:"DAV: href"`
+		ContentLength string    `xml:"DAV: propstat>prop>getcontentlength"`
+		Collection    *struct{} `xml:"DAV: propstat>prop>resourcetype>collection"`
+	} `xml:"DAV: response"`
+}
+
+func TestWebDAV(t *testing.T) {
+	f, cleanup := newTestFs(t, map[string]string{
+		"file.txt":  "0123456789",
+		"dir/a.txt": "aaa",
+	})
+	defer cleanup()
+	server := httptest.NewServer(NewWebDAVHandler(f))
+	defer server.Close()
+
+	// checkFile checks the contents of the file at remote, or that
+	// it doesn't exist if want is ""
+	checkFile := func(what, remote, want string) {
+		got, err := ioutil.ReadFile(filepath.Join(f.Root(), filepath.FromSlash(remote)))
+		if want == "" {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s: %q shouldn't exist: %v", what, remote, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Errorf("%s: failed to read %q: %v", what, remote, err)
+		} else if string(got) != want {
+			t.Errorf("%s: %q: want %q got %q", what, remote, want, string(got))
+		}
+	}
+
+	// check sends a request and checks the status of the reply
+	check := func(method, remote string, body string, status int, headers ...string) string {
+		var in io.Reader
+		if body != "" {
+			in = strings.NewReader(body)
+		}
+		resp, respBody := send(t, method, server.URL+remote, in, headers...)
+		if resp.StatusCode != status {
+			t.Errorf("%s %s: want status %d got %d", method, remote, status, resp.StatusCode)
+		}
+		return respBody
+	}
+
+	resp, _ := get(t, "OPTIONS", server.URL+"/")
+	if resp.Header.Get("DAV") != "1" {
+		t.Errorf("OPTIONS: want DAV 1 got %q", resp.Header.Get("DAV"))
+	}
+
+	// propfind returns the hrefs in the reply sorted and the content
+	// lengths and whether they are collections by href
+	propfind := func(remote, depth string) (hrefs []string, lengths map[string]string, collections map[string]bool) {
+		body := check("PROPFIND", remote, "", http.StatusMultiStatus, "Depth", depth)
+		var ms testMultistatus
+		err := xml.Unmarshal([]byte(body), &ms)
+		if err != nil {
+			t.Fatalf("PROPFIND %s: bad reply %q: %v", remote, body, err)
+		}
+		lengths = make(map[string]string)
+		collections = make(map[string]bool)
+		for _, resp := range ms.Responses {
+			hrefs = append(hrefs, resp.Href)
+			lengths[resp.Href] = resp.ContentLength
+			collections[resp.Href] = resp.Collection != nil
+		}
+		sort.Strings(hrefs)
+		return hrefs, lengths, collections
+	}
+
+	hrefs, lengths, collections := propfind("/", "1")
+	if got := strings.Join(hrefs, " "); got != "/ /dir/ /file.txt" {
+		t.Errorf("PROPFIND /: want hrefs %q got %q", "/ /dir/ /file.txt", got)
+	}
+	if !collections["/"] || !collections["/dir/"] || collections["/file.txt"] || lengths["/file.txt"] != "10" {
+		t.Errorf("PROPFIND /: wrong properties %v %v", collections, lengths)
+	}
+	hrefs, lengths, _ = propfind("/file.txt", "0")
+	if len(hrefs) != 1 || lengths["/file.txt"] != "10" {
+		t.Errorf("PROPFIND /file.txt: wrong reply %v %v", hrefs, lengths)
+	}
+	hrefs, _, _ = propfind("/dir", "0")
+	if got := strings.Join(hrefs, " "); got != "/dir/" {
+		t.Errorf("PROPFIND /dir depth 0: want %q got %q", "/dir/", got)
+	}
+	check("PROPFIND", "/potato", "", http.StatusNotFound)
+
+	// PUT with a known size and then an unknown size
+	check("PUT", "/new.txt", "hello", http.StatusCreated)
+	checkFile("PUT", "new.txt", "hello")
+	resp, _ = send(t, "PUT", server.URL+"/dir/new.txt", ioutil.NopCloser(strings.NewReader("chunky")))
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("PUT chunked: want status 201 got %d", resp.StatusCode)
+	}
+	checkFile("PUT chunked", "dir/new.txt", "chunky")
+	resp, _ = send(t, "PUT", server.URL+"/new.txt", ioutil.NopCloser(strings.NewReader("hello again")))
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("PUT chunked update: want status 204 got %d", resp.StatusCode)
+	}
+	checkFile("PUT chunked update", "new.txt", "hello again")
+	check("PUT", "/missing/dir/new.txt", "hello", http.StatusConflict)
+	checkFile("PUT without parent", "missing/dir/new.txt", "")
+	checkFile("PUT without parent", "missing", "")
+
+	check("MKCOL", "/made", "", http.StatusCreated)
+	check("MKCOL", "/made", "", http.StatusMethodNotAllowed)
+	check("MKCOL", "/x/y", "", http.StatusConflict)
+	hrefs, _, collections = propfind("/made", "1")
+	if len(hrefs) != 1 || !collections["/made/"] {
+		t.Errorf("PROPFIND /made: want empty directory got %v %v", hrefs, collections)
+	}
+
+	check("COPY", "/file.txt", "", http.StatusCreated, "Destination", server.URL+"/made/copy.txt")
+	checkFile("COPY", "made/copy.txt", "0123456789")
+	checkFile("COPY", "file.txt", "0123456789")
+	check("COPY", "/dir/a.txt", "", http.StatusPreconditionFailed, "Destination", server.URL+"/made/copy.txt", "Overwrite", "F")
+	check("COPY", "/dir/a.txt", "", http.StatusNoContent, "Destination", server.URL+"/made/copy.txt")
+	checkFile("COPY overwrite", "made/copy.txt", "aaa")
+	check("COPY", "/potato", "", http.StatusNotFound, "Destination", server.URL+"/potato2")
+	check("COPY", "/file.txt", "", http.StatusConflict, "Destination", server.URL+"/x/y")
+	check("COPY", "/dir", "", http.StatusForbidden, "Destination", server.URL+"/dir/sub")
+
+	check("COPY", "/dir", "", http.StatusCreated, "Destination", server.URL+"/dircopy")
+	checkFile("COPY dir", "dircopy/a.txt", "aaa")
+	checkFile("COPY dir", "dircopy/new.txt", "chunky")
+	checkFile("COPY dir", "dir/a.txt", "aaa")
+
+	check("MOVE", "/made", "", http.StatusCreated, "Destination", server.URL+"/moved")
+	checkFile("MOVE dir", "moved/copy.txt", "aaa")
+	checkFile("MOVE dir", "made/copy.txt", "")
+	check("MOVE", "/moved/copy.txt", "", http.StatusCreated, "Destination", server.URL+"/renamed.txt")
+	checkFile("MOVE", "renamed.txt", "aaa")
+	checkFile("MOVE", "moved/copy.txt", "")
+	check("MOVE", "/file.txt", "", http.StatusNoContent, "Destination", server.URL+"/renamed.txt")
+	checkFile("MOVE overwrite", "renamed.txt", "0123456789")
+	checkFile("MOVE overwrite", "file.txt", "")
+
+	check("DELETE", "/dircopy", "", http.StatusNoContent)
+	checkFile("DELETE dir", "dircopy/a.txt", "")
+	if _, err := os.Stat(filepath.Join(f.Root(), "dircopy")); !os.IsNotExist(err) {
+		t.Errorf("DELETE dir: directory still exists: %v", err)
+	}
+	check("DELETE", "/renamed.txt", "", http.StatusNoContent)
+	checkFile("DELETE", "renamed.txt", "")
+	check("DELETE", "/renamed.txt", "", http.StatusNotFound)
+	check("DELETE", "/", "", http.StatusForbidden)
+
+	check("PROPPATCH", "/new.txt", "", http.StatusMethodNotAllowed)
+
+	// GET is served as for serve http
+	if body := check("GET", "/new.txt", "", http.StatusOK); body != "hello again" {
+		t.Errorf("GET: want %q got %q", "hello again", body)
+	}
+}
+
+// noDirsFs is an Fs which can't store empty directories, like bucket
+// based remotes
+type noDirsFs struct {
+	fs.Fs
+}
+
+// Features returns the optional features without the directory ones
+func (f noDirsFs) Features() *fs.Features {
+	ft := *f.Fs.Features()
+	ft.MakeDir = nil
+	ft.RemoveDir = nil
+	ft.DirMove = nil
+	return &ft
+}
+
+func TestWebDAVWithoutDirs(t *testing.T) {
+	f, cleanup := newTestFs(t, map[string]string{"file.txt": "0123456789"})
+	defer cleanup()
+	server := httptest.NewServer(NewWebDAVHandler(noDirsFs{f}))
+	defer server.Close()
+
+	// check sends a request and checks the status of the reply
+	check := func(method, remote string, status int, headers ...string) string {
+		resp, body := get(t, method, server.URL+remote, headers...)
+		if resp.StatusCode != status {
+			t.Errorf("%s %s: want status %d got %d", method, remote, status, resp.StatusCode)
+		}
+		return body
+	}
+	// hrefs returns the hrefs in the reply to PROPFIND
+	hrefs := func(remote string) string {
+		body := check("PROPFIND", remote, http.StatusMultiStatus, "Depth", "1")
+		var ms testMultistatus
+		err := xml.Unmarshal([]byte(body), &ms)
+		if err != nil {
+			t.Fatalf("PROPFIND %s: bad reply %q: %v", remote, body, err)
+		}
+		var hrefs []string
+		for _, resp := range ms.Responses {
+			hrefs = append(hrefs, resp.Href)
+		}
+		sort.Strings(hrefs)
+		return strings.Join(hrefs, " ")
+	}
+
+	check("MKCOL", "/empty", http.StatusCreated)
+	check("MKCOL", "/empty/sub", http.StatusCreated)
+	if _, err := os.Stat(filepath.Join(f.Root(), "empty")); !os.IsNotExist(err) {
+		t.Errorf("MKCOL: directory shouldn't be made on the remote: %v", err)
+	}
+	if got, want := hrefs("/"), "/ /empty/ /file.txt"; got != want {
+		t.Errorf("PROPFIND /: want %q got %q", want, got)
+	}
+	if got, want := hrefs("/empty"), "/empty/ /empty/sub/"; got != want {
+		t.Errorf("PROPFIND /empty: want %q got %q", want, got)
+	}
+
+	// GET and HEAD serve indexes of the directories made
+	if body := check("GET", "/empty/", http.StatusOK); !strings.Contains(body, `href="./sub/"`) {
+		t.Errorf("GET /empty/: index doesn't contain sub/: %q", body)
+	}
+	check("GET", "/empty/sub/", http.StatusOK)
+	check("HEAD", "/empty/sub/", http.StatusOK)
+	check("GET", "/potato/", http.StatusNotFound)
+
+	check("MOVE", "/empty", http.StatusCreated, "Destination", server.URL+"/moved")
+	check("PROPFIND", "/empty", http.StatusNotFound)
+	if got, want := hrefs("/moved"), "/moved/ /moved/sub/"; got != want {
+		t.Errorf("PROPFIND /moved: want %q got %q", want, got)
+	}
+
+	check("DELETE", "/moved", http.StatusNoContent)
+	check("PROPFIND", "/moved", http.StatusNotFound)
+	if got, want := hrefs("/"), "/ /file.txt"; got != want {
+		t.Errorf("PROPFIND / after DELETE: want %q got %q", want, got)
+	}
+
+	// PUT into a directory which is only remembered
+	check("MKCOL", "/made", http.StatusCreated)
+	resp, _ := send(t, "PUT", server.URL+"/made/new.txt", strings.NewReader("hello"))
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("PUT in made dir: want status 201 got %d", resp.StatusCode)
+	}
+	if got, want := hrefs("/made"), "/made/ /made/new.txt"; got != want {
+		t.Errorf("PROPFIND /made: want %q got %q", want, got)
+	}
+}
+
+// dirCacheFs is an Fs which finds the directories it puts objects
+// in with a dircache like drive does, so it remembers them between
+// requests.  The directory IDs are their local paths.
+type dirCacheFs struct {
+	fs.Fs
+	dirCache *dircache.DirCache
+	flushes  int
+}
+
+// newDirCacheFs makes a dirCacheFs on the local directory root
+func newDirCacheFs(name, root string) (fs.Fs, error) {
+	f, err := local.NewFs(name, root)
+	if err != nil {
+		return nil, err
+	}
+	return &dirCacheFs{
+		Fs:       f,
+		dirCache: dircache.New("", f.Root(), dirCacher{}),
+	}, nil
+}
+
+// Features returns the optional features with DirMove and
+// DirCacheFlush going through the dirCacheFs.  It can't make empty
+// directories, so they are only made by Put.
+func (f *dirCacheFs) Features() *fs.Features {
+	ft := *f.Fs.Features()
+	ft.MakeDir = nil
+	ft.DirMove = f.DirMove
+	ft.DirCacheFlush = f.DirCacheFlush
+	return &ft
+}
+
+// DirMove moves src, a dirCacheFs, to this remote
+func (f *dirCacheFs) DirMove(ctx context.Context, src fs.Fs) error {
+	srcFs, ok := src.(*dirCacheFs)
+	if !ok {
+		return fs.ErrorCantDirMove
+	}
+	return f.Fs.Features().DirMove(ctx, srcFs.Fs)
+}
+
+// DirCacheFlush resets the directory cache
+func (f *dirCacheFs) DirCacheFlush() {
+	f.flushes++
+	f.dirCache.ResetRoot()
+}
+
+// Put writes the object into the directory the dircache finds
+func (f *dirCacheFs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
+	err := f.dirCache.FindRoot(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	dir, leaf := dircache.SplitPath(remote)
+	dirID, err := f.dirCache.FindDir(ctx, dir, true)
+	if err != nil {
+		return nil, err
+	}
+	out, err := os.Create(filepath.Join(dirID, leaf))
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	o := f.NewFsObject(ctx, remote)
+	if o == nil {
+		return nil, fs.ErrorObjectNotFound
+	}
+	return o, nil
+}
+
+// dirCacher finds and makes directories for the dircache of a
+// dirCacheFs
+type dirCacher struct{}
+
+// FindLeaf finds the directory leaf in the directory pathID
+func (dirCacher) FindLeaf(ctx context.Context, pathID, leaf string) (string, bool, error) {
+	pathIDOut := filepath.Join(pathID, leaf)
+	fi, err := os.Stat(pathIDOut)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return pathIDOut, fi.IsDir(), nil
+}
+
+// CreateDir makes the directory leaf in the directory pathID
+func (dirCacher) CreateDir(ctx context.Context, pathID, leaf string) (string, error) {
+	newID := filepath.Join(pathID, leaf)
+	return newID, os.Mkdir(newID, 0777)
+}
+
+func TestWebDAVDirMoveFlushesDirCache(t *testing.T) {
+	const name = "webdavdircache"
+	fs.Register(&fs.Info{
+		Name:  name,
+		NewFs: newDirCacheFs,
+	})
+	dir, cleanup := newTestFs(t, map[string]string{"dir/a.txt": "aaa"})
+	defer cleanup()
+	f, err := newDirCacheFs(name, dir.Root())
+	if err != nil {
+		t.Fatalf("Failed to make Fs: %v", err)
+	}
+	server := httptest.NewServer(NewWebDAVHandler(f))
+	defer server.Close()
+
+	// check sends a request and checks the status of the reply
+	check := func(method, remote string, body string, status int, headers ...string) {
+		resp, respBody := send(t, method, server.URL+remote, strings.NewReader(body), headers...)
+		if resp.StatusCode != status {
+			t.Errorf("%s %s: want status %d got %d: %s", method, remote, status, resp.StatusCode, respBody)
+		}
+	}
+	// checkFile checks the contents of the file at remote
+	checkFile := func(what, remote, want string) {
+		got, err := ioutil.ReadFile(filepath.Join(dir.Root(), filepath.FromSlash(remote)))
+		if err != nil {
+			t.Errorf("%s: failed to read %q: %v", what, remote, err)
+		} else if string(got) != want {
+			t.Errorf("%s: %q: want %q got %q", what, remote, want, string(got))
+		}
+	}
+
+	// Put a file in dir so its ID is cached
+	check("PUT", "/dir/b.txt", "bbb", http.StatusCreated)
+	checkFile("PUT", "dir/b.txt", "bbb")
+
+	check("MOVE", "/dir", "", http.StatusCreated, "Destination", server.URL+"/moved")
+	checkFile("MOVE", "moved/a.txt", "aaa")
+	checkFile("MOVE", "moved/b.txt", "bbb")
+	if flushes := f.(*dirCacheFs).flushes; flushes != 1 {
+		t.Errorf("MOVE: want 1 directory cache flush got %d", flushes)
+	}
+
+	// dir is only remembered by the handler, so the PUT must make
+	// it again rather than use the cached ID
+	check("MKCOL", "/dir", "", http.StatusCreated)
+	check("PUT", "/dir/c.txt", "ccc", http.StatusCreated)
+	checkFile("PUT after MOVE", "dir/c.txt", "ccc")
+}
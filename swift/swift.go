@@ -2,7 +2,9 @@
 package swift
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -77,6 +79,7 @@ type Fs struct {
 	container         string           // the container we are working on
 	segmentsContainer string           // container to store the segments (if any) in
 	root              string           // the path we are working on if any
+	features          *fs.Features     // optional features
 }
 
 // Object describes a swift object
@@ -173,6 +176,7 @@ func NewFsWithConnection(name, root string, c *swift.Connection) (fs.Fs, error)
 		segmentsContainer: container + "_segments",
 		root:              directory,
 	}
+	f.features = (&fs.Features{}).Fill(f)
 	if f.root != "" {
 		f.root += "/"
 		// Check to see if the object exists - ignoring directory markers
@@ -185,7 +189,7 @@ func NewFsWithConnection(name, root string, c *swift.Connection) (fs.Fs, error)
 			} else {
 				f.root += "/"
 			}
-			obj := f.NewFsObject(remote)
+			obj := f.NewFsObject(context.Background(), remote)
 			// return a Fs Limited to this object
 			return fs.NewLimited(f, obj), nil
 		}
@@ -205,7 +209,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 // Return an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) newFsObjectWithInfo(remote string, info *swift.Object) fs.Object {
+func (f *Fs) newFsObjectWithInfo(ctx context.Context, remote string, info *swift.Object) fs.Object {
 	o := &Object{
 		fs:     f,
 		remote: remote,
@@ -220,7 +224,7 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *swift.Object) fs.Object {
 		// Set info but not headers
 		o.info = *info
 	} else {
-		err := o.readMetaData() // reads info and headers, returning an error
+		err := o.readMetaData(ctx) // reads info and headers, returning an error
 		if err != nil {
 			fs.Debug(o, "Failed to read metadata: %s", err)
 			return nil
@@ -232,21 +236,32 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *swift.Object) fs.Object {
 // NewFsObject returns an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) NewFsObject(remote string) fs.Object {
-	return f.newFsObjectWithInfo(remote, nil)
+func (f *Fs) NewFsObject(ctx context.Context, remote string) fs.Object {
+	return f.newFsObjectWithInfo(ctx, remote, nil)
 }
 
 // listFn is called from list and listContainerRoot to handle an object
-type listFn func(string, *swift.Object) error
+// or a directory
+type listFn func(remote string, object *swift.Object, isDirectory bool) error
 
 // listContainerRoot lists the objects into the function supplied from
 // the container and root supplied
 //
-// If directories is set it only sends directories
-func (f *Fs) listContainerRoot(container, root string, directories bool, fn listFn) error {
+// dir is the directory to list relative to the root, "" being the
+// root itself
+//
+// If directories is set it only sends the objects and directories
+// directly in dir, otherwise it sends all the objects below dir.
+//
+// The swift library can't be cancelled so ctx is checked between pages
+func (f *Fs) listContainerRoot(ctx context.Context, container, root, dir string, directories bool, fn listFn) error {
+	prefix := root
+	if dir != "" {
+		prefix += dir + "/"
+	}
 	// Options for ObjectsWalk
 	opts := swift.ObjectsOpts{
-		Prefix: root,
+		Prefix: prefix,
 		Limit:  256,
 	}
 	if directories {
@@ -254,15 +269,16 @@ func (f *Fs) listContainerRoot(container, root string, directories bool, fn list
 	}
 	rootLength := len(root)
 	return f.c.ObjectsWalk(container, &opts, func(opts *swift.ObjectsOpts) (interface{}, error) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		objects, err := f.c.Objects(container, opts)
 		if err == nil {
 			for i := range objects {
 				object := &objects[i]
-				// FIXME if there are no directories, swift gives back the files for some reason!
-				if directories {
-					if !strings.HasSuffix(object.Name, "/") {
-						continue
-					}
+				isDirectory := false
+				if directories && strings.HasSuffix(object.Name, "/") {
+					isDirectory = true
 					object.Name = object.Name[:len(object.Name)-1]
 				}
 				if !strings.HasPrefix(object.Name, root) {
@@ -270,7 +286,7 @@ func (f *Fs) listContainerRoot(container, root string, directories bool, fn list
 					continue
 				}
 				remote := object.Name[rootLength:]
-				err = fn(remote, object)
+				err = fn(remote, object, isDirectory)
 				if err != nil {
 					break
 				}
@@ -282,51 +298,63 @@ func (f *Fs) listContainerRoot(container, root string, directories bool, fn list
 
 // list the objects into the function supplied
 //
-// If directories is set it only sends directories
-func (f *Fs) list(directories bool, fn listFn) {
-	err := f.listContainerRoot(f.container, f.root, directories, fn)
-	if err != nil {
-		fs.Stats.Error()
-		fs.ErrorLog(f, "Couldn't read container %q: %s", f.container, err)
+// If directories is set it only sends the objects and directories
+// directly in dir
+func (f *Fs) list(ctx context.Context, dir string, directories bool, fn listFn) error {
+	err := f.listContainerRoot(ctx, f.container, f.root, dir, directories, fn)
+	if err != nil && err != ctx.Err() && err != fs.ErrorListAborted {
+		err = fmt.Errorf("Couldn't read container %q: %s", f.container, err)
 	}
+	return err
 }
 
-// listFiles walks the path returning a channel of FsObjects
-//
-// if ignoreStorable is set then it outputs the file even if Storable() is false
-func (f *Fs) listFiles(ignoreStorable bool) fs.ObjectsChan {
-	out := make(fs.ObjectsChan, fs.Config.Checkers)
+// List lists the objects in the container into out
+func (f *Fs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
 	if f.container == "" {
-		// Return no objects at top level list
-		close(out)
-		fs.Stats.Error()
-		fs.ErrorLog(f, "Can't list objects at root - choose a container using lsd")
-	} else {
-		// List the objects
-		go func() {
-			defer close(out)
-			f.list(false, func(remote string, object *swift.Object) error {
-				if o := f.newFsObjectWithInfo(remote, object); o != nil {
-					// Storable does a full metadata read on 0 size objects which might be manifest files
-					storable := o.Storable()
-					if storable || ignoreStorable {
-						out <- o
-					}
-				}
-				return nil
-			})
-		}()
+		// Objects can only be listed within a container
+		out.SetError(fmt.Errorf("Can't list objects at root - choose a container using lsd"))
+		return
+	}
+	err := f.list(ctx, "", false, func(remote string, object *swift.Object, isDirectory bool) error {
+		if o := f.newFsObjectWithInfo(ctx, remote, object); o != nil {
+			// Storable does a full metadata read on 0 size objects which might be manifest files
+			if o.Storable() && out.Add(o) {
+				return fs.ErrorListAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && err != fs.ErrorListAborted {
+		out.SetError(err)
 	}
-	return out
 }
 
-// List walks the path returning a channel of FsObjects
-func (f *Fs) List() fs.ObjectsChan {
-	return f.listFiles(false)
+// ListDirEntries lists the objects and directories directly in dir
+func (f *Fs) ListDirEntries(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	if f.container == "" {
+		return nil, nil, fmt.Errorf("Can't list objects at root - choose a container using lsd")
+	}
+	err = f.list(ctx, dir, true, func(remote string, object *swift.Object, isDirectory bool) error {
+		if isDirectory {
+			dirs = append(dirs, &fs.Dir{
+				Name:  remote,
+				Bytes: -1,
+				Count: -1,
+			})
+		} else if o := f.newFsObjectWithInfo(ctx, remote, object); o != nil && o.Storable() {
+			objects = append(objects, o)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return objects, dirs, nil
 }
 
 // ListDir lists the containers
-func (f *Fs) ListDir() fs.DirChan {
+func (f *Fs) ListDir(ctx context.Context) fs.DirChan {
 	out := make(fs.DirChan, fs.Config.Checkers)
 	if f.container == "" {
 		// List the containers
@@ -338,10 +366,14 @@ func (f *Fs) ListDir() fs.DirChan {
 				fs.ErrorLog(f, "Couldn't list containers: %v", err)
 			} else {
 				for _, container := range containers {
-					out <- &fs.Dir{
+					select {
+					case out <- &fs.Dir{
 						Name:  container.Name,
 						Bytes: container.Bytes,
 						Count: container.Count,
+					}:
+					case <-ctx.Done():
+						return
 					}
 				}
 			}
@@ -350,14 +382,25 @@ func (f *Fs) ListDir() fs.DirChan {
 		// List the directories in the path in the container
 		go func() {
 			defer close(out)
-			f.list(true, func(remote string, object *swift.Object) error {
-				out <- &fs.Dir{
+			err := f.list(ctx, "", true, func(remote string, object *swift.Object, isDirectory bool) error {
+				if !isDirectory {
+					return nil
+				}
+				select {
+				case out <- &fs.Dir{
 					Name:  remote,
 					Bytes: object.Bytes,
 					Count: 0,
+				}:
+				case <-ctx.Done():
+					return ctx.Err()
 				}
 				return nil
 			})
+			if err != nil && ctx.Err() == nil {
+				fs.Stats.Error()
+				fs.ErrorLog(f, "%s", err)
+			}
 		}()
 	}
 	return out
@@ -368,27 +411,33 @@ func (f *Fs) ListDir() fs.DirChan {
 // Copy the reader in to the new object which is returned
 //
 // The new object may have been created if an error is returned
-func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs.Object, error) {
+func (f *Fs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
 	// Temporary Object under construction
 	fs := &Object{
 		fs:     f,
 		remote: remote,
 	}
-	return fs, fs.Update(in, modTime, size)
+	return fs, fs.Update(ctx, in, modTime, size, options...)
 }
 
 // Mkdir creates the container if it doesn't exist
-func (f *Fs) Mkdir() error {
+func (f *Fs) Mkdir(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	return f.c.ContainerCreate(f.container, nil)
 }
 
 // Rmdir deletes the container if the fs is at the root
 //
 // Returns an error if it isn't empty
-func (f *Fs) Rmdir() error {
+func (f *Fs) Rmdir(ctx context.Context) error {
 	if f.root != "" {
 		return nil
 	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	return f.c.ContainerDelete(f.container)
 }
 
@@ -397,12 +446,39 @@ func (f *Fs) Precision() time.Duration {
 	return time.Nanosecond
 }
 
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() fs.HashSet {
+	return fs.HashSet(fs.HashMD5)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
 // Purge deletes all the files and directories
 //
 // Implemented here so we can make sure we delete directory markers
-func (f *Fs) Purge() error {
-	fs.DeleteFiles(f.listFiles(true))
-	return f.Rmdir()
+func (f *Fs) Purge(ctx context.Context) error {
+	// Delete all the files including the directory markers
+	toBeDeleted := make(fs.ObjectsChan, fs.Config.Transfers)
+	deleted := make(chan struct{})
+	go func() {
+		fs.DeleteFiles(ctx, toBeDeleted)
+		close(deleted)
+	}()
+	err := f.list(ctx, "", false, func(remote string, object *swift.Object, isDirectory bool) error {
+		if o := f.newFsObjectWithInfo(ctx, remote, object); o != nil {
+			toBeDeleted <- o
+		}
+		return nil
+	})
+	close(toBeDeleted)
+	<-deleted
+	if err != nil {
+		return err
+	}
+	return f.Rmdir(ctx)
 }
 
 // Copy src to this remote using server side copy operations.
@@ -414,18 +490,64 @@ func (f *Fs) Purge() error {
 // Will only be called if src.Fs().Name() == f.Name()
 //
 // If it isn't possible then return fs.ErrorCantCopy
-func (f *Fs) Copy(src fs.Object, remote string) (fs.Object, error) {
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debug(src, "Can't copy - not same remote type")
 		return nil, fs.ErrorCantCopy
 	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	srcFs := srcObj.fs
 	_, err := f.c.ObjectCopy(srcFs.container, srcFs.root+srcObj.remote, f.container, f.root+remote, nil)
 	if err != nil {
 		return nil, err
 	}
-	return f.NewFsObject(remote), nil
+	return f.NewFsObject(ctx, remote), nil
+}
+
+// Move src to this remote using server side move operations.
+//
+// This is done as a server side copy followed by deleting src.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantMove
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debug(src, "Can't move - not same remote type")
+		return nil, fs.ErrorCantMove
+	}
+	// Removing a manifest removes its segments too, so don't
+	// risk them as part of a server side move
+	isManifest, err := srcObj.isManifestFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if isManifest {
+		fs.Debug(src, "Can't move - manifest file")
+		return nil, fs.ErrorCantMove
+	}
+	dstObj, err := f.Copy(ctx, srcObj, remote)
+	if err == fs.ErrorCantCopy {
+		return nil, fs.ErrorCantMove
+	} else if err != nil {
+		return nil, err
+	}
+	if dstObj == nil {
+		return nil, fmt.Errorf("Couldn't find %q after copying it", remote)
+	}
+	err = srcObj.Remove(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Copied but couldn't remove source: %v", err)
+	}
+	return dstObj, nil
 }
 
 // ------------------------------------------------------------
@@ -448,9 +570,12 @@ func (o *Object) Remote() string {
 	return o.remote
 }
 
-// Md5sum returns the Md5sum of an object returning a lowercase hex string
-func (o *Object) Md5sum() (string, error) {
-	isManifest, err := o.isManifestFile()
+// Hash returns the Md5sum of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t fs.HashType) (string, error) {
+	if t != fs.HashMD5 {
+		return "", fs.ErrHashUnsupported
+	}
+	isManifest, err := o.isManifestFile(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -462,8 +587,8 @@ func (o *Object) Md5sum() (string, error) {
 }
 
 // isManifestFile checks for manifest header
-func (o *Object) isManifestFile() (bool, error) {
-	err := o.readMetaData()
+func (o *Object) isManifestFile(ctx context.Context) (bool, error) {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		if err == swift.ObjectNotFound {
 			return false, nil
@@ -482,10 +607,13 @@ func (o *Object) Size() int64 {
 // readMetaData gets the metadata if it hasn't already been fetched
 //
 // it also sets the info
-func (o *Object) readMetaData() (err error) {
+func (o *Object) readMetaData(ctx context.Context) (err error) {
 	if o.headers != nil {
 		return nil
 	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	info, h, err := o.fs.c.Object(o.fs.container, o.fs.root+o.remote)
 	if err != nil {
 		return err
@@ -500,8 +628,8 @@ func (o *Object) readMetaData() (err error) {
 //
 // It attempts to read the objects mtime and if that isn't present the
 // LastModified returned in the http headers
-func (o *Object) ModTime() time.Time {
-	err := o.readMetaData()
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Debug(o, "Failed to read metadata: %s", err)
 		return o.info.LastModified
@@ -515,8 +643,8 @@ func (o *Object) ModTime() time.Time {
 }
 
 // SetModTime sets the modification time of the local fs object
-func (o *Object) SetModTime(modTime time.Time) {
-	err := o.readMetaData()
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Stats.Error()
 		fs.ErrorLog(o, "Failed to read metadata: %s", err)
@@ -541,6 +669,34 @@ func (o *Object) SetModTime(modTime time.Time) {
 	}
 }
 
+// MimeType returns the content type of the object
+func (o *Object) MimeType(ctx context.Context) string {
+	if o.info.ContentType == "" {
+		err := o.readMetaData(ctx)
+		if err != nil {
+			fs.Log(o, "Failed to read metadata: %s", err)
+			return ""
+		}
+	}
+	return o.info.ContentType
+}
+
+// Metadata returns the user metadata of the object
+func (o *Object) Metadata(ctx context.Context) (fs.Metadata, error) {
+	err := o.readMetaData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata := fs.Metadata{}
+	for k, v := range o.headers.ObjectMetadata() {
+		if k == "mtime" {
+			continue
+		}
+		metadata[k] = v
+	}
+	return metadata, nil
+}
+
 // Storable returns if this object is storable
 //
 // It compares the Content-Type to directoryMarkerContentType - that
@@ -550,9 +706,18 @@ func (o *Object) Storable() bool {
 }
 
 // Open an object for read
-func (o *Object) Open() (in io.ReadCloser, err error) {
-	in, _, err = o.fs.c.ObjectOpen(o.fs.container, o.fs.root+o.remote, true, nil)
-	return
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	headers := swift.Headers(fs.OpenOptionHeaders(nil, options...))
+	// Can only check the hash if reading the whole object
+	checkHash := len(headers) == 0
+	in, _, err = o.fs.c.ObjectOpen(o.fs.container, o.fs.root+o.remote, checkHash, headers)
+	if err != nil {
+		return nil, err
+	}
+	return fs.NewContextReadCloser(ctx, in), nil
 }
 
 // min returns the smallest of x, y
@@ -566,9 +731,9 @@ func min(x, y int64) int64 {
 // removeSegments removes any old segments from o
 //
 // if except is passed in then segments with that prefix won't be deleted
-func (o *Object) removeSegments(except string) error {
+func (o *Object) removeSegments(ctx context.Context, except string) error {
 	segmentsRoot := o.fs.root + o.remote + "/"
-	err := o.fs.listContainerRoot(o.fs.segmentsContainer, segmentsRoot, false, func(remote string, object *swift.Object) error {
+	err := o.fs.listContainerRoot(ctx, o.fs.segmentsContainer, segmentsRoot, "", false, func(remote string, object *swift.Object, isDirectory bool) error {
 		if except != "" && strings.HasPrefix(remote, except) {
 			// fs.Debug(o, "Ignoring current segment file %q in container %q", segmentsRoot+remote, o.fs.segmentsContainer)
 			return nil
@@ -590,21 +755,39 @@ func (o *Object) removeSegments(except string) error {
 
 // updateChunks updates the existing object using chunks to a separate
 // container.  It returns a string which prefixes current segments.
-func (o *Object) updateChunks(in io.Reader, headers swift.Headers, size int64) (string, error) {
+func (o *Object) updateChunks(ctx context.Context, in io.Reader, headers swift.Headers, size int64, contentType string) (string, error) {
 	// Create the segmentsContainer if it doesn't exist
 	err := o.fs.c.ContainerCreate(o.fs.segmentsContainer, nil)
 	if err != nil {
 		return "", err
 	}
 	// Upload the chunks
+	//
+	// If size is -1 then upload chunks until the input runs out
 	left := size
 	i := 0
 	uniquePrefix := fmt.Sprintf("%s/%d", swift.TimeToFloatString(time.Now()), size)
 	segmentsPath := fmt.Sprintf("%s%s/%s", o.fs.root, o.remote, uniquePrefix)
-	for left > 0 {
-		n := min(left, int64(chunkSize))
-		headers["Content-Length"] = strconv.FormatInt(n, 10) // set Content-Length as we know it
-		segmentReader := io.LimitReader(in, n)
+	buf := bufio.NewReader(in)
+	for left > 0 || size < 0 {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		n := int64(chunkSize)
+		if size < 0 {
+			// Check there is at least one more byte to upload
+			_, err := buf.Peek(1)
+			if err == io.EOF && i > 0 {
+				break
+			} else if err != nil && err != io.EOF {
+				return "", err
+			}
+			delete(headers, "Content-Length")
+		} else {
+			n = min(left, n)
+			headers["Content-Length"] = strconv.FormatInt(n, 10) // set Content-Length as we know it
+		}
+		segmentReader := io.LimitReader(buf, n)
 		segmentPath := fmt.Sprintf("%s/%08d", segmentsPath, i)
 		fs.Debug(o, "Uploading segment file %q into %q", segmentPath, o.fs.segmentsContainer)
 		_, err := o.fs.c.ObjectPut(o.fs.segmentsContainer, segmentPath, segmentReader, true, "", "", headers)
@@ -619,33 +802,41 @@ func (o *Object) updateChunks(in io.Reader, headers swift.Headers, size int64) (
 	headers["Content-Length"] = "0" // set Content-Length as we know it
 	emptyReader := bytes.NewReader(nil)
 	manifestName := o.fs.root + o.remote
-	_, err = o.fs.c.ObjectPut(o.fs.container, manifestName, emptyReader, true, "", "", headers)
+	_, err = o.fs.c.ObjectPut(o.fs.container, manifestName, emptyReader, true, "", contentType, headers)
 	return uniquePrefix + "/", err
 }
 
 // Update the object with the contents of the io.Reader, modTime and size
 //
 // The new object may have been created if an error is returned
-func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
+func (o *Object) Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...fs.PutOption) error {
 	// Note whether this has a manifest before starting
-	isManifest, err := o.isManifestFile()
+	isManifest, err := o.isManifestFile(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Set the mtime
+	// Abort the upload if ctx is cancelled
+	in = fs.NewContextReader(ctx, in)
+
+	// Set the user metadata and the mtime
+	contentType, userMetadata := fs.PutOptionsMetadata(options...)
 	m := swift.Metadata{}
+	for k, v := range userMetadata {
+		m[k] = v
+	}
 	m.SetModTime(modTime)
 	headers := m.ObjectHeaders()
 	uniquePrefix := ""
-	if size > int64(chunkSize) {
-		uniquePrefix, err = o.updateChunks(in, headers, size)
+	// Upload streams of unknown length (size -1) in chunks too
+	if size > int64(chunkSize) || size < 0 {
+		uniquePrefix, err = o.updateChunks(ctx, in, headers, size, contentType)
 		if err != nil {
 			return err
 		}
 	} else {
 		headers["Content-Length"] = strconv.FormatInt(size, 10) // set Content-Length as we know it
-		_, err := o.fs.c.ObjectPut(o.fs.container, o.fs.root+o.remote, in, true, "", "", headers)
+		_, err := o.fs.c.ObjectPut(o.fs.container, o.fs.root+o.remote, in, true, "", contentType, headers)
 		if err != nil {
 			return err
 		}
@@ -653,7 +844,7 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 
 	// If file was a manifest then remove old/all segments
 	if isManifest {
-		err = o.removeSegments(uniquePrefix)
+		err = o.removeSegments(ctx, uniquePrefix)
 		if err != nil {
 			fs.Log(o, "Failed to remove old segments - carrying on with upload: %v", err)
 		}
@@ -661,12 +852,12 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 
 	// Read the metadata from the newly created object
 	o.headers = nil // wipe old metadata
-	return o.readMetaData()
+	return o.readMetaData(ctx)
 }
 
 // Remove an object
-func (o *Object) Remove() error {
-	isManifestFile, err := o.isManifestFile()
+func (o *Object) Remove(ctx context.Context) error {
+	isManifestFile, err := o.isManifestFile(ctx)
 	if err != nil {
 		return err
 	}
@@ -677,7 +868,7 @@ func (o *Object) Remove() error {
 	}
 	// ...then segments if required
 	if isManifestFile {
-		err = o.removeSegments("")
+		err = o.removeSegments(ctx, "")
 		if err != nil {
 			return err
 		}
@@ -687,8 +878,11 @@ func (o *Object) Remove() error {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs     = &Fs{}
-	_ fs.Purger = &Fs{}
-	_ fs.Copier = &Fs{}
-	_ fs.Object = &Object{}
+	_ fs.Fs         = &Fs{}
+	_ fs.Purger     = &Fs{}
+	_ fs.Copier     = &Fs{}
+	_ fs.Mover      = &Fs{}
+	_ fs.DirLister  = &Fs{}
+	_ fs.Object     = &Object{}
+	_ fs.Metadataer = &Object{}
 )
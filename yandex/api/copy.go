@@ -0,0 +1,11 @@
+package src
+
+// Copy will copy the file or folder at fromPath to toPath on Yandex
+// Disk.
+//
+// Large folders are copied asynchronously, in which case the
+// AsyncOperation is returned and the copy is finished when its
+// OperationStatus is "success".
+func (c *Client) Copy(fromPath, toPath string, overwrite bool) (op *AsyncOperation, err error) {
+	return c.copyOrMove("copy", fromPath, toPath, overwrite)
+}
@@ -0,0 +1,8 @@
+package src
+
+// EmptyTrash will permanently delete all the files and folders in
+// the Yandex Disk trash
+func (c *Client) EmptyTrash() error {
+	fullURL := RootAddr + "/v1/disk/trash/resources"
+	return c.PerformDelete(fullURL)
+}
@@ -0,0 +1,90 @@
+package src
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// AsyncOperation is returned by the API for requests which are
+// finished asynchronously.
+type AsyncOperation struct {
+	HRef      string `json:"href"`
+	Method    string `json:"method"`
+	Templated bool   `json:"templated"`
+}
+
+// AsyncOperationStatus struct is returned by the API for the
+// status of an AsyncOperation.
+type AsyncOperationStatus struct {
+	Status string `json:"status"` // one of "success", "failed" or "in-progress"
+}
+
+// Move will move or rename the file or folder at fromPath to toPath
+// on Yandex Disk.
+//
+// Large folders are moved asynchronously, in which case the
+// AsyncOperation is returned and the move is finished when its
+// OperationStatus is "success".
+func (c *Client) Move(fromPath, toPath string, overwrite bool) (op *AsyncOperation, err error) {
+	return c.copyOrMove("move", fromPath, toPath, overwrite)
+}
+
+// copyOrMove does a copy or a move (as given by method) of fromPath
+// to toPath.
+func (c *Client) copyOrMove(method, fromPath, toPath string, overwrite bool) (op *AsyncOperation, err error) {
+	values := url.Values{}
+	values.Add("from", fromPath)
+	values.Add("path", toPath)
+	values.Add("overwrite", strconv.FormatBool(overwrite))
+
+	req, err := c.scopedRequest("POST", "/v1/disk/resources/"+method+"?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckAPIError(resp); err != nil {
+		return nil, err
+	}
+	defer CheckClose(resp.Body, &err)
+
+	//201 - resource copied or moved.
+	//202 - resource is being copied or moved (async operation).
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, nil
+	}
+	op = new(AsyncOperation)
+	if err := json.NewDecoder(resp.Body).Decode(op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// OperationStatus returns the status of the asynchronous operation op.
+func (c *Client) OperationStatus(op *AsyncOperation) (status string, err error) {
+	req, err := http.NewRequest("GET", op.HRef, nil)
+	if err != nil {
+		return "", err
+	}
+	c.setRequestScope(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	if err := CheckAPIError(resp); err != nil {
+		return "", err
+	}
+	defer CheckClose(resp.Body, &err)
+
+	var s AsyncOperationStatus
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return "", err
+	}
+	return s.Status, nil
+}
@@ -0,0 +1,34 @@
+package src
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// PerformPublish does the actual publish via PUT request.
+func (c *Client) PerformPublish(url string) error {
+	req, err := http.NewRequest("PUT", url, nil)
+	if err != nil {
+		return err
+	}
+
+	//set access token and headers
+	c.setRequestScope(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	//200 - resource published.
+	if resp.StatusCode != 200 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("publish error [%d]: %s", resp.StatusCode, string(body[:]))
+	}
+	return nil
+}
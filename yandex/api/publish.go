@@ -0,0 +1,22 @@
+package src
+
+import (
+	"net/url"
+)
+
+// Publish will make specified file/folder on Yandex Disk public so
+// it can be read from its public_url
+func (c *Client) Publish(remotePath string) error {
+
+	values := url.Values{}
+	values.Add("path", remotePath)
+	urlPath := "/v1/disk/resources/publish?" + values.Encode()
+	fullURL := RootAddr
+	if urlPath[:1] != "/" {
+		fullURL += "/" + urlPath
+	} else {
+		fullURL += urlPath
+	}
+
+	return c.PerformPublish(fullURL)
+}
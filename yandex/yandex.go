@@ -4,6 +4,7 @@
 package yandex
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -67,6 +68,7 @@ type Fs struct {
 	root       string         //root path
 	diskRoot   string         //root path with "disk:/" container name
 	mkdircache map[string]int
+	features   *fs.Features // optional features
 }
 
 // Object describes a swift object
@@ -111,6 +113,7 @@ func getAccessToken(name string) (*oauth2.Token, error) {
 
 // NewFs constructs an Fs from the path, container:path
 func NewFs(name, root string) (fs.Fs, error) {
+	ctx := context.Background()
 	//read access token from config
 	token, err := getAccessToken(name)
 	if err != nil {
@@ -123,6 +126,9 @@ func NewFs(name, root string) (fs.Fs, error) {
 	f := &Fs{
 		yd: yandexDisk,
 	}
+	f.features = (&fs.Features{
+		CanHaveEmptyDirectories: true,
+	}).Fill(f)
 
 	f.setRoot(root)
 
@@ -138,7 +144,7 @@ func NewFs(name, root string) (fs.Fs, error) {
 			remote := path.Base(root)
 			f.setRoot(path.Dir(root))
 
-			obj := f.newFsObjectWithInfo(remote, ResourceInfoResponse)
+			obj := f.newFsObjectWithInfo(ctx, remote, ResourceInfoResponse)
 			// return a Fs Limited to this object
 			return fs.NewLimited(f, obj), nil
 		}
@@ -165,7 +171,9 @@ func (f *Fs) setRoot(root string) {
 // list the objects into the function supplied
 //
 // If directories is set it only sends directories
-func (f *Fs) list(directories bool, fn func(string, yandex.ResourceInfoResponse)) {
+//
+// If fn returns an error the listing stops and the error is returned
+func (f *Fs) list(ctx context.Context, directories bool, fn func(string, yandex.ResourceInfoResponse) error) error {
 	//request files list. list is divided into pages. We send request for each page
 	//items per page is limited by limit
 	//TODO may be add config parameter for the items per page limit
@@ -178,12 +186,13 @@ func (f *Fs) list(directories bool, fn func(string, yandex.ResourceInfoResponse)
 	opt.Offset = &offset
 	//query each page of list until itemCount is less then limit
 	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		//send request
 		info, err := f.yd.NewFlatFileListRequest(opt).Exec()
 		if err != nil {
-			fs.Stats.Error()
-			fs.ErrorLog(f, "Couldn't list: %s", err)
-			return
+			return fmt.Errorf("Couldn't list: %s", err)
 		}
 		itemsCount = uint32(len(info.Items))
 
@@ -193,7 +202,10 @@ func (f *Fs) list(directories bool, fn func(string, yandex.ResourceInfoResponse)
 			if strings.HasPrefix(item.Path, f.diskRoot) {
 				//trim root folder from filename
 				var name = strings.TrimPrefix(item.Path, f.diskRoot)
-				fn(name, item)
+				err = fn(name, item)
+				if err != nil {
+					return err
+				}
 			}
 		}
 
@@ -204,34 +216,36 @@ func (f *Fs) list(directories bool, fn func(string, yandex.ResourceInfoResponse)
 			break
 		}
 	}
+	return nil
 }
 
-// List walks the path returning a channel of FsObjects
-func (f *Fs) List() fs.ObjectsChan {
-	out := make(fs.ObjectsChan, fs.Config.Checkers)
-	// List the objects
-	go func() {
-		defer close(out)
-		f.list(false, func(remote string, object yandex.ResourceInfoResponse) {
-			if fs := f.newFsObjectWithInfo(remote, &object); fs != nil {
-				out <- fs
+// List walks the path into out
+func (f *Fs) List(ctx context.Context, out fs.ListOpts) {
+	defer out.Finished()
+	err := f.list(ctx, false, func(remote string, object yandex.ResourceInfoResponse) error {
+		if o := f.newFsObjectWithInfo(ctx, remote, &object); o != nil {
+			if out.Add(o) {
+				return fs.ErrorListAborted
 			}
-		})
-	}()
-	return out
+		}
+		return nil
+	})
+	if err != nil && err != fs.ErrorListAborted {
+		out.SetError(err)
+	}
 }
 
 // NewFsObject returns an Object from a path
 //
 // May return nil if an error occurred
-func (f *Fs) NewFsObject(remote string) fs.Object {
-	return f.newFsObjectWithInfo(remote, nil)
+func (f *Fs) NewFsObject(ctx context.Context, remote string) fs.Object {
+	return f.newFsObjectWithInfo(ctx, remote, nil)
 }
 
 // Return an FsObject from a path
 //
 // May return nil if an error occurred
-func (f *Fs) newFsObjectWithInfo(remote string, info *yandex.ResourceInfoResponse) fs.Object {
+func (f *Fs) newFsObjectWithInfo(ctx context.Context, remote string, info *yandex.ResourceInfoResponse) fs.Object {
 	o := &Object{
 		fs:     f,
 		remote: remote,
@@ -239,7 +253,7 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *yandex.ResourceInfoRespons
 	if info != nil {
 		o.setMetaData(info)
 	} else {
-		err := o.readMetaData()
+		err := o.readMetaData(ctx)
 		if err != nil {
 			fs.ErrorLog(f, "Couldn't get object '%s' metadata: %s", o.remotePath(), err)
 			return nil
@@ -252,31 +266,42 @@ func (f *Fs) newFsObjectWithInfo(remote string, info *yandex.ResourceInfoRespons
 func (o *Object) setMetaData(info *yandex.ResourceInfoResponse) {
 	o.bytes = info.Size
 	o.md5sum = info.Md5
-
-	if info.CustomProperties["rclone_modified"] == nil {
-		//read modTime from Modified property of object
-		t, err := time.Parse(time.RFC3339Nano, info.Modified)
-		if err != nil {
-			return
-		}
+	if t := readModTime(info); !t.IsZero() {
 		o.modTime = t
-	} else {
+	}
+}
+
+// readModTime reads the modification time of a file or directory
+// from its rclone_modified custom property if set, or its Modified
+// property if not.
+//
+// It returns the zero time if the time couldn't be read
+func readModTime(info *yandex.ResourceInfoResponse) time.Time {
+	modTimeString := info.Modified
+	if info.CustomProperties["rclone_modified"] != nil {
 		// interface{} to string type assertion
-		if modtimestr, ok := info.CustomProperties["rclone_modified"].(string); ok {
-			//read modTime from rclone_modified custom_property of object
-			t, err := time.Parse(time.RFC3339Nano, modtimestr)
-			if err != nil {
-				return
-			}
-			o.modTime = t
-		} else {
-			return //if it is not a string
+		s, ok := info.CustomProperties["rclone_modified"].(string)
+		if !ok {
+			return time.Time{} //if it is not a string
 		}
+		modTimeString = s
 	}
+	t, _ := time.Parse(time.RFC3339Nano, modTimeString)
+	return t
+}
+
+// isNotFound returns true if err is the error for a path which
+// doesn't exist
+func isNotFound(err error) bool {
+	apiErr, ok := err.(yandex.DiskClientError)
+	return ok && apiErr.Code == "DiskNotFoundError"
 }
 
 // readMetaData gets the info if it hasn't already been fetched
-func (o *Object) readMetaData() (err error) {
+func (o *Object) readMetaData(ctx context.Context) (err error) {
+	if err = ctx.Err(); err != nil {
+		return err
+	}
 	//request meta info
 	var opt2 yandex.ResourceInfoRequestOptions
 	ResourceInfoResponse, err := o.fs.yd.NewResourceInfoRequest(o.remotePath(), opt2).Exec()
@@ -287,8 +312,61 @@ func (o *Object) readMetaData() (err error) {
 	return nil
 }
 
+// ListDirEntries lists the objects and directories directly in dir
+func (f *Fs) ListDirEntries(ctx context.Context, dir string) (objects fs.Objects, dirs []*fs.Dir, err error) {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	//the directory is listed in pages of limit items
+	var limit uint32 = 1000 // max number of object per request
+	var offset uint32       //for the next page of request
+	var opt yandex.ResourceInfoRequestOptions
+	opt.Limit = &limit
+	opt.Offset = &offset
+	for {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		info, err := f.yd.NewResourceInfoRequest(f.diskRoot+dir, opt).Exec()
+		if isNotFound(err) {
+			return nil, nil, fs.ErrorDirNotFound
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("Couldn't list: %s", err)
+		}
+		if info.ResourceType != "dir" || info.Embedded == nil {
+			return nil, nil, fs.ErrorDirNotFound
+		}
+		for i := range info.Embedded.Items {
+			item := &info.Embedded.Items[i]
+			remote := prefix + item.Name
+			switch item.ResourceType {
+			case "dir":
+				dirs = append(dirs, &fs.Dir{
+					Name:  remote,
+					When:  readModTime(item),
+					Bytes: -1,
+					Count: -1,
+				})
+			case "file":
+				if o := f.newFsObjectWithInfo(ctx, remote, item); o != nil {
+					objects = append(objects, o)
+				}
+			}
+		}
+		//check if we reached end of list
+		itemsCount := uint32(len(info.Embedded.Items))
+		if itemsCount < limit {
+			break
+		}
+		offset += itemsCount
+	}
+	return objects, dirs, nil
+}
+
 // ListDir walks the path returning a channel of FsObjects
-func (f *Fs) ListDir() fs.DirChan {
+func (f *Fs) ListDir(ctx context.Context) fs.DirChan {
 	out := make(fs.DirChan, fs.Config.Checkers)
 	go func() {
 		defer close(out)
@@ -307,11 +385,15 @@ func (f *Fs) ListDir() fs.DirChan {
 					if err != nil {
 						return
 					}
-					out <- &fs.Dir{
+					select {
+					case out <- &fs.Dir{
 						Name:  element.Name,
 						When:  t,
 						Bytes: int64(element.Size),
 						Count: -1,
+					}:
+					case <-ctx.Done():
+						return
 					}
 				}
 			}
@@ -326,7 +408,7 @@ func (f *Fs) ListDir() fs.DirChan {
 // Copy the reader in to the new object which is returned
 //
 // The new object may have been created if an error is returned
-func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs.Object, error) {
+func (f *Fs) Put(ctx context.Context, in io.Reader, remote string, modTime time.Time, size int64, options ...fs.PutOption) (fs.Object, error) {
 	o := &Object{
 		fs:      f,
 		remote:  remote,
@@ -334,29 +416,37 @@ func (f *Fs) Put(in io.Reader, remote string, modTime time.Time, size int64) (fs
 		modTime: modTime,
 	}
 	//TODO maybe read metadata after upload to check if file uploaded successfully
-	return o, o.Update(in, modTime, size)
+	return o, o.Update(ctx, in, modTime, size, options...)
 }
 
 // Mkdir creates the container if it doesn't exist
-func (f *Fs) Mkdir() error {
+func (f *Fs) Mkdir(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	return mkDirFullPath(f.yd, f.diskRoot)
 }
 
 // Rmdir deletes the container
 //
 // Returns an error if it isn't empty
-func (f *Fs) Rmdir() error {
-	return f.purgeCheck(true)
+func (f *Fs) Rmdir(ctx context.Context) error {
+	return f.purgeCheck(ctx, "", true)
 }
 
-// purgeCheck remotes the root directory, if check is set then it
-// refuses to do so if it has anything in
-func (f *Fs) purgeCheck(check bool) error {
+// purgeCheck removes the directory dir below the root, "" being the
+// root itself.  If check is set then it refuses to do so if it has
+// anything in
+func (f *Fs) purgeCheck(ctx context.Context, dir string, check bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dirPath := f.diskRoot + dir
 	if check {
 		//to comply with rclone logic we check if the directory is empty before delete.
 		//send request to get list of objects in this directory.
 		var opt yandex.ResourceInfoRequestOptions
-		ResourceInfoResponse, err := f.yd.NewResourceInfoRequest(f.diskRoot, opt).Exec()
+		ResourceInfoResponse, err := f.yd.NewResourceInfoRequest(dirPath, opt).Exec()
 		if err != nil {
 			return fmt.Errorf("Rmdir failed: %s", err)
 		}
@@ -365,7 +455,31 @@ func (f *Fs) purgeCheck(check bool) error {
 		}
 	}
 	//delete directory
-	return f.yd.Delete(f.diskRoot, true)
+	return f.yd.Delete(dirPath, true)
+}
+
+// MakeDir makes the directory dir and any parents it needs
+func (f *Fs) MakeDir(ctx context.Context, dir string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mkDirFullPath(f.yd, f.diskRoot+dir+"/")
+}
+
+// RemoveDir removes the directory dir
+//
+// Returns an error if it isn't empty
+func (f *Fs) RemoveDir(ctx context.Context, dir string) error {
+	return f.purgeCheck(ctx, dir, true)
+}
+
+// SetDirModTime sets the modification time of the directory dir
+func (f *Fs) SetDirModTime(ctx context.Context, dir string, modTime time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	//set custom_property 'rclone_modified' of the directory to modTime
+	return f.yd.SetCustomProperty(f.diskRoot+dir, "rclone_modified", modTime.Format(time.RFC3339Nano))
 }
 
 // Precision return the precision of this Fs
@@ -373,13 +487,199 @@ func (f *Fs) Precision() time.Duration {
 	return time.Nanosecond
 }
 
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() fs.HashSet {
+	return fs.HashSet(fs.HashMD5)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// Copy src to this remote using server side copy operations.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantCopy
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debug(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	dstObj := &Object{
+		fs:     f,
+		remote: remote,
+	}
+	dstPath := dstObj.remotePath()
+
+	// Make the parent directories of the destination
+	if err := mkDirFullPath(f.yd, dstPath); err != nil {
+		return nil, err
+	}
+
+	// Do the copy
+	op, err := f.yd.Copy(srcObj.remotePath(), dstPath, true)
+	if err != nil {
+		return nil, err
+	}
+	if op != nil {
+		if err = f.waitForOperation(ctx, op); err != nil {
+			return nil, err
+		}
+	}
+
+	// Make sure the modification time is preserved
+	modTime := srcObj.ModTime(ctx)
+	dstObj.SetModTime(ctx, modTime)
+	if err = dstObj.readMetaData(ctx); err != nil {
+		return nil, err
+	}
+	dstObj.modTime = modTime
+	return dstObj, nil
+}
+
+// DirMove moves src directory to this remote using server side move
+// operations.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantDirMove
+//
+// If destination exists then return fs.ErrorDirExists
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs) error {
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		fs.Debug(srcFs, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+	if srcFs.root == "" {
+		fs.Debug(srcFs, "Can't move root directory")
+		return fs.ErrorCantDirMove
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Check if destination exists
+	dstPath := "disk:/" + f.root
+	var opt yandex.ResourceInfoRequestOptions
+	if _, err := f.yd.NewResourceInfoRequest(dstPath, opt).Exec(); err == nil {
+		return fs.ErrorDirExists
+	} else if !isNotFound(err) {
+		return err
+	}
+
+	// Make the parent directories of the destination
+	if strings.Contains(f.root, "/") {
+		if err := mkDirFullPath(f.yd, dstPath); err != nil {
+			return err
+		}
+	}
+
+	// Do the move
+	op, err := f.yd.Move("disk:/"+srcFs.root, dstPath, false)
+	if err != nil {
+		return err
+	}
+	if op != nil {
+		return f.waitForOperation(ctx, op)
+	}
+	return nil
+}
+
+// waitForOperation waits for the asynchronous operation op to finish
+func (f *Fs) waitForOperation(ctx context.Context, op *yandex.AsyncOperation) error {
+	for {
+		status, err := f.yd.OperationStatus(op)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case "success":
+			return nil
+		case "failed":
+			return fmt.Errorf("operation %q failed", op.HRef)
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// CleanUp permanently deletes everything in the trash
+//
+// The trash is for the whole disk, not just the root of the Fs
+func (f *Fs) CleanUp(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if fs.Config.DryRun {
+		fs.Log(f, "Not emptying trash as --dry-run")
+		return nil
+	}
+	return f.yd.EmptyTrash()
+}
+
+// PublicLink publishes the file or directory at remote and returns
+// the public link to it
+//
+// Yandex links don't expire so expire is ignored
+func (f *Fs) PublicLink(ctx context.Context, remote string, expire time.Duration) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	remotePath := f.diskRoot + remote
+	err := f.yd.Publish(remotePath)
+	if err != nil {
+		return "", err
+	}
+	var opt yandex.ResourceInfoRequestOptions
+	info, err := f.yd.NewResourceInfoRequest(remotePath, opt).Exec()
+	if err != nil {
+		return "", err
+	}
+	if info.PublicURL == "" {
+		return "", fmt.Errorf("no public link returned for %q", remote)
+	}
+	return info.PublicURL, nil
+}
+
+// About gets quota information
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	info, err := f.yd.NewDiskInfoRequest().Exec()
+	if err != nil {
+		return nil, err
+	}
+	usage := &fs.Usage{
+		Total:   fs.NewUsageValue(int64(info.TotalSpace)),
+		Used:    fs.NewUsageValue(int64(info.UsedSpace)),
+		Trashed: fs.NewUsageValue(int64(info.TrashSize)),
+		Free:    fs.NewUsageValue(int64(info.TotalSpace) - int64(info.UsedSpace)),
+	}
+	return usage, nil
+}
+
 // Purge deletes all the files and the container
 //
 // Optional interface: Only implement this if you have a way of
 // deleting all the files quicker than just running Remove() on the
 // result of List()
-func (f *Fs) Purge() error {
-	return f.purgeCheck(false)
+func (f *Fs) Purge(ctx context.Context) error {
+	return f.purgeCheck(ctx, "", false)
 }
 
 // ------------------------------------------------------------
@@ -402,8 +702,11 @@ func (o *Object) Remote() string {
 	return o.remote
 }
 
-// Md5sum returns the Md5sum of an object returning a lowercase hex string
-func (o *Object) Md5sum() (string, error) {
+// Hash returns the Md5sum of an object returning a lowercase hex string
+func (o *Object) Hash(ctx context.Context, t fs.HashType) (string, error) {
+	if t != fs.HashMD5 {
+		return "", fs.ErrHashUnsupported
+	}
 	return o.md5sum, nil
 }
 
@@ -417,8 +720,8 @@ func (o *Object) Size() int64 {
 //
 // It attempts to read the objects mtime and if that isn't present the
 // LastModified returned in the http headers
-func (o *Object) ModTime() time.Time {
-	err := o.readMetaData()
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	err := o.readMetaData(ctx)
 	if err != nil {
 		fs.Log(o, "Failed to read metadata: %s", err)
 		return time.Now()
@@ -427,19 +730,35 @@ func (o *Object) ModTime() time.Time {
 }
 
 // Open an object for read
-func (o *Object) Open() (in io.ReadCloser, err error) {
-	return o.fs.yd.Download(o.remotePath())
+//
+// Ranged reads aren't supported natively so are done by skipping
+// through the stream.
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	in, err = o.fs.yd.Download(o.remotePath())
+	if err != nil {
+		return nil, err
+	}
+	return fs.OpenOptionsReadCloser(fs.NewContextReadCloser(ctx, in), o.Size(), options...)
 }
 
 // Remove an object
-func (o *Object) Remove() error {
+func (o *Object) Remove(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	return o.fs.yd.Delete(o.remotePath(), true)
 }
 
 // SetModTime sets the modification time of the local fs object
 //
 // Commits the datastore
-func (o *Object) SetModTime(modTime time.Time) {
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) {
+	if ctx.Err() != nil {
+		return
+	}
 	remote := o.remotePath()
 	//set custom_property 'rclone_modified' of object to modTime
 	err := o.fs.yd.SetCustomProperty(remote, "rclone_modified", modTime.Format(time.RFC3339Nano))
@@ -464,7 +783,7 @@ func (o *Object) remotePath() string {
 // Copy the reader into the object updating modTime and size
 //
 // The new object may have been created if an error is returned
-func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
+func (o *Object) Update(ctx context.Context, in io.Reader, modTime time.Time, size int64, options ...fs.PutOption) error {
 	remote := o.remotePath()
 	//create full path to file before upload.
 	err1 := mkDirFullPath(o.fs.yd, remote)
@@ -473,14 +792,22 @@ func (o *Object) Update(in io.Reader, modTime time.Time, size int64) error {
 	}
 	//upload file
 	overwrite := true //overwrite existing file
-	err := o.fs.yd.Upload(in, remote, overwrite)
+	err := o.fs.yd.Upload(fs.NewContextReader(ctx, in), remote, overwrite)
 	if err == nil {
 		//if file uploaded sucessfuly then return metadata
-		o.bytes = uint64(size)
+		if size >= 0 {
+			o.bytes = uint64(size)
+		} else {
+			//size of a stream isn't known until it has been uploaded
+			err = o.readMetaData(ctx)
+			if err != nil {
+				return err
+			}
+		}
 		o.modTime = modTime
 		o.md5sum = "" // according to unit tests after put the md5 is empty.
 		//and set modTime of uploaded file
-		o.SetModTime(modTime)
+		o.SetModTime(ctx, modTime)
 	}
 	return err
 }
@@ -534,8 +861,15 @@ func mkDirFullPath(client *yandex.Client, path string) error {
 
 // Check the interfaces are satisfied
 var (
-	_ fs.Fs     = (*Fs)(nil)
-	_ fs.Purger = (*Fs)(nil)
-	//_ fs.Copier = (*Fs)(nil)
-	_ fs.Object = (*Object)(nil)
+	_ fs.Fs               = (*Fs)(nil)
+	_ fs.Purger           = (*Fs)(nil)
+	_ fs.DirMover         = (*Fs)(nil)
+	_ fs.DirLister        = (*Fs)(nil)
+	_ fs.DirMaker         = (*Fs)(nil)
+	_ fs.DirModTimeSetter = (*Fs)(nil)
+	_ fs.Abouter          = (*Fs)(nil)
+	_ fs.PublicLinker     = (*Fs)(nil)
+	_ fs.CleanUpper       = (*Fs)(nil)
+	_ fs.Copier           = (*Fs)(nil)
+	_ fs.Object           = (*Object)(nil)
 )